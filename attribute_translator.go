@@ -24,28 +24,71 @@ package velocypack
 
 import "strconv"
 
-var attributeTranslator attributeIDTranslator = &arangoAttributeIDTranslator{}
+var attributeTranslator AttributeTranslator = ArangoAttributeTranslator
 
-// attributeIDTranslator is used to translation integer style object keys to strings.
-type attributeIDTranslator interface {
+// AttributeTranslator is used to translate between integer style object keys and attribute names.
+type AttributeTranslator interface {
+	// IDToString returns the attribute name for the given integer key.
 	IDToString(id uint64) string
+	// StringToID returns the integer key for the given attribute name.
+	// It returns false if the attribute name has no integer key.
+	StringToID(name string) (uint64, bool)
 }
 
-type arangoAttributeIDTranslator struct{}
-
-func (t *arangoAttributeIDTranslator) IDToString(id uint64) string {
-	switch id {
-	case 1:
-		return "_key"
-	case 2:
-		return "_rev"
-	case 3:
-		return "_id"
-	case 4:
-		return "_from"
-	case 5:
-		return "_to"
-	default:
-		return strconv.FormatUint(id, 10)
+// ArangoAttributeTranslator translates the integer keys used by ArangoDB for its system attributes.
+var ArangoAttributeTranslator = NewAttributeTranslator(map[uint64]string{
+	1: "_key",
+	2: "_rev",
+	3: "_id",
+	4: "_from",
+	5: "_to",
+})
+
+// SetAttributeTranslator sets the AttributeTranslator that is used when no translator
+// is specified for a Builder, Dumper, Decoder or ObjectIterator.
+// By default ArangoAttributeTranslator is used.
+// Setting nil disables the translation of integer keys, which then results in a NeedAttributeTranslatorError.
+// SetAttributeTranslator is not safe for concurrent use, call it before using this package.
+func SetAttributeTranslator(t AttributeTranslator) {
+	attributeTranslator = t
+}
+
+// GetAttributeTranslator returns the AttributeTranslator that is used when no translator
+// is specified for a Builder, Dumper, Decoder or ObjectIterator.
+func GetAttributeTranslator() AttributeTranslator {
+	return attributeTranslator
+}
+
+// NewAttributeTranslator creates an AttributeTranslator for the given integer keys & attribute names.
+// Integer keys that are not in the given map are translated into their decimal representation.
+func NewAttributeTranslator(names map[uint64]string) AttributeTranslator {
+	t := &mapAttributeTranslator{
+		names: make(map[uint64]string, len(names)),
+		ids:   make(map[string]uint64, len(names)),
+	}
+	for id, name := range names {
+		t.names[id] = name
+		t.ids[name] = id
 	}
+	return t
+}
+
+// mapAttributeTranslator is an AttributeTranslator based on a fixed set of integer keys & attribute names.
+type mapAttributeTranslator struct {
+	names map[uint64]string
+	ids   map[string]uint64
+}
+
+// IDToString returns the attribute name for the given integer key.
+func (t *mapAttributeTranslator) IDToString(id uint64) string {
+	if name, found := t.names[id]; found {
+		return name
+	}
+	return strconv.FormatUint(id, 10)
+}
+
+// StringToID returns the integer key for the given attribute name.
+func (t *mapAttributeTranslator) StringToID(name string) (uint64, bool) {
+	id, found := t.ids[name]
+	return id, found
 }
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"reflect"
 )
 
@@ -35,6 +36,13 @@ type BuilderOptions struct {
 	BuildUnindexedArrays     bool
 	BuildUnindexedObjects    bool
 	CheckAttributeUniqueness bool
+	// If set, arrays & objects with 2 or 4 byte offsets are written without padding
+	// between their header and their members.
+	NoPadding bool
+	// If set, object keys known to this translator are written as integer keys
+	// and integer keys are translated with it.
+	// Otherwise integer keys are translated with the translator set with SetAttributeTranslator.
+	AttributeTranslator AttributeTranslator
 }
 
 // Builder is used to build VPack structures.
@@ -44,6 +52,9 @@ type Builder struct {
 	stack      builderStack
 	index      []indexVector
 	keyWritten bool
+	// encodeState is the state of the Marshal, MarshalTo or Encode call that
+	// is adding a value to this builder, if any.
+	encodeState *encodeState
 }
 
 func NewBuilder(capacity uint) *Builder {
@@ -201,31 +212,20 @@ func (b *Builder) Close() error {
 		// case we would win back 6 bytes but would need one byte per subvalue
 		// for the index table
 		offsetSize = 1
-
-		// Maybe we need to move down data:
-		targetPos := ValueLength(3)
-		if b.buf.Len() > (tos + 9) {
-			_len := ValueLength(b.buf.Len() - (tos + 9))
-			checkOverflow(_len)
-			src := b.buf[tos+9:]
-			copy(b.buf[tos+targetPos:], src[:_len])
-		}
-		diff := ValueLength(9 - targetPos)
-		b.buf.Shrink(uint(diff))
-		n := len(index)
-		for i := 0; i < n; i++ {
-			index[i] -= diff
-		}
-
-		// One could move down things in the offsetSize == 2 case as well,
-		// since we only need 4 bytes in the beginning. However, saving these
-		// 4 bytes has been sacrificed on the Altar of Performance.
 	} else if b.buf.Len()-tos+2*ValueLength(len(index)) <= 0xffff {
 		offsetSize = 2
 	} else if b.buf.Len()-tos+4*ValueLength(len(index)) <= 0xffffffff {
 		offsetSize = 4
 	}
 
+	// Maybe we need to move down data:
+	// One could move down things in the offsetSize == 2 case as well,
+	// since we only need 4 bytes in the beginning. However, saving these
+	// 4 bytes has been sacrificed on the Altar of Performance (unless NoPadding is set).
+	if offsetSize == 1 || (offsetSize < 8 && b.BuilderOptions.NoPadding) {
+		b.moveDownMembers(tos, ValueLength(1+2*offsetSize), index)
+	}
+
 	// Now build the table:
 	extraSpace := offsetSize * uint(len(index))
 	if offsetSize == 8 {
@@ -310,7 +310,7 @@ func (b *Builder) HasKey(key string) (bool, error) {
 	}
 	for _, idx := range index {
 		s := Slice(b.buf[tos+idx:])
-		k, err := s.makeKey()
+		k, err := s.makeKeyWith(b.keyTranslator())
 		if err != nil {
 			return false, WithStack(err)
 		}
@@ -340,7 +340,7 @@ func (b *Builder) GetKey(key string) (Slice, error) {
 	}
 	for _, idx := range index {
 		s := Slice(b.buf[tos+idx:])
-		k, err := s.makeKey()
+		k, err := s.makeKeyWith(b.keyTranslator())
 		if err != nil {
 			return nil, WithStack(err)
 		}
@@ -458,6 +458,40 @@ func (b *Builder) addBinary(v []byte) {
 	b.buf.Write(v)                // data
 }
 
+// addBCD adds a BCD value to the buffer.
+func (b *Builder) addBCD(v Decimal) {
+	m := v.mantissa()
+	digits := new(big.Int).Abs(m).String()
+	if len(digits)%2 == 1 {
+		digits = "0" + digits
+	}
+	l := uint(len(digits) / 2)
+	b.buf.ReserveSpace(1 + 8 + 4 + l)
+	if m.Sign() < 0 {
+		b.appendUInt(uint64(l), 0xcf) // negative BCD, mantissa length
+	} else {
+		b.appendUInt(uint64(l), 0xc7) // positive BCD, mantissa length
+	}
+	binary.LittleEndian.PutUint32(b.buf.Grow(4), uint32(v.Exponent)) // exponent
+	dst := b.buf.Grow(l)
+	for i := uint(0); i < l; i++ {
+		dst[i] = (digits[2*i]-'0')<<4 | (digits[2*i+1] - '0') // packed digits
+	}
+}
+
+// addTag adds the header of a tagged value to the buffer.
+func (b *Builder) addTag(tag uint64) {
+	if tag <= 0xff {
+		dst := b.buf.Grow(2)
+		dst[0] = 0xee
+		dst[1] = byte(tag)
+	} else {
+		dst := b.buf.Grow(9)
+		dst[0] = 0xef
+		setLength(dst[1:], ValueLength(tag), 8)
+	}
+}
+
 // addIllegal adds an Illegal value to the buffer.
 func (b *Builder) addIllegal() {
 	b.buf.WriteByte(0x17)
@@ -499,6 +533,28 @@ func (b *Builder) AddValue(v Value) error {
 	return nil
 }
 
+// AddTagged adds a value, tagged with the given tag, to an array/raw value/object.
+// Tag 0 means "no tag", in which case the value is added without tag.
+func (b *Builder) AddTagged(tag uint64, v Value) error {
+	if err := b.addInternal(NewTaggedValue(tag, v)); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// AddCustom adds a Custom value with given head (0xf0-0xff) and payload to an array/raw value/object.
+// See CustomSlice for the constraints on the payload length.
+func (b *Builder) AddCustom(head byte, payload []byte) error {
+	s, err := CustomSlice(head, payload)
+	if err != nil {
+		return WithStack(err)
+	}
+	if err := b.addInternal(NewSliceValue(s)); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
 // AddKeyValue adds a key+value to an open object.
 func (b *Builder) AddKeyValue(key string, v Value) error {
 	if err := b.addInternalKeyValue(key, v); err != nil {
@@ -756,7 +812,7 @@ func (b *Builder) checkAttributeUniqueness(obj Slice) error {
 
 	if obj.IsSorted() {
 		// object attributes are sorted
-		previous, err := obj.KeyAt(0)
+		previous, err := b.keyAt(obj, 0)
 		if err != nil {
 			return WithStack(err)
 		}
@@ -767,7 +823,7 @@ func (b *Builder) checkAttributeUniqueness(obj Slice) error {
 
 		// compare each two adjacent attribute names
 		for i := ValueLength(1); i < n; i++ {
-			current, err := obj.KeyAt(i)
+			current, err := b.keyAt(obj, i)
 			if err != nil {
 				return WithStack(err)
 			}
@@ -791,7 +847,7 @@ func (b *Builder) checkAttributeUniqueness(obj Slice) error {
 
 		for i := ValueLength(0); i < n; i++ {
 			// note: keyAt() already translates integer attributes
-			key, err := obj.KeyAt(i)
+			key, err := b.keyAt(obj, i)
 			if err != nil {
 				return WithStack(err)
 			}
@@ -811,7 +867,35 @@ func (b *Builder) checkAttributeUniqueness(obj Slice) error {
 	return nil
 }
 
-func findAttrName(base []byte) ([]byte, error) {
+// keyAt returns the key at given index of the given object, translated with the translator of the builder.
+func (b *Builder) keyAt(obj Slice, index ValueLength) (Slice, error) {
+	key, err := obj.KeyAt(index, false)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	key, err = key.makeKeyWith(b.keyTranslator())
+	return key, WithStack(err)
+}
+
+// keyTranslator returns the translator used for integer keys.
+func (b *Builder) keyTranslator() AttributeTranslator {
+	if b.AttributeTranslator != nil {
+		return b.AttributeTranslator
+	}
+	return attributeTranslator
+}
+
+// isKeyExpected returns true if the next value written is the key of an object.
+func (b *Builder) isKeyExpected() bool {
+	tos, stackLen := b.stack.Tos()
+	if stackLen == 0 || b.keyWritten {
+		return false
+	}
+	h := b.buf[tos]
+	return h == 0x0b || h == 0x14
+}
+
+func findAttrName(base []byte, t AttributeTranslator) ([]byte, error) {
 	b := base[0]
 	if b >= 0x40 && b <= 0xbe {
 		// short UTF-8 string
@@ -829,17 +913,18 @@ func findAttrName(base []byte) ([]byte, error) {
 	}
 
 	// translate attribute name
-	key, err := Slice(base).makeKey()
+	key, err := Slice(base).makeKeyWith(t)
 	if err != nil {
 		return nil, WithStack(err)
 	}
-	return findAttrName(key)
+	return findAttrName(key, t)
 }
 
 func (b *Builder) sortObjectIndex(objBase []byte, offsets []ValueLength) error {
 	list := make(sortEntries, len(offsets))
+	t := b.keyTranslator()
 	for i, off := range offsets {
-		name, err := findAttrName(objBase[off:])
+		name, err := findAttrName(objBase[off:], t)
 		if err != nil {
 			return WithStack(err)
 		}
@@ -856,6 +941,23 @@ func (b *Builder) sortObjectIndex(objBase []byte, offsets []ValueLength) error {
 	return nil
 }
 
+// moveDownMembers moves the members of the array or object at tos down, such that they start
+// at targetPos instead of after the 9 bytes reserved for the header.
+// The given index is updated accordingly.
+func (b *Builder) moveDownMembers(tos, targetPos ValueLength, index []ValueLength) {
+	if b.buf.Len() > (tos + 9) {
+		_len := ValueLength(b.buf.Len() - (tos + 9))
+		checkOverflow(_len)
+		src := b.buf[tos+9:]
+		copy(b.buf[tos+targetPos:], src[:_len])
+	}
+	diff := ValueLength(9 - targetPos)
+	b.buf.Shrink(uint(diff))
+	for i := range index {
+		index[i] -= diff
+	}
+}
+
 func (b *Builder) closeArray(tos ValueLength, index []ValueLength) {
 	// fix head byte in case a compact Array was originally requested:
 	b.buf[tos] = 0x06
@@ -913,29 +1015,16 @@ func (b *Builder) closeArray(tos ValueLength, index []ValueLength) {
 	}
 
 	// Maybe we need to move down data:
-	if offsetSize == 1 {
-		targetPos := ValueLength(3)
+	// One could move down things in the offsetSize == 2 case as well,
+	// since we only need 4 bytes in the beginning. However, saving these
+	// 4 bytes has been sacrificed on the Altar of Performance (unless NoPadding is set).
+	if offsetSize == 1 || (offsetSize < 8 && b.BuilderOptions.NoPadding) {
+		targetPos := ValueLength(1 + 2*offsetSize)
 		if !needIndexTable {
-			targetPos = 2
+			targetPos = ValueLength(1 + offsetSize)
 		}
-		if b.buf.Len() > (tos + 9) {
-			_len := ValueLength(b.buf.Len() - (tos + 9))
-			checkOverflow(_len)
-			src := b.buf[tos+9:]
-			copy(b.buf[tos+targetPos:], src[:_len])
-		}
-		diff := ValueLength(9 - targetPos)
-		b.buf.Shrink(uint(diff))
-		if needIndexTable {
-			n := len(index)
-			for i := 0; i < n; i++ {
-				index[i] -= diff
-			}
-		} // Note: if !needIndexTable the index array is now wrong!
+		b.moveDownMembers(tos, targetPos, index)
 	}
-	// One could move down things in the offsetSize == 2 case as well,
-	// since we only need 4 bytes in the beginning. However, saving these
-	// 4 bytes has been sacrificed on the Altar of Performance.
 
 	// Now build the table:
 	if needIndexTable {
@@ -1078,6 +1167,27 @@ func (b *Builder) addInternalKey(attrName string) (haveReported bool, err error)
 	return haveReported, nil
 }
 
+// prepareDirectValue does the bookkeeping of addInternal for a non-compound value
+// that is about to be written directly into the buffer (used by the Parser).
+func (b *Builder) prepareDirectValue(isString bool) error {
+	tos, stackLen := b.stack.Tos()
+	if stackLen == 0 {
+		return nil
+	}
+	h := b.buf[tos]
+	isObject := h == 0x0b || h == 0x14
+	if isObject && !b.keyWritten && !isString {
+		return WithStack(BuilderKeyMustBeStringError)
+	}
+	if !b.keyWritten {
+		b.reportAdd()
+	}
+	if isObject {
+		b.keyWritten = !b.keyWritten
+	}
+	return nil
+}
+
 func (b *Builder) checkKeyIsString(isString bool) error {
 	tos, stackLen := b.stack.Tos()
 	if stackLen > 0 {
@@ -1101,18 +1211,42 @@ func (b *Builder) set(item Value) error {
 	//oldPos := b.buf.Len()
 	//ctype := item.vt
 
+	translateKey := item.vt == String && !item.IsSlice() && b.AttributeTranslator != nil && b.isKeyExpected()
 	if err := b.checkKeyIsString(item.vt == String); err != nil {
 		return WithStack(err)
 	}
+	if translateKey {
+		if id, found := b.AttributeTranslator.StringToID(item.stringValue()); found {
+			b.addUInt(id)
+			return nil
+		}
+	}
+
+	if item.vt == Tagged && !item.IsSlice() {
+		// write all tags, followed by the tagged value
+		pos := b.buf.Len()
+		for item.vt == Tagged {
+			tv := item.taggedValue()
+			b.addTag(tv.tag)
+			item = tv.value
+		}
+		if err := b.setValue(item); err != nil {
+			b.buf.Shrink(uint(b.buf.Len() - pos))
+			return WithStack(err)
+		}
+		return nil
+	}
+	return b.setValue(item)
+}
 
+// setValue builds the given (non-tagged) value at the current append position.
+func (b *Builder) setValue(item Value) error {
 	if item.IsSlice() {
 		switch item.vt {
 		case None:
 			return WithStack(BuilderUnexpectedTypeError{"Cannot set a ValueType::None"})
 		case External:
 			return fmt.Errorf("External not supported")
-		case Custom:
-			return WithStack(fmt.Errorf("Cannot set a ValueType::Custom with this method"))
 		}
 		s := item.sliceValue()
 		// Determine length of slice
@@ -1178,9 +1312,11 @@ func (b *Builder) set(item Value) error {
 	case MaxKey:
 		b.addMaxKey()
 	case BCD:
-		return WithStack(fmt.Errorf("Not implemented"))
+		b.addBCD(item.bcdValue())
 	case Custom:
 		return WithStack(fmt.Errorf("Cannot set a ValueType::Custom with this method"))
+	case Tagged:
+		return WithStack(BuilderUnexpectedTypeError{"Cannot set a ValueType::Tagged with this method"})
 	}
 	return nil
 }
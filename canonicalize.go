@@ -0,0 +1,257 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"math"
+	"math/big"
+)
+
+// CanonicalizeOptions configures Canonicalize.
+type CanonicalizeOptions struct {
+	// If set, arrays are written in compact (unindexed) format.
+	// Otherwise arrays are written with an index table, unless all members have the same size.
+	UnindexedArrays bool
+	// If set, doubles & BCD values keep their type.
+	// Otherwise numbers with an integral value that fits in an int64 or uint64 are written as integer
+	// and BCD values that can be represented exactly as double are written as double.
+	KeepNumberTypes bool
+	// AttributeTranslator is used to translate integer object keys into strings.
+	// If not set, the translator set with SetAttributeTranslator is used.
+	AttributeTranslator AttributeTranslator
+}
+
+// Canonicalize re-encodes the given slice into its canonical form.
+// Slices that are equal (see Slice.Equals) result in identical canonical bytes, which makes
+// the result suitable for signatures, caching and content addressing.
+//
+// The canonical form uses:
+//   - indexed objects with members & index table sorted by attribute name and string keys only
+//     (objects with a single attribute are compact)
+//   - indexed arrays (or compact arrays when UnindexedArrays is set)
+//   - the smallest encoding for integers, strings & binary data
+//   - no padding
+//
+// Non-negative integers are written as UInt (or SmallInt), negative integers as Int (or SmallInt).
+// Doubles are written with a single encoding for NaN and without negative zero.
+// Tags are preserved.
+func Canonicalize(s Slice, options ...CanonicalizeOptions) (Slice, error) {
+	var opts CanonicalizeOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.AttributeTranslator == nil {
+		opts.AttributeTranslator = attributeTranslator
+	}
+	b := &Builder{
+		BuilderOptions: BuilderOptions{
+			BuildUnindexedArrays: opts.UnindexedArrays,
+			NoPadding:            true,
+		},
+	}
+	if err := addCanonical(b, s, opts); err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := b.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// addCanonical adds the canonical form of the given slice to the given builder.
+func addCanonical(b *Builder, s Slice, opts CanonicalizeOptions) error {
+	switch s.Type() {
+	case Array:
+		if err := b.OpenArray(); err != nil {
+			return WithStack(err)
+		}
+		it, err := NewArrayIterator(s)
+		if err != nil {
+			return WithStack(err)
+		}
+		for it.IsValid() {
+			v, err := it.Value()
+			if err != nil {
+				return WithStack(err)
+			}
+			if err := addCanonical(b, v, opts); err != nil {
+				return WithStack(err)
+			}
+			if err := it.Next(); err != nil {
+				return WithStack(err)
+			}
+		}
+		if err := b.Close(); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case Object:
+		if err := b.OpenObject(); err != nil {
+			return WithStack(err)
+		}
+		// Add attributes in sorted order, so members are stored in the same order as the index table
+		attrs, err := objectAttributes(s, opts.AttributeTranslator)
+		if err != nil {
+			return WithStack(err)
+		}
+		sortAttributes(attrs)
+		for _, attr := range attrs {
+			if err := b.AddValue(NewStringValue(string(attr.key))); err != nil {
+				return WithStack(err)
+			}
+			if err := addCanonical(b, attr.value, opts); err != nil {
+				return WithStack(err)
+			}
+		}
+		if err := b.Close(); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case Tagged:
+		// Canonicalize the tagged value separately, then add it with all its tags
+		tags := s.GetTags()
+		value, err := Canonicalize(s.UnwrapTags(), opts)
+		if err != nil {
+			return WithStack(err)
+		}
+		v := NewSliceValue(value)
+		for i := len(tags) - 1; i >= 0; i-- {
+			v = NewTaggedValue(tags[i], v)
+		}
+		if err := b.AddValue(v); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case SmallInt, Int, UInt, Double, BCD:
+		v, err := canonicalNumber(s, opts)
+		if err != nil {
+			return WithStack(err)
+		}
+		if err := b.AddValue(v); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case String:
+		v, err := s.GetString()
+		if err != nil {
+			return WithStack(err)
+		}
+		if err := b.AddValue(NewStringValue(v)); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case Binary:
+		v, err := s.GetBinary()
+		if err != nil {
+			return WithStack(err)
+		}
+		if err := b.AddValue(NewBinaryValue(v)); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	default:
+		// All other types have a single encoding
+		if err := b.AddValue(NewSliceValue(s)); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	}
+}
+
+// canonicalNumber returns a value with the canonical form of the given number slice.
+func canonicalNumber(s Slice, opts CanonicalizeOptions) (Value, error) {
+	switch {
+	case s.IsInteger():
+		v, u, isUnsigned, err := integerValue(s)
+		if err != nil {
+			return Value{}, WithStack(err)
+		}
+		return integerValueOf(v, u, isUnsigned), nil
+	case s.IsDouble():
+		f, err := s.GetDouble()
+		if err != nil {
+			return Value{}, WithStack(err)
+		}
+		if math.IsNaN(f) {
+			return NewDoubleValue(math.NaN()), nil
+		}
+		if f == 0 {
+			// Normalize -0
+			f = 0
+		}
+		if !opts.KeepNumberTypes && !math.IsInf(f, 0) && f == math.Trunc(f) {
+			if v, ok := integerValueOfRat(new(big.Rat).SetFloat64(f)); ok {
+				return v, nil
+			}
+		}
+		return NewDoubleValue(f), nil
+	default:
+		d, err := s.GetBCD()
+		if err != nil {
+			return Value{}, WithStack(err)
+		}
+		r := d.Rat()
+		if !opts.KeepNumberTypes {
+			if v, ok := integerValueOfRat(r); ok {
+				return v, nil
+			}
+			if f, exact := r.Float64(); exact {
+				return NewDoubleValue(f), nil
+			}
+		}
+		// Normalize mantissa & exponent
+		d, err = NewDecimalFromRat(r)
+		if err != nil {
+			return Value{}, WithStack(err)
+		}
+		return NewBCDValue(d), nil
+	}
+}
+
+// integerValueOf returns a UInt value for non-negative integers and an Int value otherwise.
+func integerValueOf(v int64, u uint64, isUnsigned bool) Value {
+	if isUnsigned {
+		return NewUIntValue(u)
+	}
+	if v >= 0 {
+		return NewUIntValue(uint64(v))
+	}
+	return NewIntValue(v)
+}
+
+// integerValueOfRat returns an integer value for the given rational number,
+// if it is integral and fits in an int64 or uint64.
+func integerValueOfRat(r *big.Rat) (Value, bool) {
+	if !r.IsInt() {
+		return Value{}, false
+	}
+	n := r.Num()
+	if n.IsInt64() {
+		return integerValueOf(n.Int64(), 0, false), true
+	}
+	if n.IsUint64() {
+		return integerValueOf(0, n.Uint64(), true), true
+	}
+	return Value{}, false
+}
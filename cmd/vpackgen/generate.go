@@ -0,0 +1,317 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+)
+
+// velocypackImport is the import path of the velocypack package.
+const velocypackImport = "github.com/arangodb/go-velocypack"
+
+// generator accumulates the generated source of a single file.
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// generate returns the formatted source of a file in the given package, containing
+// the methods of all given struct types.
+func generate(pkg string, structs []structType) ([]byte, error) {
+	g := &generator{imports: make(map[string]bool)}
+	for _, st := range structs {
+		g.marshal(st)
+		g.unmarshal(st)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by vpackgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	var imports []string
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	fmt.Fprintf(&out, "import (\n")
+	for _, path := range imports {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	fmt.Fprintf(&out, "\n\tvelocypack %q\n", velocypackImport)
+	fmt.Fprintf(&out, ")\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v", err)
+	}
+	return src, nil
+}
+
+// printf writes formatted code.
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// use records an import needed by the generated code.
+func (g *generator) use(path string) {
+	g.imports[path] = true
+}
+
+// marshal generates the MarshalVPackTo method of the given struct type.
+func (g *generator) marshal(st structType) {
+	g.printf("\n// MarshalVPackTo implements velocypack.BuilderMarshaler.\n")
+	g.printf("func (v %s) MarshalVPackTo(b *velocypack.Builder) error {\n", st.Name)
+	g.printf("if err := b.OpenObject(); err != nil {\nreturn err\n}\n")
+	for _, f := range st.Fields {
+		cond := g.nonEmptyCondition(f)
+		if cond != "" {
+			g.printf("if %s {\n", cond)
+		}
+		key := strconv.Quote(f.Name)
+		switch f.Kind {
+		case kindBasic:
+			g.addBasic(key, f, "v."+f.GoName)
+		case kindPtrBasic:
+			if cond != "" {
+				// Only encoded when not nil
+				g.addBasic(key, f, "*v."+f.GoName)
+				break
+			}
+			g.printf("if v.%s == nil {\n", f.GoName)
+			g.printf("if err := b.AddKeyValue(%s, velocypack.NewNullValue()); err != nil {\nreturn err\n}\n", key)
+			g.printf("} else {\n")
+			g.addBasic(key, f, "*v."+f.GoName)
+			g.printf("}\n")
+		default:
+			g.printf("if err := b.AddValue(velocypack.NewStringValue(%s)); err != nil {\nreturn err\n}\n", key)
+			g.printf("if err := velocypack.MarshalTo(b, &v.%s); err != nil {\nreturn err\n}\n", f.GoName)
+		}
+		if cond != "" {
+			g.printf("}\n")
+		}
+	}
+	g.printf("return b.Close()\n}\n")
+}
+
+// nonEmptyCondition returns the condition under which a field with the omitempty option is encoded,
+// or an empty string when the field is always encoded.
+func (g *generator) nonEmptyCondition(f structField) string {
+	if !f.OmitEmpty {
+		return ""
+	}
+	x := "v." + f.GoName
+	switch f.Empty {
+	case emptyZero:
+		switch f.Class {
+		case classBool:
+			return x
+		case classString:
+			return x + ` != ""`
+		default:
+			return x + " != 0"
+		}
+	case emptyLen:
+		return "len(" + x + ") != 0"
+	case emptyNil:
+		return x + " != nil"
+	case emptyReflect:
+		return "!velocypack.IsEmptyValue(" + x + ")"
+	}
+	return ""
+}
+
+// addBasic generates the statements adding the given basic value under the given key.
+func (g *generator) addBasic(key string, f structField, x string) {
+	if f.Quoted && f.Class == classString {
+		// Quoted strings are encoded as JSON strings, exactly like Marshal does.
+		g.printf("if err := b.AddKeyValue(%s, velocypack.NewStringValue(velocypack.QuoteString(b, %s))); err != nil {\nreturn err\n}\n", key, x)
+		return
+	}
+	g.printf("if err := b.AddKeyValue(%s, %s); err != nil {\nreturn err\n}\n", key, g.basicValue(f, x))
+}
+
+// basicValue returns an expression creating the velocypack.Value of the given basic value.
+func (g *generator) basicValue(f structField, x string) string {
+	if f.Quoted {
+		switch f.Class {
+		case classBool:
+			g.use("strconv")
+			return "velocypack.NewStringValue(strconv.FormatBool(" + x + "))"
+		case classInt:
+			g.use("strconv")
+			return "velocypack.NewStringValue(strconv.FormatInt(int64(" + x + "), 10))"
+		case classUint:
+			g.use("strconv")
+			return "velocypack.NewStringValue(strconv.FormatUint(uint64(" + x + "), 10))"
+		case classFloat:
+			g.use("strconv")
+			return "velocypack.NewStringValue(strconv.FormatFloat(float64(" + x + "), 'g', -1, 64))"
+		}
+	}
+	switch f.Class {
+	case classBool:
+		return "velocypack.NewBoolValue(" + x + ")"
+	case classInt:
+		return "velocypack.NewIntValue(int64(" + x + "))"
+	case classUint:
+		return "velocypack.NewUIntValue(uint64(" + x + "))"
+	case classFloat:
+		return "velocypack.NewDoubleValue(float64(" + x + "))"
+	default:
+		return "velocypack.NewStringValue(" + x + ")"
+	}
+}
+
+// unmarshal generates the UnmarshalVPack and UnmarshalVPackWithOptions methods of the given struct type.
+func (g *generator) unmarshal(st structType) {
+	g.use("reflect")
+	g.printf("\n// UnmarshalVPack implements velocypack.Unmarshaler.\n")
+	g.printf("func (v *%s) UnmarshalVPack(s velocypack.Slice) error {\n", st.Name)
+	g.printf("return v.UnmarshalVPackWithOptions(s, velocypack.DecoderOptions{})\n}\n")
+	g.printf("\n// UnmarshalVPackWithOptions implements velocypack.OptionsUnmarshaler.\n")
+	g.printf("func (v *%s) UnmarshalVPackWithOptions(s velocypack.Slice, options velocypack.DecoderOptions) error {\n", st.Name)
+	g.printf("if s.IsNull() {\nreturn nil\n}\n")
+	g.printf("if !s.IsObject() {\n")
+	g.printf("// Let Unmarshal report the error, using a type without methods.\n")
+	g.printf("type noMethods %s\n", st.Name)
+	g.printf("err := velocypack.UnmarshalWithOptions(s, (*noMethods)(v), options)\n")
+	g.printf("if e, ok := velocypack.Cause(err).(*velocypack.UnmarshalTypeError); ok && e.Type == reflect.TypeOf(noMethods{}) {\n")
+	g.printf("e.Type = reflect.TypeOf(*v)\n}\n")
+	g.printf("return err\n}\n")
+	if len(st.Fields) == 0 {
+		// Every key is unknown.
+		g.printf("if options.DisallowUnknownFields {\n")
+		g.printf("it, err := velocypack.NewObjectIterator(s)\nif err != nil {\nreturn err\n}\n")
+		g.printf("if it.IsValid() {\n")
+		g.printf("key, err := it.Key(true)\nif err != nil {\nreturn err\n}\n")
+		g.printf("name, err := key.GetStringUTF8()\nif err != nil {\nreturn err\n}\n")
+		g.printf("return velocypack.WithStack(&velocypack.UnknownFieldError{Field: string(name), Struct: %q})\n", st.Name)
+		g.printf("}\n}\n")
+		g.printf("return nil\n}\n")
+		return
+	}
+	g.use("strings")
+	g.printf("it, err := velocypack.NewObjectIterator(s)\nif err != nil {\nreturn err\n}\n")
+	g.printf("var firstErr error\n")
+	g.printf("saveError := func(err error, field string) {\n")
+	g.printf("if firstErr != nil {\nreturn\n}\n")
+	g.printf("if e, ok := velocypack.Cause(err).(*velocypack.UnmarshalTypeError); ok && e.Struct == \"\" && e.Field == \"\" {\n")
+	g.printf("e.Struct, e.Field = %q, field\n}\n", st.Name)
+	g.printf("firstErr = err\n}\n")
+	g.printf("for it.IsValid() {\n")
+	g.printf("key, err := it.Key(true)\nif err != nil {\nreturn err\n}\n")
+	g.printf("name, err := key.GetStringUTF8()\nif err != nil {\nreturn err\n}\n")
+	g.printf("value, err := it.Value()\nif err != nil {\nreturn err\n}\n")
+	// Find the field, preferring an exact match over a case-insensitive one.
+	g.printf("field := -1\n")
+	g.printf("switch string(name) {\n")
+	for i, f := range st.Fields {
+		g.printf("case %q:\nfield = %d\n", f.Name, i)
+	}
+	g.printf("default:\nif !options.CaseSensitive {\nswitch {\n")
+	for i, f := range st.Fields {
+		g.printf("case strings.EqualFold(string(name), %q):\nfield = %d\n", f.Name, i)
+	}
+	g.printf("}\n}\n}\n")
+	g.printf("switch field {\n")
+	for i, f := range st.Fields {
+		g.printf("case %d:\n", i)
+		g.decodeField(f)
+	}
+	g.printf("default:\n")
+	g.printf("if options.DisallowUnknownFields {\n")
+	g.printf("saveError(velocypack.WithStack(&velocypack.UnknownFieldError{Field: string(name), Struct: %q}), \"\")\n", st.Name)
+	g.printf("}\n}\n")
+	// In strict mode decoding stops at the first error.
+	g.printf("if firstErr != nil && options.Strict {\nreturn firstErr\n}\n")
+	g.printf("if err := it.Next(); err != nil {\nreturn err\n}\n")
+	g.printf("}\n")
+	g.printf("return firstErr\n}\n")
+}
+
+// decodeField generates the statements decoding value into the given field.
+func (g *generator) decodeField(f structField) {
+	x := "v." + f.GoName
+	name := strconv.Quote(f.Name)
+	if f.Quoted {
+		// The value is a string containing the JSON encoding of the field.
+		g.use("fmt")
+		g.printf("if raw, err := value.GetStringUTF8(); err != nil {\n")
+		g.printf("saveError(fmt.Errorf(\"json: invalid use of ,string struct tag, expected string, got %%s in %%s (%%v)\", value.Type(), %q, err), %s)\n", f.Type, name)
+		g.printf("} else if parsed, err := velocypack.ParseJSONFromUTF8(raw); err != nil {\n")
+		g.printf("saveError(err, %s)\n", name)
+		g.printf("} else if err := velocypack.UnmarshalWithOptions(parsed, &%s, options); err != nil {\n", x)
+		g.printf("saveError(err, %s)\n}\n", name)
+		return
+	}
+	if f.Kind == kindBasic || f.Kind == kindPtrBasic {
+		var getter, getterType, check string
+		switch f.Class {
+		case classBool:
+			getter, getterType = "value.GetBool()", "bool"
+		case classString:
+			getter, getterType = "value.GetString()", "string"
+		case classInt:
+			getter, getterType = "value.GetInt()", "int64"
+			if f.Basic != getterType {
+				check = fmt.Sprintf("int64(%s(x)) == x", f.Basic)
+			}
+		case classUint:
+			getter, getterType = "value.GetUInt()", "uint64"
+			if f.Basic != getterType {
+				check = fmt.Sprintf("uint64(%s(x)) == x", f.Basic)
+			}
+		case classFloat:
+			getter, getterType = "value.GetDouble()", "float64"
+			if f.Basic == "float32" {
+				g.use("math")
+				check = "(math.Abs(x) <= math.MaxFloat32 || math.IsInf(x, 0))"
+			}
+		}
+		cond := "err == nil"
+		if check != "" {
+			cond += " && " + check
+		}
+		// Values that do not fit the fast path are decoded by Unmarshal,
+		// which also reports the appropriate error.
+		g.printf("if x, err := %s; %s {\n", getter, cond)
+		if f.Kind == kindPtrBasic {
+			g.printf("if %s == nil {\n%s = new(%s)\n}\n", x, x, f.Basic)
+			x = "*" + x
+		}
+		if f.Basic == getterType {
+			g.printf("%s = x\n", x)
+		} else {
+			g.printf("%s = %s(x)\n", x, f.Basic)
+		}
+		g.printf("} else if err := velocypack.UnmarshalWithOptions(value, &v.%s, options); err != nil {\n", f.GoName)
+		g.printf("saveError(err, %s)\n}\n", name)
+		return
+	}
+	g.printf("if err := velocypack.UnmarshalWithOptions(value, &%s, options); err != nil {\n", x)
+	g.printf("saveError(err, %s)\n}\n", name)
+}
@@ -0,0 +1,118 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedUpToDate checks that the generated code used by the round trip
+// tests in the test package matches the output of the current generator.
+func TestGeneratedUpToDate(t *testing.T) {
+	input := filepath.Join("..", "..", "test", "vpackgen_types_test.go")
+	pkg, structs, err := parseFile(input, false)
+	if err != nil {
+		t.Fatalf("parseFile failed: %v", err)
+	}
+	src, err := generate(pkg, structs)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	expected, err := os.ReadFile(outputPath(input))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(src, expected) {
+		t.Errorf("%s is out of date, run go generate in the test directory", outputPath(input))
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	for input, expected := range map[string]string{
+		"types.go":          "types_vpack.go",
+		"dir/types_test.go": "dir/types_vpack_test.go",
+	} {
+		if got := outputPath(input); got != expected {
+			t.Errorf("outputPath(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for src, expected := range map[string]string{
+		"type T struct { Inner }":                       "embedded field Inner is not supported",
+		"type T struct { X []int `json:\"x,string\"` }": "string option on field X",
+		"type T[P any] struct { X P }":                  "generic type T is not supported",
+		"type Inner struct{}\ntype T struct { *Inner }": "embedded field Inner is not supported",
+	} {
+		path := filepath.Join(t.TempDir(), "types.go")
+		if err := os.WriteFile(path, []byte("package p\n\n"+src+"\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if _, _, err := parseFile(path, true); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: expected error containing %q, got %v", src, expected, err)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	src := "package p\n\n" +
+		"//vpackgen:generate\n" +
+		"type T struct {\n" +
+		"	A, b int\n" +
+		"	C *float32 `velocypack:\"c,omitempty\" json:\"x\"`\n" +
+		"	D string `json:\"-\"`\n" +
+		"	E Named `json:\",omitempty\"`\n" +
+		"	F1 int `json:\"f\"`\n" +
+		"	F2 int `json:\"f\"`\n" +
+		"}\n" +
+		"type U struct {}\n"
+	path := filepath.Join(t.TempDir(), "types.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	pkg, structs, err := parseFile(path, false)
+	if err != nil {
+		t.Fatalf("parseFile failed: %v", err)
+	}
+	if pkg != "p" || len(structs) != 1 || structs[0].Name != "T" {
+		t.Fatalf("unexpected result %q, %+v", pkg, structs)
+	}
+	fields := structs[0].Fields
+	if len(fields) != 3 {
+		t.Fatalf("expected 3 fields, got %+v", fields)
+	}
+	if f := fields[0]; f.Name != "A" || f.Kind != kindBasic || f.Class != classInt {
+		t.Errorf("unexpected field %+v", f)
+	}
+	if f := fields[1]; f.Name != "c" || f.Kind != kindPtrBasic || !f.OmitEmpty || f.Empty != emptyNil {
+		t.Errorf("unexpected field %+v", f)
+	}
+	if f := fields[2]; f.Name != "E" || f.Kind != kindOther || f.Empty != emptyReflect {
+		t.Errorf("unexpected field %+v", f)
+	}
+}
@@ -0,0 +1,120 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+// Command vpackgen generates reflection-free Velocypack encoding and decoding
+// methods for Go struct types.
+//
+// Usage:
+//
+//	vpackgen [-all] [-output file] file.go...
+//
+// For every struct type in the given files that is annotated with a
+//
+//	//vpackgen:generate
+//
+// comment (or every struct type when -all is given), vpackgen generates a
+// MarshalVPackTo method (implementing velocypack.BuilderMarshaler) and
+// UnmarshalVPack and UnmarshalVPackWithOptions methods (implementing
+// velocypack.Unmarshaler and velocypack.OptionsUnmarshaler).
+// The generated code is written to <file>_vpack.go next to each input file,
+// or to the file given with -output.
+//
+// The generated methods honour the `velocypack` and `json` struct tags,
+// including the omitempty and string options, the same way Marshal and
+// Unmarshal do. Fields of predeclared basic types (and pointers to them) are
+// encoded and decoded without reflection. Fields of other types are passed on
+// to velocypack.MarshalTo and velocypack.UnmarshalWithOptions.
+// The EncoderOptions and DecoderOptions of the Marshal, Unmarshal or
+// Encoder/Decoder call that invokes the generated methods are used throughout.
+//
+// Embedded struct fields without a name in their tag are not supported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	options struct {
+		all    bool
+		output string
+	}
+)
+
+func init() {
+	flag.BoolVar(&options.all, "all", false, "Generate methods for all struct types, not only annotated ones")
+	flag.StringVar(&options.output, "output", "", "Name of the generated file (only with a single input file)")
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("vpackgen: ")
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		log.Fatalln("Usage: vpackgen [-all] [-output file] file.go...")
+	}
+	if options.output != "" && len(args) > 1 {
+		log.Fatalln("-output can only be used with a single input file")
+	}
+	for _, path := range args {
+		output := options.output
+		if output == "" {
+			output = outputPath(path)
+		}
+		if err := generateFile(path, output, options.all); err != nil {
+			log.Fatalf("%s: %v\n", path, err)
+		}
+	}
+}
+
+// outputPath returns the default name of the file generated for the given input file.
+func outputPath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	if strings.HasSuffix(base, "_test") {
+		return strings.TrimSuffix(base, "_test") + "_vpack_test" + ext
+	}
+	return base + "_vpack" + ext
+}
+
+// generateFile generates the methods for the struct types found in the given input file
+// and writes them to the given output file.
+func generateFile(path, output string, all bool) error {
+	pkg, structs, err := parseFile(path, all)
+	if err != nil {
+		return err
+	}
+	if len(structs) == 0 {
+		return fmt.Errorf("no struct types to generate found")
+	}
+	src, err := generate(pkg, structs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, src, 0644)
+}
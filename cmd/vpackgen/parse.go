@@ -0,0 +1,363 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// generateDirective is the comment that marks a struct type for generation.
+const generateDirective = "//vpackgen:generate"
+
+// fieldKind describes how a field is encoded and decoded.
+type fieldKind int
+
+const (
+	// kindBasic is a field of a predeclared bool, string, integer or float type.
+	kindBasic fieldKind = iota
+	// kindPtrBasic is a pointer to a kindBasic type.
+	kindPtrBasic
+	// kindOther is any other field, handled by reflection.
+	kindOther
+)
+
+// emptyKind describes how the omitempty option tests a field for emptiness.
+type emptyKind int
+
+const (
+	emptyNever   emptyKind = iota // never empty
+	emptyZero                     // compare with zero value of a basic type
+	emptyLen                      // len(x) == 0
+	emptyNil                      // x == nil
+	emptyReflect                  // velocypack.IsEmptyValue(x)
+)
+
+// basicClass groups the predeclared basic types by their Velocypack encoding.
+type basicClass int
+
+const (
+	classBool basicClass = iota
+	classString
+	classInt
+	classUint
+	classFloat
+)
+
+// basicTypes maps the supported predeclared types to their class.
+var basicTypes = map[string]basicClass{
+	"bool":    classBool,
+	"string":  classString,
+	"int":     classInt,
+	"int8":    classInt,
+	"int16":   classInt,
+	"int32":   classInt,
+	"int64":   classInt,
+	"rune":    classInt,
+	"uint":    classUint,
+	"uint8":   classUint,
+	"uint16":  classUint,
+	"uint32":  classUint,
+	"uint64":  classUint,
+	"uintptr": classUint,
+	"byte":    classUint,
+	"float32": classFloat,
+	"float64": classFloat,
+}
+
+// structType is a struct type to generate methods for.
+type structType struct {
+	Name   string
+	Fields []structField
+}
+
+// structField is a field of a struct type that is encoded.
+type structField struct {
+	GoName    string    // Name of the Go field
+	Name      string    // Name of the object attribute
+	Type      string    // Go type of the field
+	Kind      fieldKind // How the field is encoded
+	Basic     string    // Basic type for kindBasic & kindPtrBasic
+	Class     basicClass
+	Empty     emptyKind
+	OmitEmpty bool
+	Quoted    bool
+	tagged    bool
+	index     int
+}
+
+// parseFile parses the given Go source file and returns its package name and
+// the struct types to generate methods for.
+func parseFile(path string, all bool) (string, []structType, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return "", nil, err
+	}
+	// Collect locally declared type names, since they may shadow predeclared types.
+	declared := make(map[string]bool)
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				declared[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+		}
+	}
+	var result []structType
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || ts.Assign.IsValid() {
+				continue
+			}
+			if !all && !hasDirective(gd.Doc) && !hasDirective(ts.Doc) {
+				continue
+			}
+			if ts.TypeParams != nil && len(ts.TypeParams.List) > 0 {
+				return "", nil, fmt.Errorf("%s: generic type %s is not supported", fset.Position(ts.Pos()), ts.Name.Name)
+			}
+			fields, err := parseFields(fset, st, declared)
+			if err != nil {
+				return "", nil, fmt.Errorf("%s: %v", ts.Name.Name, err)
+			}
+			result = append(result, structType{Name: ts.Name.Name, Fields: fields})
+		}
+	}
+	return f.Name.Name, result, nil
+}
+
+// hasDirective returns true if the given comment group contains the generate directive.
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == generateDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFields returns the encoded fields of the given struct type,
+// following the rules of typeFields in the velocypack package.
+func parseFields(fset *token.FileSet, st *ast.StructType, declared map[string]bool) ([]structField, error) {
+	var fields []structField
+	index := 0
+	for _, af := range st.Fields.List {
+		var tag reflect.StructTag
+		if af.Tag != nil {
+			raw, err := strconv.Unquote(af.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid tag: %v", fset.Position(af.Tag.Pos()), err)
+			}
+			tag = reflect.StructTag(raw)
+		}
+		names := af.Names
+		embedded := len(names) == 0
+		if embedded {
+			names = []*ast.Ident{ast.NewIdent(embeddedName(af.Type))}
+		}
+		for _, ident := range names {
+			index++
+			tagValue := tag.Get("velocypack")
+			if tagValue == "" {
+				tagValue = tag.Get("json")
+			}
+			if tagValue == "-" {
+				continue
+			}
+			name, opts := parseTag(tagValue)
+			if !isValidTag(name) {
+				name = ""
+			}
+			if embedded && name == "" {
+				return nil, fmt.Errorf("%s: embedded field %s is not supported without a name tag", fset.Position(af.Pos()), ident.Name)
+			}
+			if !ast.IsExported(ident.Name) {
+				continue
+			}
+			f := structField{
+				GoName:    ident.Name,
+				Name:      name,
+				Type:      typeString(fset, af.Type),
+				OmitEmpty: opts.contains("omitempty"),
+				tagged:    name != "",
+				index:     index,
+			}
+			if f.Name == "" {
+				f.Name = ident.Name
+			}
+			classifyField(&f, af.Type, declared)
+			if opts.contains("string") {
+				if f.Kind == kindOther {
+					return nil, fmt.Errorf("%s: string option on field %s requires a predeclared basic type", fset.Position(af.Pos()), ident.Name)
+				}
+				f.Quoted = true
+			}
+			fields = append(fields, f)
+		}
+	}
+	return dominantFields(fields), nil
+}
+
+// dominantFields removes fields with conflicting names.
+// Of multiple fields with the same name, only a single tagged one survives.
+func dominantFields(fields []structField) []structField {
+	byName := make(map[string][]structField)
+	for _, f := range fields {
+		byName[f.Name] = append(byName[f.Name], f)
+	}
+	var result []structField
+	for _, list := range byName {
+		if len(list) == 1 {
+			result = append(result, list[0])
+			continue
+		}
+		var tagged []structField
+		for _, f := range list {
+			if f.tagged {
+				tagged = append(tagged, f)
+			}
+		}
+		if len(tagged) == 1 {
+			result = append(result, tagged[0])
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].index < result[j].index })
+	return result
+}
+
+// classifyField sets the kind, basic type and emptiness test of the given field.
+func classifyField(f *structField, expr ast.Expr, declared map[string]bool) {
+	f.Kind = kindOther
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if class, ok := basicTypes[t.Name]; ok && !declared[t.Name] {
+			f.Kind, f.Basic, f.Class, f.Empty = kindBasic, t.Name, class, emptyZero
+			return
+		}
+		if (t.Name == "any" || t.Name == "error") && !declared[t.Name] {
+			f.Empty = emptyNil
+			return
+		}
+		f.Empty = emptyReflect
+	case *ast.StarExpr:
+		f.Empty = emptyNil
+		if id, ok := t.X.(*ast.Ident); ok {
+			if class, ok := basicTypes[id.Name]; ok && !declared[id.Name] {
+				f.Kind, f.Basic, f.Class = kindPtrBasic, id.Name, class
+			}
+		}
+	case *ast.ArrayType, *ast.MapType:
+		f.Empty = emptyLen
+	case *ast.InterfaceType:
+		f.Empty = emptyNil
+	case *ast.StructType, *ast.FuncType, *ast.ChanType:
+		f.Empty = emptyNever
+	case *ast.ParenExpr:
+		classifyField(f, t.X, declared)
+	default:
+		f.Empty = emptyReflect
+	}
+}
+
+// embeddedName returns the field name of an embedded field of the given type.
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	}
+	return ""
+}
+
+// typeString returns the source representation of the given type expression.
+func typeString(fset *token.FileSet, expr ast.Expr) string {
+	var sb strings.Builder
+	if err := printer.Fprint(&sb, fset, expr); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+// isValidTag returns true if the given tag name is valid, like isValidTag in the velocypack package.
+func isValidTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but
+			// otherwise any punctuation chars are allowed
+			// in a tag name.
+		default:
+			if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// tagOptions is the string following a comma in a struct field's tag.
+type tagOptions string
+
+// parseTag splits a struct field's tag into its name and comma-separated options.
+func parseTag(tag string) (string, tagOptions) {
+	if idx := strings.Index(tag, ","); idx != -1 {
+		return tag[:idx], tagOptions(tag[idx+1:])
+	}
+	return tag, tagOptions("")
+}
+
+// contains reports whether a comma-separated list of options contains the given option.
+func (o tagOptions) contains(optionName string) bool {
+	for _, opt := range strings.Split(string(o), ",") {
+		if opt == optionName {
+			return true
+		}
+	}
+	return false
+}
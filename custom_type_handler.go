@@ -0,0 +1,80 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+// CustomTypeConverter is used by the Decoder to convert Custom (0xf0-0xff) values into strings.
+type CustomTypeConverter interface {
+	// ToString converts the given Custom value into a string.
+	// Base is the array or object that contains the value, or nil when the value is not
+	// contained in an array or object.
+	ToString(value Slice, base Slice) (string, error)
+}
+
+// IDCustomTypeHandler is a CustomTypeHandler and CustomTypeConverter that resolves the Custom values (0xf3) used by
+// ArangoDB to store the `_id` attribute of documents.
+// Such a value contains the ID of the collection of the document as 8 byte little endian integer.
+// The collection ID is resolved into a collection name using CollectionName and the `_key` is
+// taken from the object that contains the value, resulting in "<collection-name>/<key>".
+type IDCustomTypeHandler struct {
+	// CollectionName returns the name of the collection with given ID.
+	CollectionName func(collectionID uint64) (string, error)
+}
+
+// Dump writes the given `_id` value as string.
+func (h IDCustomTypeHandler) Dump(value Slice, dumper *Dumper, base Slice) error {
+	id, err := h.ToString(value, base)
+	if err != nil {
+		return WithStack(err)
+	}
+	if err := dumper.appendString(id); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// ToString converts the given `_id` value into a string.
+func (h IDCustomTypeHandler) ToString(value Slice, base Slice) (string, error) {
+	if value.head() != 0xf3 {
+		return "", WithStack(InvalidTypeError{"Expecting custom type 0xf3"})
+	}
+	if !base.IsObject() {
+		return "", WithStack(InvalidTypeError{"Expecting _id to be contained in an object"})
+	}
+	key, err := base.Get("_key")
+	if err != nil {
+		return "", WithStack(err)
+	}
+	keyStr, err := key.GetString()
+	if err != nil {
+		return "", WithStack(err)
+	}
+	payload, err := value.GetCustomPayload()
+	if err != nil {
+		return "", WithStack(err)
+	}
+	name, err := h.CollectionName(readIntegerFixed(payload, 8))
+	if err != nil {
+		return "", WithStack(err)
+	}
+	return name + "/" + keyStr, nil
+}
@@ -0,0 +1,315 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, stored in VPack as BCD.
+// Its value is Mantissa * 10^Exponent.
+// A nil Mantissa is treated as 0.
+type Decimal struct {
+	Mantissa *big.Int
+	Exponent int32
+}
+
+var (
+	bigTen  = big.NewInt(10)
+	bigTwo  = big.NewInt(2)
+	bigFive = big.NewInt(5)
+	bigZero = big.NewInt(0)
+	bigOne  = big.NewInt(1)
+)
+
+// NewDecimalFromInt64 creates a Decimal with value v * 10^exponent.
+func NewDecimalFromInt64(v int64, exponent int32) Decimal {
+	return Decimal{Mantissa: big.NewInt(v), Exponent: exponent}
+}
+
+// ParseDecimal parses a decimal literal such as "-123.45" or "1.5e-7" into a Decimal.
+// The conversion is exact.
+func ParseDecimal(s string) (Decimal, error) {
+	invalid := func() (Decimal, error) {
+		return Decimal{}, WithStack(&ParseError{msg: fmt.Sprintf("invalid decimal literal '%s'", s)})
+	}
+	i := 0
+	negative := false
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		negative = s[i] == '-'
+		i++
+	}
+	var digits strings.Builder
+	intDigits := 0
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		digits.WriteByte(s[i])
+		intDigits++
+	}
+	fracDigits := 0
+	if i < len(s) && s[i] == '.' {
+		i++
+		for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+			digits.WriteByte(s[i])
+			fracDigits++
+		}
+	}
+	if intDigits+fracDigits == 0 {
+		return invalid()
+	}
+	exponent := int64(0)
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		e, err := strconv.ParseInt(s[i+1:], 10, 64)
+		if err != nil {
+			if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+				return Decimal{}, WithStack(NumberOutOfRangeError)
+			}
+			return invalid()
+		}
+		exponent = e
+		i = len(s)
+	}
+	if i != len(s) {
+		return invalid()
+	}
+	exponent -= int64(fracDigits)
+	if exponent > math.MaxInt32 || exponent < math.MinInt32 {
+		return Decimal{}, WithStack(NumberOutOfRangeError)
+	}
+	mantissa, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return invalid()
+	}
+	if negative {
+		mantissa.Neg(mantissa)
+	}
+	return Decimal{Mantissa: mantissa, Exponent: int32(exponent)}, nil
+}
+
+// NewDecimalFromRat converts the given rational number into a Decimal.
+// An error is returned when the number has no finite decimal representation (e.g. 1/3).
+func NewDecimalFromRat(r *big.Rat) (Decimal, error) {
+	if r == nil {
+		return Decimal{}, WithStack(InvalidBCDError)
+	}
+	// The denominator must be of the form 2^a * 5^b.
+	den := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	mod := new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(den, bigTwo, mod)
+		if m.Sign() != 0 {
+			break
+		}
+		den, twos = q, twos+1
+	}
+	for {
+		q, m := new(big.Int).QuoRem(den, bigFive, mod)
+		if m.Sign() != 0 {
+			break
+		}
+		den, fives = q, fives+1
+	}
+	if den.Cmp(bigOne) != 0 {
+		return Decimal{}, WithStack(InvalidBCDError)
+	}
+	k := twos
+	if fives > k {
+		k = fives
+	}
+	if int64(k) > math.MaxInt32 {
+		return Decimal{}, WithStack(NumberOutOfRangeError)
+	}
+	mantissa := new(big.Int).Exp(bigTen, big.NewInt(int64(k)), nil)
+	mantissa.Mul(mantissa, r.Num())
+	mantissa.Quo(mantissa, r.Denom())
+	return Decimal{Mantissa: mantissa, Exponent: int32(-k)}, nil
+}
+
+// NewDecimalFromFloat converts the given floating point number into a Decimal.
+// The conversion is exact, since every finite binary floating point number has a finite decimal representation.
+func NewDecimalFromFloat(f *big.Float) (Decimal, error) {
+	if f == nil || f.IsInf() {
+		return Decimal{}, WithStack(InvalidBCDError)
+	}
+	r, _ := f.Rat(nil)
+	d, err := NewDecimalFromRat(r)
+	if err != nil {
+		return Decimal{}, WithStack(err)
+	}
+	return d, nil
+}
+
+// mantissa returns the mantissa of the decimal, never nil.
+func (d Decimal) mantissa() *big.Int {
+	if d.Mantissa == nil {
+		return bigZero
+	}
+	return d.Mantissa
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of the decimal.
+func (d Decimal) Sign() int {
+	return d.mantissa().Sign()
+}
+
+// Rat returns the value of the decimal as a rational number.
+func (d Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.mantissa())
+	if d.Exponent == 0 {
+		return r
+	}
+	exp := int64(d.Exponent)
+	if exp < 0 {
+		exp = -exp
+	}
+	p := new(big.Rat).SetInt(new(big.Int).Exp(bigTen, big.NewInt(exp), nil))
+	if d.Exponent > 0 {
+		return r.Mul(r, p)
+	}
+	return r.Quo(r, p)
+}
+
+// Float returns the value of the decimal as a big.Float.
+// The result is rounded when the value cannot be represented exactly.
+func (d Decimal) Float() *big.Float {
+	return new(big.Float).SetRat(d.Rat())
+}
+
+// Float64 returns the nearest float64 value for the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Int returns the value of the decimal as an integer.
+// The second return value is false when the decimal has a fractional part.
+func (d Decimal) Int() (*big.Int, bool) {
+	r := d.Rat()
+	if !r.IsInt() {
+		return nil, false
+	}
+	return new(big.Int).Set(r.Num()), true
+}
+
+// String returns the decimal as an exact decimal literal that is also valid JSON.
+// Plain notation is used unless the exponent is positive or the number is very small,
+// in which case scientific notation (e.g. 1.5E+10) is used.
+func (d Decimal) String() string {
+	m := d.mantissa()
+	digits := new(big.Int).Abs(m).String()
+	var buf bytes.Buffer
+	if m.Sign() < 0 {
+		buf.WriteByte('-')
+	}
+	scale := -int64(d.Exponent)
+	adjusted := -scale + int64(len(digits)-1)
+	if scale >= 0 && adjusted >= -6 {
+		// plain notation
+		switch {
+		case scale == 0:
+			buf.WriteString(digits)
+		case int64(len(digits)) > scale:
+			buf.WriteString(digits[:int64(len(digits))-scale])
+			buf.WriteByte('.')
+			buf.WriteString(digits[int64(len(digits))-scale:])
+		default:
+			buf.WriteString("0.")
+			buf.WriteString(strings.Repeat("0", int(scale-int64(len(digits)))))
+			buf.WriteString(digits)
+		}
+		return buf.String()
+	}
+	// scientific notation
+	buf.WriteByte(digits[0])
+	if len(digits) > 1 {
+		buf.WriteByte('.')
+		buf.WriteString(digits[1:])
+	}
+	buf.WriteByte('E')
+	if adjusted >= 0 {
+		buf.WriteByte('+')
+	}
+	buf.WriteString(strconv.FormatInt(adjusted, 10))
+	return buf.String()
+}
+
+// MarshalVPack returns the decimal as a BCD slice.
+func (d Decimal) MarshalVPack() (Slice, error) {
+	var b Builder
+	if err := b.AddValue(NewBCDValue(d)); err != nil {
+		return nil, WithStack(err)
+	}
+	return b.Slice()
+}
+
+// UnmarshalVPack sets d to the value of the given BCD or integer slice.
+// Tags of the given slice are ignored.
+func (d *Decimal) UnmarshalVPack(s Slice) error {
+	s = s.UnwrapTags()
+	switch s.Type() {
+	case BCD:
+		v, err := s.GetBCD()
+		if err != nil {
+			return WithStack(err)
+		}
+		*d = v
+	case Int, SmallInt:
+		v, err := s.GetInt()
+		if err != nil {
+			return WithStack(err)
+		}
+		*d = NewDecimalFromInt64(v, 0)
+	case UInt:
+		v, err := s.GetUInt()
+		if err != nil {
+			return WithStack(err)
+		}
+		*d = Decimal{Mantissa: new(big.Int).SetUint64(v)}
+	case Double:
+		v, err := s.GetDouble()
+		if err != nil {
+			return WithStack(err)
+		}
+		if math.IsNaN(v) {
+			return WithStack(InvalidBCDError)
+		}
+		x, err := NewDecimalFromFloat(big.NewFloat(v))
+		if err != nil {
+			return WithStack(err)
+		}
+		*d = x
+	case Null:
+		// Null has no effect, like for other Go types.
+	default:
+		return WithStack(InvalidTypeError{"Expecting type BCD"})
+	}
+	return nil
+}
+
+var _ Marshaler = Decimal{}
+var _ Unmarshaler = (*Decimal)(nil)
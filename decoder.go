@@ -32,6 +32,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"reflect"
 	"runtime"
 	"strconv"
@@ -39,14 +41,92 @@ import (
 
 // A Decoder decodes velocypack values into Go structures.
 type Decoder struct {
-	r io.Reader
+	r                   io.Reader
+	customTypeHandler   CustomTypeConverter
+	attributeTranslator AttributeTranslator
+	options             DecoderOptions
 }
 
+// DecoderOptions controls how velocypack values are decoded into Go values.
+type DecoderOptions struct {
+	// If set, numbers decoded into an interface{} are stored as json.Number
+	// instead of as float64, int64 or uint64.
+	UseNumber bool
+	// If set, an object key that does not match any exported field of the
+	// destination struct results in an UnknownFieldError.
+	DisallowUnknownFields bool
+	// If set, decoding stops at the first value that cannot be stored in its
+	// destination, instead of skipping it and continuing.
+	// Values that would otherwise be ignored silently (Custom values without a
+	// custom type handler, values without a Go equivalent and array elements that
+	// do not fit in a Go array) result in an UnmarshalTypeError, as do doubles
+	// with a fraction that are decoded into an integer.
+	Strict bool
+	// If set, object keys must match struct field names exactly.
+	// Otherwise a case-insensitive match is accepted when there is no exact match.
+	CaseSensitive bool
+	// MaxDepth is the maximum nesting depth of arrays and objects.
+	// Deeper nested data results in a MaxDepthExceededError.
+	// If 0, DefaultMaxDepth is used.
+	MaxDepth int
+
+	// parent is the state of the decoding that passed these options to an
+	// OptionsUnmarshaler, if any.
+	parent *decodeState
+}
+
+// DefaultMaxDepth is the maximum nesting depth of arrays and objects used by
+// the Encoder and Decoder when no other maximum depth is set.
+const DefaultMaxDepth = 10000
+
 // Unmarshaler is implemented by types that can convert themselves from Velocypack.
 type Unmarshaler interface {
 	UnmarshalVPack(Slice) error
 }
 
+// SliceUnmarshaler is implemented by types that can convert themselves from Velocypack
+// and want to keep a reference to (parts of) the data they are given.
+// The given slice is a sub-slice of the data being decoded; it is not copied,
+// so it remains valid only as long as that data is not modified.
+// It is preferred over Unmarshaler.
+type SliceUnmarshaler interface {
+	UnmarshalVPackSlice(Slice) error
+}
+
+// OptionsUnmarshaler is implemented by types that can convert themselves from Velocypack
+// using the options of the Unmarshal call that decodes them.
+// Values nested in the given data should be decoded by passing the given options
+// to UnmarshalWithOptions, which then continues with the state of that Unmarshal call.
+// It is preferred over SliceUnmarshaler and Unmarshaler.
+// Code generated by vpackgen implements it.
+type OptionsUnmarshaler interface {
+	UnmarshalVPackWithOptions(Slice, DecoderOptions) error
+}
+
+// optionsUnmarshaler adapts an OptionsUnmarshaler to the Unmarshaler interface.
+type optionsUnmarshaler struct {
+	u OptionsUnmarshaler
+	d *decodeState
+}
+
+// UnmarshalVPack passes data on to the wrapped OptionsUnmarshaler,
+// together with the options of the decode state.
+func (o optionsUnmarshaler) UnmarshalVPack(data Slice) error {
+	options := o.d.options
+	options.parent = o.d
+	return o.u.UnmarshalVPackWithOptions(data, options)
+}
+
+// sliceUnmarshaler adapts a SliceUnmarshaler to the Unmarshaler interface.
+type sliceUnmarshaler struct {
+	u SliceUnmarshaler
+}
+
+// UnmarshalVPack passes data on to the wrapped SliceUnmarshaler.
+func (s sliceUnmarshaler) UnmarshalVPack(data Slice) error {
+	return s.u.UnmarshalVPackSlice(data)
+}
+
 // NewDecoder creates a new Decoder that reads data from the given reader.
 func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{
@@ -54,6 +134,50 @@ func NewDecoder(r io.Reader) *Decoder {
 	}
 }
 
+// SetCustomTypeHandler sets the handler used to convert Custom values into strings.
+// Without a handler, Custom values are ignored unless they are decoded into an Unmarshaler.
+func (e *Decoder) SetCustomTypeHandler(h CustomTypeConverter) {
+	e.customTypeHandler = h
+}
+
+// SetAttributeTranslator sets the translator used to translate integer object keys.
+// By default the translator set with the package level SetAttributeTranslator is used.
+func (e *Decoder) SetAttributeTranslator(t AttributeTranslator) {
+	e.attributeTranslator = t
+}
+
+// UseNumber causes the Decoder to unmarshal a number into an interface{} as a
+// json.Number instead of as a float64, int64 or uint64.
+func (e *Decoder) UseNumber() {
+	e.options.UseNumber = true
+}
+
+// DisallowUnknownFields causes the Decoder to return an error when the destination
+// is a struct and the input contains object keys which do not match any
+// non-ignored, exported fields in the destination.
+func (e *Decoder) DisallowUnknownFields() {
+	e.options.DisallowUnknownFields = true
+}
+
+// UseStrictTypes causes the Decoder to return an error as soon as a value
+// cannot be stored in its destination, instead of skipping it.
+func (e *Decoder) UseStrictTypes() {
+	e.options.Strict = true
+}
+
+// UseCaseSensitiveKeys causes the Decoder to match object keys to struct fields
+// exactly, without falling back to a case-insensitive match.
+func (e *Decoder) UseCaseSensitiveKeys() {
+	e.options.CaseSensitive = true
+}
+
+// SetMaxDepth sets the maximum nesting depth of arrays and objects that is decoded.
+// Deeper nested data results in a MaxDepthExceededError.
+// If 0 (the default), DefaultMaxDepth is used.
+func (e *Decoder) SetMaxDepth(depth int) {
+	e.options.MaxDepth = depth
+}
+
 // Unmarshal reads v from the given Velocypack encoded data slice.
 //
 // Unmarshal uses the inverse of the encodings that
@@ -66,6 +190,9 @@ func NewDecoder(r io.Reader) *Decoder {
 // the value pointed at by the pointer. If the pointer is nil, Unmarshal
 // allocates a new value for it to point to.
 //
+// To unmarshal VelocyPack into a value implementing the SliceUnmarshaler interface,
+// Unmarshal calls that value's UnmarshalVPackSlice method with the part of data
+// that holds the value, without copying it.
 // To unmarshal VelocyPack into a value implementing the Unmarshaler interface,
 // Unmarshal calls that value's UnmarshalVPack method, including
 // when the input is a VelocyPack Null.
@@ -78,6 +205,10 @@ func NewDecoder(r io.Reader) *Decoder {
 // preferring an exact match but also accepting a case-insensitive match.
 // Unmarshal will only set exported fields of the struct.
 //
+// To unmarshal a VelocyPack number into a big.Float, big.Rat or json.Number,
+// Unmarshal uses the exact value of the number. This is the preferred way
+// of reading VelocyPack BCD values.
+//
 // To unmarshal VelocyPack into an interface value,
 // Unmarshal stores one of these in the interface value:
 //
@@ -90,6 +221,8 @@ func NewDecoder(r io.Reader) *Decoder {
 //	map[string]interface{}, for VelocyPack Object's
 //	nil for VelocyPack Null.
 //	[]byte for VelocyPack Binary.
+//	Decimal for VelocyPack BCD.
+//	string for VelocyPack Custom, when decoding with a custom type handler.
 //
 // To unmarshal a VelocyPack array into a slice, Unmarshal resets the slice length
 // to zero and then appends each element to the slice.
@@ -120,8 +253,28 @@ func NewDecoder(r io.Reader) *Decoder {
 // ``not present,'' unmarshaling a VelocyPack Null into any other Go type has no effect
 // on the value and produces no error.
 //
+// Use UnmarshalWithOptions to change any of these rules.
+//
 func Unmarshal(data Slice, v interface{}) error {
-	if err := unmarshalSlice(data, v); err != nil {
+	if err := unmarshalSlice(data, v, &decodeState{}); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// UnmarshalWithOptions reads v from the given Velocypack encoded data slice,
+// like Unmarshal, using the given options.
+// When called with the options given to an OptionsUnmarshaler, the custom type handler,
+// attribute translator and nesting depth of the outer Unmarshal or Decode call are used.
+func UnmarshalWithOptions(data Slice, v interface{}, options DecoderOptions) error {
+	d := &decodeState{options: options}
+	if p := options.parent; p != nil {
+		d.options.parent = nil
+		d.customTypeHandler = p.customTypeHandler
+		d.attributeTranslator = p.attributeTranslator
+		d.depth = p.depth
+	}
+	if err := unmarshalSlice(data, v, d); err != nil {
 		return WithStack(err)
 	}
 	return nil
@@ -133,14 +286,18 @@ func (e *Decoder) Decode(v interface{}) error {
 	if err != nil {
 		return WithStack(err)
 	}
-	if err := unmarshalSlice(s, v); err != nil {
+	if err := unmarshalSlice(s, v, &decodeState{
+		customTypeHandler:   e.customTypeHandler,
+		attributeTranslator: e.attributeTranslator,
+		options:             e.options,
+	}); err != nil {
 		return WithStack(err)
 	}
 	return nil
 }
 
-// unmarshalSlice reads v from the given slice.
-func unmarshalSlice(data Slice, v interface{}) (err error) {
+// unmarshalSlice reads v from the given slice using the given decode state.
+func unmarshalSlice(data Slice, v interface{}, d *decodeState) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if _, ok := r.(runtime.Error); ok {
@@ -155,7 +312,6 @@ func unmarshalSlice(data Slice, v interface{}) (err error) {
 		return &InvalidUnmarshalError{reflect.TypeOf(v)}
 	}
 
-	d := &decodeState{}
 	// We decode rv not rv.Elem because the Unmarshaler interface
 	// test must be applied at the top level of the value.
 	d.unmarshalValue(data, rv)
@@ -168,7 +324,13 @@ var (
 )
 
 type decodeState struct {
-	useNumber    bool
+	options             DecoderOptions
+	customTypeHandler   CustomTypeConverter
+	attributeTranslator AttributeTranslator
+	// base is the array or object that contains the value that is being decoded.
+	base Slice
+	// depth is the current nesting depth of arrays and objects.
+	depth        int
 	errorContext struct { // provides context for type errors
 		Struct string
 		Field  string
@@ -183,7 +345,11 @@ func (d *decodeState) error(err error) {
 
 // saveError saves the first err it is called with,
 // for reporting at the end of the unmarshal.
+// In strict mode it aborts the decoding instead.
 func (d *decodeState) saveError(err error) {
+	if d.options.Strict {
+		d.error(err)
+	}
 	if d.savedError == nil {
 		d.savedError = d.addErrorContext(err)
 	}
@@ -197,6 +363,9 @@ func (d *decodeState) addErrorContext(err error) error {
 			err.Struct = d.errorContext.Struct
 			err.Field = d.errorContext.Field
 			return err
+		case *UnknownFieldError:
+			err.Struct = d.errorContext.Struct
+			return err
 		}
 	}
 	return err
@@ -215,12 +384,115 @@ func (d *decodeState) unmarshalValue(data Slice, v reflect.Value) {
 		d.unmarshalObject(data, v)
 	case Bool, Int, SmallInt, UInt, Double, Binary, BCD, String:
 		d.unmarshalLiteral(data, v)
+	case Tagged:
+		d.unmarshalTagged(data, v)
+	case Custom:
+		d.unmarshalCustom(data, v)
+	case Null:
+		// Null has no effect on the value
+	default:
+		if d.options.Strict {
+			d.saveError(&UnmarshalTypeError{Value: data.Type().String(), Type: v.Type()})
+		}
 	}
 }
 
+// unmarshalCustom unmarshals a custom slice into given v.
+// An Unmarshaler gets the custom slice itself. Otherwise the value is converted into
+// a string using the custom type handler and decoded as such.
+// Without a custom type handler the value is ignored.
+func (d *decodeState) unmarshalCustom(data Slice, v reflect.Value) {
+	u, _, _, _ := d.indirect(v, false)
+	if u != nil {
+		if err := u.UnmarshalVPack(data); err != nil {
+			d.error(err)
+		}
+		return
+	}
+	if d.customTypeHandler == nil {
+		if d.options.Strict {
+			d.saveError(&UnmarshalTypeError{Value: "custom", Type: v.Type()})
+		}
+		return
+	}
+	d.literalStore(StringSlice(d.customString(data)), v, false)
+}
+
+// customString converts a custom slice into a string using the custom type handler.
+func (d *decodeState) customString(data Slice) string {
+	s, err := d.customTypeHandler.ToString(data, d.base)
+	if err != nil {
+		d.error(err)
+	}
+	return s
+}
+
+// newObjectIterator creates an iterator for the given object that uses the attribute translator of the decoder.
+func (d *decodeState) newObjectIterator(data Slice) *ObjectIterator {
+	it, err := NewObjectIterator(data)
+	if err != nil {
+		d.error(err)
+	}
+	if d.attributeTranslator != nil {
+		it.SetAttributeTranslator(d.attributeTranslator)
+	}
+	return it
+}
+
+// setBase sets the array or object that contains the values that are being decoded
+// and returns a function that restores the previous one.
+func (d *decodeState) setBase(base Slice) func() {
+	previous := d.base
+	d.base = base
+	return func() { d.base = previous }
+}
+
+// enter is called when decoding of an array or object starts.
+// It aborts the decoding when the maximum depth is exceeded.
+func (d *decodeState) enter() {
+	maxDepth := d.options.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if d.depth++; d.depth > maxDepth {
+		d.error(WithStack(MaxDepthExceededError))
+	}
+}
+
+// leave is called when decoding of an array or object ends.
+func (d *decodeState) leave() {
+	d.depth--
+}
+
+// unmarshalTagged unmarshals a tagged slice into given v.
+// When decoding into an empty interface and the outermost tag is registered
+// using RegisterTaggedType, a value of the registered type is decoded.
+// Otherwise the tag is ignored and the tagged value is decoded into v.
+func (d *decodeState) unmarshalTagged(data Slice, v reflect.Value) {
+	// Check for unmarshaler, it gets the full tagged slice.
+	u, _, _, pv := d.indirect(v, false)
+	if u != nil {
+		if err := u.UnmarshalVPack(data); err != nil {
+			d.error(err)
+		}
+		return
+	}
+	tag, value := data.firstTag()
+	if pv.IsValid() && pv.Kind() == reflect.Interface && pv.NumMethod() == 0 {
+		if t, found := registeredTypeForTag(tag); found {
+			x := reflect.New(t)
+			d.unmarshalValue(value, x)
+			pv.Set(x.Elem())
+			return
+		}
+	}
+	d.unmarshalValue(value, v)
+}
+
 // indirect walks down v allocating pointers as needed,
 // until it gets to a non-pointer.
 // if it encounters an Unmarshaler, indirect stops and returns that.
+// A SliceUnmarshaler is returned wrapped as an Unmarshaler.
 // if decodingNull is true, indirect stops at the last pointer so it can be set to nil.
 func (d *decodeState) indirect(v reflect.Value, decodingNull bool) (Unmarshaler, json.Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
 	// If v is a named type and is addressable,
@@ -251,6 +523,12 @@ func (d *decodeState) indirect(v reflect.Value, decodingNull bool) (Unmarshaler,
 			v.Set(reflect.New(v.Type().Elem()))
 		}
 		if v.Type().NumMethod() > 0 {
+			if u, ok := v.Interface().(OptionsUnmarshaler); ok {
+				return optionsUnmarshaler{u, d}, nil, nil, reflect.Value{}
+			}
+			if u, ok := v.Interface().(SliceUnmarshaler); ok {
+				return sliceUnmarshaler{u}, nil, nil, reflect.Value{}
+			}
 			if u, ok := v.Interface().(Unmarshaler); ok {
 				return u, nil, nil, reflect.Value{}
 			}
@@ -270,6 +548,9 @@ func (d *decodeState) indirect(v reflect.Value, decodingNull bool) (Unmarshaler,
 
 // unmarshalArray unmarshals an array slice into given v.
 func (d *decodeState) unmarshalArray(data Slice, v reflect.Value) {
+	defer d.setBase(data)()
+	d.enter()
+	defer d.leave()
 	// Check for unmarshaler.
 	u, ju, ut, pv := d.indirect(v, false)
 	if u != nil {
@@ -345,6 +626,8 @@ func (d *decodeState) unmarshalArray(data Slice, v reflect.Value) {
 		if i < v.Len() {
 			// Decode into element.
 			d.unmarshalValue(value, v.Index(i))
+		} else if d.options.Strict {
+			d.saveError(&UnmarshalTypeError{Value: "array", Type: v.Type()})
 		}
 		i++
 		if err := it.Next(); err != nil {
@@ -370,6 +653,9 @@ func (d *decodeState) unmarshalArray(data Slice, v reflect.Value) {
 
 // unmarshalObject unmarshals an object slice into given v.
 func (d *decodeState) unmarshalObject(data Slice, v reflect.Value) {
+	defer d.setBase(data)()
+	d.enter()
+	defer d.leave()
 	// Check for unmarshaler.
 	u, ju, ut, pv := d.indirect(v, false)
 	if u != nil {
@@ -432,10 +718,7 @@ func (d *decodeState) unmarshalObject(data Slice, v reflect.Value) {
 
 	var mapElem reflect.Value
 
-	it, err := NewObjectIterator(data)
-	if err != nil {
-		d.error(err)
-	}
+	it := d.newObjectIterator(data)
 	for it.IsValid() {
 		key, err := it.Key(true)
 		if err != nil {
@@ -467,14 +750,18 @@ func (d *decodeState) unmarshalObject(data Slice, v reflect.Value) {
 			fields := cachedTypeFields(v.Type())
 			for i := range fields {
 				ff := &fields[i]
-				if bytes.Equal(ff.nameBytes, key) {
+				if bytes.Equal(ff.nameBytes, keyUTF8) {
 					f = ff
 					break
 				}
-				if f == nil && ff.equalFold(ff.nameBytes, keyUTF8) {
+				if f == nil && !d.options.CaseSensitive && ff.equalFold(ff.nameBytes, keyUTF8) {
 					f = ff
 				}
 			}
+			if f == nil && d.options.DisallowUnknownFields {
+				d.errorContext.Struct = v.Type().Name()
+				d.saveError(&UnknownFieldError{Field: string(keyUTF8)})
+			}
 			if f != nil {
 				subv = v
 				destring = f.quoted
@@ -566,16 +853,39 @@ func (d *decodeState) unmarshalLiteral(data Slice, v reflect.Value) {
 func (d *decodeState) valueInterface(data Slice) interface{} {
 	switch data.Type() {
 	case Array:
+		d.enter()
+		defer d.leave()
 		return d.arrayInterface(data)
 	case Object:
+		d.enter()
+		defer d.leave()
 		return d.objectInterface(data)
+	case Tagged:
+		return d.taggedInterface(data)
+	case Custom:
+		if d.customTypeHandler == nil {
+			return nil
+		}
+		return d.customString(data)
 	default:
 		return d.literalInterface(data)
 	}
 }
 
+// taggedInterface is like unmarshalTagged but returns interface{}.
+func (d *decodeState) taggedInterface(data Slice) interface{} {
+	tag, value := data.firstTag()
+	if t, found := registeredTypeForTag(tag); found {
+		x := reflect.New(t)
+		d.unmarshalValue(value, x)
+		return x.Elem().Interface()
+	}
+	return d.valueInterface(value)
+}
+
 // arrayInterface is like array but returns []interface{}.
 func (d *decodeState) arrayInterface(data Slice) []interface{} {
+	defer d.setBase(data)()
 	l, err := data.Length()
 	if err != nil {
 		d.error(err)
@@ -603,11 +913,9 @@ func (d *decodeState) arrayInterface(data Slice) []interface{} {
 
 // objectInterface is like object but returns map[string]interface{}.
 func (d *decodeState) objectInterface(data Slice) map[string]interface{} {
+	defer d.setBase(data)()
 	m := make(map[string]interface{})
-	it, err := NewObjectIterator(data)
-	if err != nil {
-		d.error(err)
-	}
+	it := d.newObjectIterator(data)
 	for it.IsValid() {
 		key, err := it.Key(true)
 		if err != nil {
@@ -658,6 +966,9 @@ func (d *decodeState) literalInterface(data Slice) interface{} {
 		if err != nil {
 			d.error(err)
 		}
+		if d.options.UseNumber {
+			return d.numberInterface(v)
+		}
 		return v
 
 	case Int, SmallInt:
@@ -665,6 +976,9 @@ func (d *decodeState) literalInterface(data Slice) interface{} {
 		if err != nil {
 			d.error(err)
 		}
+		if d.options.UseNumber {
+			return d.numberInterface(v)
+		}
 		intV := int(v)
 		if int64(intV) == v {
 			// Value fits in int
@@ -677,6 +991,9 @@ func (d *decodeState) literalInterface(data Slice) interface{} {
 		if err != nil {
 			d.error(err)
 		}
+		if d.options.UseNumber {
+			return d.numberInterface(v)
+		}
 		return v
 
 	case Binary:
@@ -686,6 +1003,13 @@ func (d *decodeState) literalInterface(data Slice) interface{} {
 		}
 		return v
 
+	case BCD:
+		v, err := data.GetBCD()
+		if err != nil {
+			d.error(err)
+		}
+		return d.numberInterface(v)
+
 	default: // ??
 		d.error(fmt.Errorf("unknown literal type: %s", data.Type()))
 		return nil
@@ -724,6 +1048,9 @@ func (d *decodeState) literalStore(item Slice, v reflect.Value, fromQuoted bool)
 		return
 	}
 	if ut != nil {
+		if (item.IsNumber() || item.IsBCD()) && d.bigNumberStore(item, ut) {
+			return
+		}
 		if !item.IsString() {
 			//if item[0] != '"' {
 			if fromQuoted {
@@ -854,7 +1181,7 @@ func (d *decodeState) literalStore(item Slice, v reflect.Value, fromQuoted bool)
 
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			n := int64(value)
-			if err != nil || v.OverflowInt(n) {
+			if err != nil || v.OverflowInt(n) || (d.options.Strict && float64(n) != value) {
 				d.saveError(&UnmarshalTypeError{Value: fmt.Sprintf("number %v", value), Type: v.Type()})
 				break
 			}
@@ -862,7 +1189,7 @@ func (d *decodeState) literalStore(item Slice, v reflect.Value, fromQuoted bool)
 
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 			n := uint64(value)
-			if err != nil || v.OverflowUint(n) {
+			if err != nil || v.OverflowUint(n) || (d.options.Strict && float64(n) != value) {
 				d.saveError(&UnmarshalTypeError{Value: fmt.Sprintf("number %v", value), Type: v.Type()})
 				break
 			}
@@ -1016,16 +1343,117 @@ func (d *decodeState) literalStore(item Slice, v reflect.Value, fromQuoted bool)
 			}
 		}
 
+	case BCD:
+		value, err := item.GetBCD()
+		if err != nil {
+			d.error(err)
+		}
+		switch v.Kind() {
+		default:
+			if v.Kind() == reflect.String && v.Type() == numberType {
+				v.SetString(value.String())
+				break
+			}
+			if fromQuoted {
+				d.error(fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", item, v.Type()))
+			} else {
+				d.error(&UnmarshalTypeError{Value: "number", Type: v.Type()})
+			}
+		case reflect.Interface:
+			n, err := d.convertNumber(value)
+			if err != nil {
+				d.saveError(err)
+				break
+			}
+			if v.NumMethod() != 0 {
+				d.saveError(&UnmarshalTypeError{Value: "number", Type: v.Type()})
+				break
+			}
+			v.Set(reflect.ValueOf(n))
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, ok := value.Int()
+			if !ok || !n.IsInt64() || v.OverflowInt(n.Int64()) {
+				d.saveError(&UnmarshalTypeError{Value: "number " + value.String(), Type: v.Type()})
+				break
+			}
+			v.SetInt(n.Int64())
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n, ok := value.Int()
+			if !ok || !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+				d.saveError(&UnmarshalTypeError{Value: "number " + value.String(), Type: v.Type()})
+				break
+			}
+			v.SetUint(n.Uint64())
+
+		case reflect.Float32, reflect.Float64:
+			n := value.Float64()
+			if v.OverflowFloat(n) {
+				d.saveError(&UnmarshalTypeError{Value: "number " + value.String(), Type: v.Type()})
+				break
+			}
+			v.SetFloat(n)
+		}
+
 	default: // number
 		d.error(fmt.Errorf("Unknown type %s", item.Type()))
 	}
 }
 
-// convertNumber converts the number literal s to a float64 or a Number
-// depending on the setting of d.useNumber.
+// bigNumberStore stores a numeric slice into a *big.Float or *big.Rat.
+// Returns false if the target is not one of these types.
+func (d *decodeState) bigNumberStore(item Slice, ut encoding.TextUnmarshaler) bool {
+	var r *big.Rat
+	if item.IsDouble() {
+		value, err := item.GetDouble()
+		if err != nil {
+			d.error(err)
+		}
+		if x, ok := ut.(*big.Float); ok {
+			if math.IsNaN(value) {
+				d.saveError(&UnmarshalTypeError{Value: "number NaN", Type: reflect.TypeOf(x)})
+			} else {
+				x.SetFloat64(value)
+			}
+			return true
+		}
+		if r = new(big.Rat).SetFloat64(value); r == nil {
+			d.saveError(&UnmarshalTypeError{Value: fmt.Sprintf("number %v", value), Type: reflect.TypeOf(ut)})
+			return true
+		}
+	} else {
+		value, err := item.GetBCD()
+		if err != nil {
+			d.error(err)
+		}
+		r = value.Rat()
+	}
+	switch x := ut.(type) {
+	case *big.Float:
+		x.SetRat(r)
+	case *big.Rat:
+		x.Set(r)
+	default:
+		return false
+	}
+	return true
+}
+
+// convertNumber converts the number literal s to a Number
+// depending on the setting of d.options.UseNumber.
 func (d *decodeState) convertNumber(s interface{}) (interface{}, error) {
-	if d.useNumber {
+	if d.options.UseNumber {
 		return json.Number(fmt.Sprintf("%v", s)), nil
 	}
 	return s, nil
 }
+
+// numberInterface is like convertNumber but aborts the decoding on errors.
+func (d *decodeState) numberInterface(s interface{}) interface{} {
+	n, err := d.convertNumber(s)
+	if err != nil {
+		d.error(err)
+	}
+	return n
+}
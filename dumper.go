@@ -23,8 +23,12 @@
 package velocypack
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 )
 
@@ -34,8 +38,85 @@ type DumperOptions struct {
 	// EscapeForwardSlashes turns on escapping forward slashes when serializing VPack values into JSON.
 	EscapeForwardSlashes    bool
 	UnsupportedTypeBehavior UnsupportedTypeBehavior
+	// Indent is the string used for every level of indentation.
+	// If Indent or Prefix is set, the JSON is pretty printed, with every array element
+	// and object attribute on a new line.
+	Indent string
+	// Prefix is the string written at the beginning of every new line.
+	Prefix string
+	// Newline is the string used to start a new line when pretty printing. Defaults to "\n".
+	Newline string
+	// KeyOrder determines the order in which the attributes of objects are written.
+	KeyOrder ObjectKeyOrder
+	// UTCDateBehavior determines how UTCDate values are written.
+	UTCDateBehavior UTCDateBehavior
+	// BinaryBehavior determines how Binary values are written.
+	BinaryBehavior BinaryBehavior
+	// If set, MinKey values are written as a string with this content.
+	// Otherwise they are handled according to UnsupportedTypeBehavior.
+	MinKeyString string
+	// If set, MaxKey values are written as a string with this content.
+	// Otherwise they are handled according to UnsupportedTypeBehavior.
+	MaxKeyString string
+	// If set, BCD values are written as strings instead of numbers, so they can be read
+	// without loss of precision by consumers that parse all numbers as doubles.
+	BCDAsString bool
+	// CustomTypeHandler is used to write Custom values.
+	// If not set, they are handled according to UnsupportedTypeBehavior.
+	CustomTypeHandler CustomTypeHandler
+	// AttributeTranslator is used to translate integer object keys.
+	// If not set, the translator set with SetAttributeTranslator is used.
+	AttributeTranslator AttributeTranslator
 }
 
+// UTCDateBehavior determines how the Dumper writes UTCDate values.
+type UTCDateBehavior int
+
+const (
+	// UTCDateAsUnsupportedType handles UTCDate values according to UnsupportedTypeBehavior.
+	UTCDateAsUnsupportedType UTCDateBehavior = iota
+	// UTCDateAsISO8601 writes UTCDate values as ISO-8601 strings with millisecond precision (e.g. "2017-10-25T14:30:00.000Z").
+	UTCDateAsISO8601
+	// UTCDateAsEpochMillis writes UTCDate values as the number of milliseconds since the Unix epoch.
+	UTCDateAsEpochMillis
+)
+
+// BinaryBehavior determines how the Dumper writes Binary values.
+type BinaryBehavior int
+
+const (
+	// BinaryAsUnsupportedType handles Binary values according to UnsupportedTypeBehavior.
+	BinaryAsUnsupportedType BinaryBehavior = iota
+	// BinaryAsBase64 writes Binary values as standard base64 encoded strings.
+	BinaryAsBase64
+	// BinaryAsHex writes Binary values as lowercase hexadecimal strings.
+	BinaryAsHex
+)
+
+// CustomTypeHandler is used by the Dumper to write Custom (0xf0-0xff) values.
+type CustomTypeHandler interface {
+	// Dump writes the JSON representation of the given Custom value using the given dumper,
+	// typically by calling dumper.Append with a VPack value that represents it.
+	// Base is the array or object that contains the value, or nil when the value is not
+	// contained in an array or object.
+	Dump(value Slice, dumper *Dumper, base Slice) error
+}
+
+// ObjectKeyOrder determines the order in which the Dumper writes the attributes of objects.
+type ObjectKeyOrder int
+
+const (
+	// IndexTableKeyOrder writes attributes in the order of the index table of the object.
+	// This is sorted for indexed objects and insertion order for compact objects.
+	IndexTableKeyOrder ObjectKeyOrder = iota
+	// SortedKeyOrder writes attributes sorted by key, regardless of the index table.
+	SortedKeyOrder
+	// InsertionKeyOrder writes attributes in the order in which they are stored in the object,
+	// which is the order in which they were added to the Builder, also for objects that
+	// were requested unindexed but ended up with an index table.
+	InsertionKeyOrder
+)
+
 type UnsupportedTypeBehavior int
 
 const (
@@ -48,6 +129,9 @@ type Dumper struct {
 	w           io.Writer
 	indentation uint
 	options     DumperOptions
+	pretty      bool
+	// base is the array or object that is currently being written.
+	base Slice
 }
 
 // NewDumper creates a new dumper around the given writer, with an optional options.
@@ -58,6 +142,10 @@ func NewDumper(w io.Writer, options *DumperOptions) *Dumper {
 	if options != nil {
 		d.options = *options
 	}
+	d.pretty = d.options.Indent != "" || d.options.Prefix != ""
+	if d.options.Newline == "" {
+		d.options.Newline = "\n"
+	}
 	return d
 }
 
@@ -103,6 +191,23 @@ func (d *Dumper) Append(s Slice) error {
 			return WithStack(err)
 		}
 		return nil
+	case BCD:
+		if v, err := s.GetBCD(); err != nil {
+			return WithStack(err)
+		} else if d.options.BCDAsString {
+			if err := d.appendString(v.String()); err != nil {
+				return WithStack(err)
+			}
+		} else if _, err := w.Write([]byte(v.String())); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case Tagged:
+		// Tags have no JSON representation, dump the tagged value only
+		if err := d.Append(s.UnwrapTags()); err != nil {
+			return WithStack(err)
+		}
+		return nil
 	case String:
 		if v, err := s.GetString(); err != nil {
 			return WithStack(err)
@@ -110,6 +215,37 @@ func (d *Dumper) Append(s Slice) error {
 			return WithStack(err)
 		}
 		return nil
+	case UTCDate:
+		if err := d.appendUTCDate(s); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case Binary:
+		if err := d.appendBinary(s); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case MinKey:
+		if d.options.MinKeyString == "" {
+			return WithStack(d.appendUnsupported(s))
+		} else if err := d.appendString(d.options.MinKeyString); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case MaxKey:
+		if d.options.MaxKeyString == "" {
+			return WithStack(d.appendUnsupported(s))
+		} else if err := d.appendString(d.options.MaxKeyString); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case Custom:
+		if d.options.CustomTypeHandler == nil {
+			return WithStack(d.appendUnsupported(s))
+		} else if err := d.options.CustomTypeHandler.Dump(s, d, d.base); err != nil {
+			return WithStack(err)
+		}
+		return nil
 	case Array:
 		if err := d.appendArray(s); err != nil {
 			return WithStack(err)
@@ -121,21 +257,70 @@ func (d *Dumper) Append(s Slice) error {
 		}
 		return nil
 	default:
-		switch d.options.UnsupportedTypeBehavior {
-		case NullifyUnsupportedType:
-			if _, err := w.Write([]byte("null")); err != nil {
-				return WithStack(err)
-			}
-		case ConvertUnsupportedType:
-			msg := fmt.Sprintf("(non-representable type %s)", s.Type().String())
-			if err := d.appendString(msg); err != nil {
-				return WithStack(err)
-			}
-		default:
-			return WithStack(NoJSONEquivalentError)
+		return WithStack(d.appendUnsupported(s))
+	}
+}
+
+// appendUnsupported writes a value that has no JSON equivalent, according to UnsupportedTypeBehavior.
+func (d *Dumper) appendUnsupported(s Slice) error {
+	switch d.options.UnsupportedTypeBehavior {
+	case NullifyUnsupportedType:
+		if _, err := d.w.Write([]byte("null")); err != nil {
+			return WithStack(err)
+		}
+	case ConvertUnsupportedType:
+		msg := fmt.Sprintf("(non-representable type %s)", s.Type().String())
+		if err := d.appendString(msg); err != nil {
+			return WithStack(err)
+		}
+	default:
+		return WithStack(NoJSONEquivalentError)
+	}
+	return nil
+}
+
+// appendUTCDate writes an UTCDate value according to UTCDateBehavior.
+func (d *Dumper) appendUTCDate(s Slice) error {
+	switch d.options.UTCDateBehavior {
+	case UTCDateAsISO8601:
+		v, err := s.GetUTCDate()
+		if err != nil {
+			return WithStack(err)
+		}
+		if err := d.appendString(v.UTC().Format("2006-01-02T15:04:05.000Z07:00")); err != nil {
+			return WithStack(err)
 		}
+	case UTCDateAsEpochMillis:
+		if len(s) < 9 {
+			return WithStack(InternalError)
+		}
+		if err := d.appendInt(toInt64(readIntegerFixed(s[1:], 8))); err != nil {
+			return WithStack(err)
+		}
+	default:
+		return WithStack(d.appendUnsupported(s))
 	}
+	return nil
+}
 
+// appendBinary writes a Binary value according to BinaryBehavior.
+func (d *Dumper) appendBinary(s Slice) error {
+	var encode func([]byte) string
+	switch d.options.BinaryBehavior {
+	case BinaryAsBase64:
+		encode = base64.StdEncoding.EncodeToString
+	case BinaryAsHex:
+		encode = hex.EncodeToString
+	default:
+		return WithStack(d.appendUnsupported(s))
+	}
+	v, err := s.GetBinary()
+	if err != nil {
+		return WithStack(err)
+	}
+	if err := d.appendString(encode(v)); err != nil {
+		return WithStack(err)
+	}
 	return nil
 }
 
@@ -297,12 +482,17 @@ func (d *Dumper) appendArray(v Slice) error {
 	if _, err := w.Write([]byte{'['}); err != nil {
 		return WithStack(err)
 	}
+	defer d.setBase(v)()
+	d.indentation++
 	for it.IsValid() {
 		if !it.IsFirst() {
 			if _, err := w.Write([]byte{','}); err != nil {
 				return WithStack(err)
 			}
 		}
+		if err := d.appendNewline(); err != nil {
+			return WithStack(err)
+		}
 		if value, err := it.Value(); err != nil {
 			return WithStack(err)
 		} else if err := d.Append(value); err != nil {
@@ -312,6 +502,12 @@ func (d *Dumper) appendArray(v Slice) error {
 			return WithStack(err)
 		}
 	}
+	d.indentation--
+	if !it.IsFirst() {
+		if err := d.appendNewline(); err != nil {
+			return WithStack(err)
+		}
+	}
 	if _, err := w.Write([]byte{']'}); err != nil {
 		return WithStack(err)
 	}
@@ -320,42 +516,174 @@ func (d *Dumper) appendArray(v Slice) error {
 
 func (d *Dumper) appendObject(v Slice) error {
 	w := d.w
-	it, err := NewObjectIterator(v)
+	if d.options.KeyOrder == SortedKeyOrder {
+		if err := d.appendSortedObject(v); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	}
+	it, err := d.newObjectIterator(v, d.options.KeyOrder == InsertionKeyOrder)
 	if err != nil {
 		return WithStack(err)
 	}
 	if _, err := w.Write([]byte{'{'}); err != nil {
 		return WithStack(err)
 	}
+	defer d.setBase(v)()
+	d.indentation++
 	for it.IsValid() {
-		if !it.IsFirst() {
-			if _, err := w.Write([]byte{','}); err != nil {
-				return WithStack(err)
-			}
+		key, err := it.Key(true)
+		if err != nil {
+			return WithStack(err)
 		}
-		if key, err := it.Key(true); err != nil {
+		value, err := it.Value()
+		if err != nil {
 			return WithStack(err)
-		} else if err := d.Append(key); err != nil {
+		}
+		if err := d.appendAttribute(key, value, it.IsFirst()); err != nil {
 			return WithStack(err)
 		}
-		if _, err := w.Write([]byte{':'}); err != nil {
+		if err := it.Next(); err != nil {
 			return WithStack(err)
 		}
-		if value, err := it.Value(); err != nil {
+	}
+	d.indentation--
+	if !it.IsFirst() {
+		if err := d.appendNewline(); err != nil {
 			return WithStack(err)
-		} else if err := d.Append(value); err != nil {
+		}
+	}
+	if _, err := w.Write([]byte{'}'}); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// appendSortedObject writes an object with its attributes sorted by key.
+func (d *Dumper) appendSortedObject(v Slice) error {
+	w := d.w
+	type attribute struct {
+		key   []byte
+		slice Slice
+		value Slice
+	}
+	var attributes []attribute
+	it, err := d.newObjectIterator(v, true)
+	if err != nil {
+		return WithStack(err)
+	}
+	for it.IsValid() {
+		key, err := it.Key(true)
+		if err != nil {
 			return WithStack(err)
 		}
+		keyStr, err := key.GetStringUTF8()
+		if err != nil {
+			return WithStack(err)
+		}
+		value, err := it.Value()
+		if err != nil {
+			return WithStack(err)
+		}
+		attributes = append(attributes, attribute{keyStr, key, value})
 		if err := it.Next(); err != nil {
 			return WithStack(err)
 		}
 	}
+	sort.SliceStable(attributes, func(i, j int) bool {
+		return bytes.Compare(attributes[i].key, attributes[j].key) < 0
+	})
+
+	if _, err := w.Write([]byte{'{'}); err != nil {
+		return WithStack(err)
+	}
+	defer d.setBase(v)()
+	d.indentation++
+	for i, a := range attributes {
+		if err := d.appendAttribute(a.slice, a.value, i == 0); err != nil {
+			return WithStack(err)
+		}
+	}
+	d.indentation--
+	if len(attributes) > 0 {
+		if err := d.appendNewline(); err != nil {
+			return WithStack(err)
+		}
+	}
 	if _, err := w.Write([]byte{'}'}); err != nil {
 		return WithStack(err)
 	}
 	return nil
 }
 
+// newObjectIterator creates an iterator for the given object that uses the attribute translator of the dumper.
+func (d *Dumper) newObjectIterator(v Slice, allowRandomIteration bool) (*ObjectIterator, error) {
+	it, err := NewObjectIterator(v, allowRandomIteration)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	if d.options.AttributeTranslator != nil {
+		it.SetAttributeTranslator(d.options.AttributeTranslator)
+	}
+	return it, nil
+}
+
+// setBase sets the array or object that is currently being written and
+// returns a function that restores the previous one.
+func (d *Dumper) setBase(base Slice) func() {
+	previous := d.base
+	d.base = base
+	return func() { d.base = previous }
+}
+
+// appendAttribute writes a single key+value of an object.
+func (d *Dumper) appendAttribute(key, value Slice, isFirst bool) error {
+	w := d.w
+	if !isFirst {
+		if _, err := w.Write([]byte{','}); err != nil {
+			return WithStack(err)
+		}
+	}
+	if err := d.appendNewline(); err != nil {
+		return WithStack(err)
+	}
+	if err := d.Append(key); err != nil {
+		return WithStack(err)
+	}
+	sep := []byte{':'}
+	if d.pretty {
+		sep = []byte{':', ' '}
+	}
+	if _, err := w.Write(sep); err != nil {
+		return WithStack(err)
+	}
+	if err := d.Append(value); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// appendNewline starts a new line, followed by the prefix and the current indentation,
+// if pretty printing is enabled.
+func (d *Dumper) appendNewline() error {
+	if !d.pretty {
+		return nil
+	}
+	w := d.w
+	if _, err := io.WriteString(w, d.options.Newline); err != nil {
+		return WithStack(err)
+	}
+	if _, err := io.WriteString(w, d.options.Prefix); err != nil {
+		return WithStack(err)
+	}
+	for i := uint(0); i < d.indentation; i++ {
+		if _, err := io.WriteString(w, d.options.Indent); err != nil {
+			return WithStack(err)
+		}
+	}
+	return nil
+}
+
 func dumpUnicodeCharacter(dst []byte, value uint) []byte {
 	dst = append(dst, '\\', 'u')
 
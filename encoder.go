@@ -30,17 +30,66 @@ import (
 	"encoding"
 	"encoding/json"
 	"io"
+	"math/big"
 	"reflect"
 	"runtime"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 )
 
 // An Encoder encodes Go structures into velocypack values written to an output stream.
 type Encoder struct {
-	b Builder
-	w io.Writer
+	b       Builder
+	w       io.Writer
+	options EncoderOptions
+}
+
+// EncoderOptions controls how Go values are encoded into velocypack values.
+type EncoderOptions struct {
+	// If set, all Array's will be unindexed.
+	// This results in smaller output, at the cost of slower access to array members.
+	BuildUnindexedArrays bool
+	// If set, all Objects's will be unindexed.
+	// This results in smaller output, at the cost of slower attribute lookups.
+	BuildUnindexedObjects bool
+	// If set, objects that contain the same attribute name more than once are rejected
+	// with a DuplicateAttributeNameError.
+	CheckAttributeUniqueness bool
+	// If set, the keys of a map are written in map iteration order instead of in sorted order.
+	// This is faster, but the output for a map is no longer deterministic.
+	UnsortedMapKeys bool
+	// If set, nil slices and maps are encoded as empty arrays, binary values and objects
+	// instead of as Null.
+	NilAsEmpty bool
+	// If set, string fields with the ",string" option are quoted without
+	// escaping the HTML characters <, > and &.
+	NoHTMLEscaping bool
+	// If set, a json.Number is encoded as Int or UInt when possible, otherwise as BCD.
+	// By default a json.Number is encoded as String.
+	NumbersAsNumeric bool
+	// MaxDepth is the maximum nesting depth of arrays and objects.
+	// Deeper nested values result in an UnsupportedValueError.
+	// If 0, DefaultMaxDepth is used.
+	MaxDepth int
+}
+
+// maxDepth returns the maximum nesting depth to use with the given options.
+func (o EncoderOptions) maxDepth() int {
+	if o.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return o.MaxDepth
+}
+
+// builderOptions returns the options of a Builder used to encode with the given options.
+func (o EncoderOptions) builderOptions() BuilderOptions {
+	return BuilderOptions{
+		BuildUnindexedArrays:     o.BuildUnindexedArrays,
+		BuildUnindexedObjects:    o.BuildUnindexedObjects,
+		CheckAttributeUniqueness: o.CheckAttributeUniqueness,
+	}
 }
 
 // Marshaler is implemented by types that can convert themselves into Velocypack.
@@ -48,17 +97,33 @@ type Marshaler interface {
 	MarshalVPack() (Slice, error)
 }
 
+// BuilderMarshaler is implemented by types that can add themselves to a Builder.
+// MarshalVPackTo must add exactly one value to the given builder, closing every
+// array or object it opens.
+// It is preferred over Marshaler, since it avoids allocating an intermediate Slice.
+type BuilderMarshaler interface {
+	MarshalVPackTo(b *Builder) error
+}
+
 // NewEncoder creates a new Encoder that writes output to the given writer.
-func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{
+func NewEncoder(w io.Writer, options ...EncoderOptions) *Encoder {
+	e := &Encoder{
 		w: w,
 	}
+	if len(options) > 0 {
+		e.options = options[0]
+	}
+	e.b.BuilderOptions = e.options.builderOptions()
+	return e
 }
 
 // Marshal writes the Velocypack encoding of v to a buffer and returns that buffer.
 //
 // Marshal traverses the value v recursively.
-// If an encountered value implements the Marshaler interface
+// If an encountered value implements the BuilderMarshaler interface
+// and is not a nil pointer, Marshal calls its MarshalVPackTo method
+// to add its Velocypack directly to the output.
+// Otherwise, if an encountered value implements the Marshaler interface
 // and is not a nil pointer, Marshal calls its MarshalVPack method
 // to produce Velocypack.
 // If an encountered value implements the json.Marshaler interface
@@ -76,6 +141,11 @@ func NewEncoder(w io.Writer) *Encoder {
 // Boolean values encode as Velocypack booleans.
 //
 // Floating point, integer, and Number values encode as Velocypack Int's, UInt's and Double's.
+// A json.Number encodes as a Velocypack String, unless EncoderOptions.NumbersAsNumeric is set.
+//
+// Decimal, big.Float and big.Rat values encode as Velocypack BCD.
+// A big.Rat that has no finite decimal representation causes Marshal to return
+// a MarshalerError.
 //
 // String values encode as Velocypack strings.
 //
@@ -101,52 +171,121 @@ func NewEncoder(w io.Writer) *Encoder {
 // an UnsupportedTypeError.
 //
 // Velocypack cannot represent cyclic data structures and Marshal does not
-// handle them. Passing cyclic structures to Marshal will result in
-// an infinite recursion.
+// handle them. Passing cyclic structures to Marshal results in
+// an UnsupportedValueError, as does a value with arrays and objects nested
+// deeper than DefaultMaxDepth.
+//
+// Use MarshalWithOptions to change any of these rules.
 //
-func Marshal(v interface{}) (result Slice, err error) {
+func Marshal(v interface{}) (Slice, error) {
+	return MarshalWithOptions(v, EncoderOptions{})
+}
+
+// MarshalWithOptions writes the Velocypack encoding of v to a buffer, like Marshal,
+// using the given options, and returns that buffer.
+func MarshalWithOptions(v interface{}, options EncoderOptions) (result Slice, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			if _, ok := r.(runtime.Error); ok {
-				panic(r)
-			}
-			if s, ok := r.(string); ok {
-				panic(s)
-			}
-			err = r.(error)
+			err = encodeError(r)
 		}
 	}()
-	var b Builder
-	reflectValue(&b, reflect.ValueOf(v), encoderOptions{})
+	b := Builder{BuilderOptions: options.builderOptions()}
+	encodeTo(&b, v, newEncoderOptions(options))
 	return b.Slice()
 }
 
+// MarshalTo adds the Velocypack encoding of v to the given builder,
+// following the same rules as Marshal.
+// The builder may contain an open array or object, in which case the
+// encoding of v is added as a member.
+// When MarshalTo is called from the MarshalVPackTo method of a value that is
+// being encoded into the same builder, it continues that encoding, using its
+// options, maximum depth and cycle detection.
+// Otherwise MarshalTo uses the default EncoderOptions, use MarshalToWithOptions
+// to encode with other options.
+// When an error is returned, the builder may contain a partial encoding of v.
+func MarshalTo(b *Builder, v interface{}) (err error) {
+	state := b.encodeState
+	if state == nil {
+		return MarshalToWithOptions(b, v, EncoderOptions{})
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = encodeError(r)
+		}
+	}()
+	reflectValue(b, reflect.ValueOf(v), encoderOptions{state: state})
+	return nil
+}
+
+// MarshalToWithOptions adds the Velocypack encoding of v to the given builder,
+// like MarshalTo, using the given options.
+// BuildUnindexedArrays, BuildUnindexedObjects and CheckAttributeUniqueness are
+// applied to the given builder in addition to its own options while v is encoded.
+func MarshalToWithOptions(b *Builder, v interface{}, options EncoderOptions) (err error) {
+	defer func(previous BuilderOptions) {
+		b.BuilderOptions = previous
+	}(b.BuilderOptions)
+	defer func() {
+		if r := recover(); r != nil {
+			err = encodeError(r)
+		}
+	}()
+	b.BuildUnindexedArrays = b.BuildUnindexedArrays || options.BuildUnindexedArrays
+	b.BuildUnindexedObjects = b.BuildUnindexedObjects || options.BuildUnindexedObjects
+	b.CheckAttributeUniqueness = b.CheckAttributeUniqueness || options.CheckAttributeUniqueness
+	if state := b.encodeState; state != nil {
+		// Continue the encoding in progress with the given options.
+		defer func(previous EncoderOptions, maxDepth int) {
+			state.options, state.maxDepth = previous, maxDepth
+		}(state.options, state.maxDepth)
+		state.options, state.maxDepth = options, options.maxDepth()
+		reflectValue(b, reflect.ValueOf(v), encoderOptions{state: state})
+		return nil
+	}
+	encodeTo(b, v, newEncoderOptions(options))
+	return nil
+}
+
 // Encode writes the Velocypack encoding of v to the stream.
 func (e *Encoder) Encode(v interface{}) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			if _, ok := r.(runtime.Error); ok {
-				panic(r)
-			}
-			if s, ok := r.(string); ok {
-				panic(s)
-			}
-			err = r.(error)
+			err = encodeError(r)
 		}
 	}()
 	e.b.Clear()
-	reflectValue(&e.b, reflect.ValueOf(v), encoderOptions{})
+	encodeTo(&e.b, v, newEncoderOptions(e.options))
 	if _, err := e.b.WriteTo(e.w); err != nil {
 		return WithStack(err)
 	}
 	return nil
 }
 
+// SetMaxDepth sets the maximum nesting depth of arrays and objects that is encoded.
+// Deeper nested values result in an UnsupportedValueError.
+// If 0 (the default), DefaultMaxDepth is used.
+func (e *Encoder) SetMaxDepth(depth int) {
+	e.options.MaxDepth = depth
+}
+
 // Builder returns a reference to the builder used in the given encoder.
 func (e *Encoder) Builder() *Builder {
 	return &e.b
 }
 
+// QuoteString returns the JSON encoding of s, like it is stored for a string field with the
+// ",string" option by the Marshal, MarshalTo or Encode call that is adding a value to the given builder.
+func QuoteString(b *Builder, s string) string {
+	noHTMLEscaping := b.encodeState != nil && b.encodeState.options.NoHTMLEscaping
+	return quoteString(s, noHTMLEscaping)
+}
+
+// IsEmptyValue returns true if v is empty in the sense of the omitempty struct tag option.
+func IsEmptyValue(v interface{}) bool {
+	return isEmptyValue(reflect.ValueOf(v))
+}
+
 func isEmptyValue(v reflect.Value) bool {
 	switch v.Kind() {
 	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
@@ -169,8 +308,173 @@ func reflectValue(b *Builder, v reflect.Value, options encoderOptions) {
 	valueEncoder(v)(b, v, options)
 }
 
+// encodeTo adds the encoding of v to the given builder.
+// While v is being encoded, the builder refers to the encoding state, so
+// MarshalTo calls from MarshalVPackTo methods can continue with it.
+func encodeTo(b *Builder, v interface{}, options encoderOptions) {
+	b.encodeState = options.state
+	defer func() {
+		b.encodeState = nil
+	}()
+	reflectValue(b, reflect.ValueOf(v), options)
+}
+
 type encoderOptions struct {
 	quoted bool
+	state  *encodeState
+}
+
+// newEncoderOptions returns the options for encoding a single value
+// with the given options.
+func newEncoderOptions(options EncoderOptions) encoderOptions {
+	return encoderOptions{state: &encodeState{options: options, maxDepth: options.maxDepth()}}
+}
+
+// parserOptions returns the options used to convert JSON into velocypack
+// while encoding.
+func (o encoderOptions) parserOptions() ParserOptions {
+	return ParserOptions{
+		BuildUnindexedArrays:     o.state.options.BuildUnindexedArrays,
+		BuildUnindexedObjects:    o.state.options.BuildUnindexedObjects,
+		CheckAttributeUniqueness: o.state.options.CheckAttributeUniqueness,
+	}
+}
+
+// addNil adds the encoding of a nil slice or map, which is encoded as
+// a value of type t, to the given builder.
+func (o encoderOptions) addNil(b *Builder, t ValueType) {
+	if !o.state.options.NilAsEmpty {
+		b.addInternal(nullValue)
+		return
+	}
+	switch t {
+	case Binary:
+		b.addInternal(NewBinaryValue(nil))
+	case Array:
+		if err := b.OpenArray(); err != nil {
+			panic(err)
+		}
+		if err := b.Close(); err != nil {
+			panic(err)
+		}
+	case Object:
+		if err := b.OpenObject(); err != nil {
+			panic(err)
+		}
+		if err := b.Close(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// startDetectingCyclesAfter is the number of nested pointers, maps and slices
+// after which the encoder starts to keep track of the values it has visited.
+// Tracking them is expensive, so it is only done for deeply nested values.
+const startDetectingCyclesAfter = 1000
+
+// encodeState holds the state of encoding a single value.
+type encodeState struct {
+	options EncoderOptions
+	// depth is the current nesting depth of arrays and objects.
+	depth    int
+	maxDepth int
+	// ptrLevel is the current number of nested pointers, maps and slices.
+	// Once it exceeds startDetectingCyclesAfter, ptrSeen is used to detect cycles.
+	ptrLevel int
+	ptrSeen  map[visitKey]struct{}
+}
+
+// enter is called before an array or object is encoded for v.
+// It panics with an UnsupportedValueError when the maximum depth is exceeded.
+func (s *encodeState) enter(v reflect.Value) {
+	s.depth++
+	if s.depth > s.maxDepth {
+		panic(&UnsupportedValueError{Value: v, Str: "exceeds maximum depth of " + strconv.Itoa(s.maxDepth)})
+	}
+}
+
+// leave is called after an array or object has been encoded.
+func (s *encodeState) leave() {
+	s.depth--
+}
+
+// visitKey identifies a pointer, map or slice for cycle detection.
+// A slice is identified by its first element and its length,
+// since different slices may share the same underlying array.
+type visitKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+func newVisitKey(v reflect.Value) visitKey {
+	key := visitKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	return key
+}
+
+// visit is called before the pointer, map or slice v is followed.
+// It panics with an UnsupportedValueError when v is already being encoded
+// further up, which means that the value is cyclic.
+// Every call must be matched by a call to unvisit.
+func (s *encodeState) visit(v reflect.Value) {
+	s.ptrLevel++
+	if s.ptrLevel > startDetectingCyclesAfter {
+		key := newVisitKey(v)
+		if s.ptrSeen == nil {
+			s.ptrSeen = make(map[visitKey]struct{})
+		}
+		if _, found := s.ptrSeen[key]; found {
+			// Like encoding/json, leave out the path, which would be very long.
+			panic(&UnsupportedValueError{Value: v, Str: "encountered a cycle via " + v.Type().String(), noPath: true})
+		}
+		s.ptrSeen[key] = struct{}{}
+	}
+}
+
+// unvisit is called after the pointer, map or slice given to visit has been encoded.
+func (s *encodeState) unvisit(v reflect.Value) {
+	if s.ptrLevel > startDetectingCyclesAfter {
+		delete(s.ptrSeen, newVisitKey(v))
+	}
+	s.ptrLevel--
+}
+
+// addPathSegment adds the given segment to the path of r, when r is an
+// UnsupportedValueError, and continues panicking with r.
+// Nothing is done when r is nil.
+func addPathSegment(r interface{}, seg pathSegment) {
+	if r == nil {
+		return
+	}
+	if e, ok := r.(*UnsupportedValueError); ok && !e.noPath {
+		e.segments = append(e.segments, seg)
+	}
+	panic(r)
+}
+
+// encodeError returns the error for a value recovered from a panic during encoding.
+// Runtime errors and string panics are not caused by the value being encoded,
+// so panicking continues for those.
+func encodeError(r interface{}) error {
+	if _, ok := r.(runtime.Error); ok {
+		panic(r)
+	}
+	if s, ok := r.(string); ok {
+		panic(s)
+	}
+	if e, ok := r.(*UnsupportedValueError); ok && len(e.segments) > 0 {
+		// Segments were added while unwinding, innermost first.
+		segments := make([]pathSegment, len(e.segments))
+		for i, seg := range e.segments {
+			segments[len(segments)-1-i] = seg
+		}
+		e.Path = formatPath(segments)
+		e.segments = nil
+	}
+	return r.(error)
 }
 
 type encoderFunc func(b *Builder, v reflect.Value, options encoderOptions)
@@ -180,6 +484,13 @@ var encoderCache struct {
 	m map[reflect.Type]encoderFunc
 }
 
+// clearEncoderCache removes all cached encoders.
+func clearEncoderCache() {
+	encoderCache.Lock()
+	encoderCache.m = nil
+	encoderCache.Unlock()
+}
+
 func valueEncoder(v reflect.Value) encoderFunc {
 	if !v.IsValid() {
 		return invalidValueEncoder
@@ -188,10 +499,13 @@ func valueEncoder(v reflect.Value) encoderFunc {
 }
 
 var (
-	marshalerType     = reflect.TypeOf(new(Marshaler)).Elem()
-	jsonMarshalerType = reflect.TypeOf(new(json.Marshaler)).Elem()
-	textMarshalerType = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
-	nullValue         = NewNullValue()
+	builderMarshalerType = reflect.TypeOf(new(BuilderMarshaler)).Elem()
+	marshalerType        = reflect.TypeOf(new(Marshaler)).Elem()
+	jsonMarshalerType    = reflect.TypeOf(new(json.Marshaler)).Elem()
+	textMarshalerType    = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
+	bigFloatType         = reflect.TypeOf(big.Float{})
+	bigRatType           = reflect.TypeOf(big.Rat{})
+	nullValue            = NewNullValue()
 )
 
 func typeEncoder(t reflect.Type) encoderFunc {
@@ -221,6 +535,9 @@ func typeEncoder(t reflect.Type) encoderFunc {
 	// Compute fields without lock.
 	// Might duplicate effort but won't hold other computations back.
 	f = newTypeEncoder(t, true)
+	if tag, found := registeredTagForType(t); found {
+		f = newTaggedEncoder(tag, f)
+	}
 	wg.Done()
 	encoderCache.Lock()
 	encoderCache.m[t] = f
@@ -231,6 +548,14 @@ func typeEncoder(t reflect.Type) encoderFunc {
 // newTypeEncoder constructs an encoderFunc for a type.
 // The returned encoder only checks CanAddr when allowAddr is true.
 func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
+	if t.Implements(builderMarshalerType) {
+		return builderMarshalerEncoder
+	}
+	if t.Kind() != reflect.Ptr && allowAddr {
+		if reflect.PtrTo(t).Implements(builderMarshalerType) {
+			return newCondAddrEncoder(addrBuilderMarshalerEncoder, newTypeEncoder(t, false))
+		}
+	}
 	if t.Implements(marshalerType) {
 		return marshalerEncoder
 	}
@@ -246,6 +571,13 @@ func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
 		}
 	}
 
+	switch t {
+	case bigFloatType, bigRatType, reflect.PtrTo(bigFloatType), reflect.PtrTo(bigRatType):
+		return bigNumberEncoder
+	case numberType:
+		return numberEncoder
+	}
+
 	if t.Implements(textMarshalerType) {
 		return textMarshalerEncoder
 	}
@@ -287,6 +619,59 @@ func invalidValueEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 	b.addInternal(nullValue)
 }
 
+func builderMarshalerEncoder(b *Builder, v reflect.Value, options encoderOptions) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		b.addInternal(nullValue)
+		return
+	}
+	m, ok := v.Interface().(BuilderMarshaler)
+	if !ok {
+		b.addInternal(nullValue)
+		return
+	}
+	if v.Kind() == reflect.Ptr {
+		options.state.visit(v)
+		defer options.state.unvisit(v)
+	}
+	marshalTo(b, m, v, options)
+}
+
+func addrBuilderMarshalerEncoder(b *Builder, v reflect.Value, options encoderOptions) {
+	va := v.Addr()
+	if va.IsNil() {
+		b.addInternal(nullValue)
+		return
+	}
+	options.state.visit(va)
+	defer options.state.unvisit(va)
+	marshalTo(b, va.Interface().(BuilderMarshaler), v, options)
+}
+
+// marshalTo calls MarshalVPackTo on the given marshaler of v and checks that it
+// added a single, closed value to the builder.
+// The value added by the marshaler counts as one level of nesting.
+func marshalTo(b *Builder, m BuilderMarshaler, v reflect.Value, options encoderOptions) {
+	t := v.Type()
+	options.state.enter(v)
+	depth, size := b.stack.Len(), b.buf.Len()
+	if err := m.MarshalVPackTo(b); err != nil {
+		if e, ok := Cause(err).(*UnsupportedValueError); ok {
+			// Raised by a nested MarshalTo call. The path within the value
+			// added by the marshaler is unknown.
+			e.Path, e.segments, e.noPath = "", nil, true
+			panic(e)
+		}
+		panic(&MarshalerError{t, err})
+	}
+	if b.stack.Len() != depth {
+		panic(&MarshalerError{t, WithStack(BuilderNotClosedError)})
+	}
+	if b.buf.Len() == size {
+		panic(&MarshalerError{t, WithStack(BuilderNeedSubValueError)})
+	}
+	options.state.leave()
+}
+
 func marshalerEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 	if v.Kind() == reflect.Ptr && v.IsNil() {
 		b.addInternal(nullValue)
@@ -304,6 +689,27 @@ func marshalerEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 	}
 }
 
+// taggedEncoder encodes values of a type registered with RegisterTaggedType.
+type taggedEncoder struct {
+	tag     uint64
+	elemEnc encoderFunc
+}
+
+func (te *taggedEncoder) encode(b *Builder, v reflect.Value, options encoderOptions) {
+	vb := Builder{BuilderOptions: b.BuilderOptions, encodeState: options.state}
+	te.elemEnc(&vb, v, options)
+	vpack, err := vb.Slice()
+	if err != nil {
+		panic(err)
+	}
+	b.addInternal(NewTaggedValue(te.tag, NewSliceValue(vpack)))
+}
+
+func newTaggedEncoder(tag uint64, elemEnc encoderFunc) encoderFunc {
+	enc := &taggedEncoder{tag: tag, elemEnc: elemEnc}
+	return enc.encode
+}
+
 func jsonMarshalerEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 	if v.Kind() == reflect.Ptr && v.IsNil() {
 		b.addInternal(nullValue)
@@ -318,7 +724,7 @@ func jsonMarshalerEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 		panic(&MarshalerError{v.Type(), err})
 	} else {
 		// Convert JSON to vpack
-		if slice, err := ParseJSON(bytes.NewReader(json)); err != nil {
+		if slice, err := ParseJSON(bytes.NewReader(json), options.parserOptions()); err != nil {
 			panic(&MarshalerError{v.Type(), err})
 		} else {
 			b.addInternal(NewSliceValue(slice))
@@ -423,12 +829,75 @@ func doubleEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 func stringEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 	s := v.String()
 	if options.quoted {
-		raw, _ := json.Marshal(s)
-		s = string(raw)
+		s = quoteString(s, options.state.options.NoHTMLEscaping)
 	}
 	b.addInternal(NewStringValue(s))
 }
 
+// quoteString returns the JSON encoding of s, escaping HTML characters unless noHTMLEscaping is set.
+func quoteString(s string, noHTMLEscaping bool) string {
+	if noHTMLEscaping {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(s)
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+	raw, _ := json.Marshal(s)
+	return string(raw)
+}
+
+// numberEncoder encodes a json.Number as String, or with the NumbersAsNumeric option
+// as Int or UInt when possible, otherwise as BCD.
+func numberEncoder(b *Builder, v reflect.Value, options encoderOptions) {
+	if !options.state.options.NumbersAsNumeric {
+		stringEncoder(b, v, options)
+		return
+	}
+	n := v.String()
+	if n == "" {
+		n = "0"
+	}
+	if options.quoted {
+		b.addInternal(NewStringValue(n))
+	} else if x, err := strconv.ParseInt(n, 10, 64); err == nil {
+		b.addInternal(NewIntValue(x))
+	} else if x, err := strconv.ParseUint(n, 10, 64); err == nil {
+		b.addInternal(NewUIntValue(x))
+	} else if x, err := ParseDecimal(n); err == nil {
+		b.addInternal(NewBCDValue(x))
+	} else {
+		panic(&MarshalerError{v.Type(), err})
+	}
+}
+
+// bigNumberEncoder encodes big.Float and big.Rat values (or pointers to them) as BCD.
+func bigNumberEncoder(b *Builder, v reflect.Value, options encoderOptions) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			b.addInternal(nullValue)
+			return
+		}
+		v = v.Elem()
+	}
+	var d Decimal
+	var err error
+	switch x := v.Interface().(type) {
+	case big.Float:
+		d, err = NewDecimalFromFloat(&x)
+	case big.Rat:
+		d, err = NewDecimalFromRat(&x)
+	}
+	if err != nil {
+		panic(&MarshalerError{v.Type(), err})
+	}
+	if options.quoted {
+		b.addInternal(NewStringValue(d.String()))
+	} else {
+		b.addInternal(NewBCDValue(d))
+	}
+}
+
 func interfaceEncoder(b *Builder, v reflect.Value, options encoderOptions) {
 	if v.IsNil() {
 		b.addInternal(nullValue)
@@ -448,6 +917,13 @@ type structEncoder struct {
 }
 
 func (se *structEncoder) encode(b *Builder, v reflect.Value, options encoderOptions) {
+	options.state.enter(v)
+	current := -1
+	defer func() {
+		if current >= 0 {
+			addPathSegment(recover(), pathSegment{kind: pathSegmentAttribute, attribute: se.fields[current].name})
+		}
+	}()
 	if err := b.OpenObject(); err != nil {
 		panic(err)
 	}
@@ -463,11 +939,14 @@ func (se *structEncoder) encode(b *Builder, v reflect.Value, options encoderOpti
 		}
 		// Value
 		options.quoted = f.quoted
+		current = i
 		se.fieldEncs[i](b, fv, options)
 	}
+	current = -1
 	if err := b.Close(); err != nil {
 		panic(err)
 	}
+	options.state.leave()
 }
 
 func newStructEncoder(t reflect.Type) encoderFunc {
@@ -488,9 +967,11 @@ type mapEncoder struct {
 
 func (e *mapEncoder) encode(b *Builder, v reflect.Value, options encoderOptions) {
 	if v.IsNil() {
-		b.addInternal(nullValue)
+		options.addNil(b, Object)
 		return
 	}
+	options.state.visit(v)
+	options.state.enter(v)
 	if err := b.OpenObject(); err != nil {
 		panic(err)
 	}
@@ -504,20 +985,32 @@ func (e *mapEncoder) encode(b *Builder, v reflect.Value, options encoderOptions)
 			panic(&MarshalerError{v.Type(), err})
 		}
 	}
-	sort.Sort(sv)
+	if !options.state.options.UnsortedMapKeys {
+		sort.Sort(sv)
+	}
 
-	for _, kv := range sv {
+	current := -1
+	defer func() {
+		if current >= 0 {
+			addPathSegment(recover(), pathSegment{kind: pathSegmentAttribute, attribute: sv[current].s})
+		}
+	}()
+	for i, kv := range sv {
 		// Key
 		_, err := b.addInternalKey(kv.s)
 		if err != nil {
 			panic(err)
 		}
 		// Value
+		current = i
 		e.elemEnc(b, v.MapIndex(kv.v), options)
 	}
+	current = -1
 	if err := b.Close(); err != nil {
 		panic(err)
 	}
+	options.state.leave()
+	options.state.unvisit(v)
 }
 
 func newMapEncoder(t reflect.Type) encoderFunc {
@@ -536,7 +1029,7 @@ func newMapEncoder(t reflect.Type) encoderFunc {
 
 func encodeByteSlice(b *Builder, v reflect.Value, options encoderOptions) {
 	if v.IsNil() {
-		b.addInternal(nullValue)
+		options.addNil(b, Binary)
 		return
 	}
 	b.addInternal(NewBinaryValue(v.Bytes()))
@@ -549,17 +1042,19 @@ type sliceEncoder struct {
 
 func (se *sliceEncoder) encode(b *Builder, v reflect.Value, options encoderOptions) {
 	if v.IsNil() {
-		b.addInternal(nullValue)
+		options.addNil(b, Array)
 		return
 	}
+	options.state.visit(v)
 	se.arrayEnc(b, v, options)
+	options.state.unvisit(v)
 }
 
 func newSliceEncoder(t reflect.Type) encoderFunc {
 	// Byte slices get special treatment; arrays don't.
 	if t.Elem().Kind() == reflect.Uint8 {
 		p := reflect.PtrTo(t.Elem())
-		if !p.Implements(marshalerType) && !p.Implements(jsonMarshalerType) && !p.Implements(textMarshalerType) {
+		if !p.Implements(builderMarshalerType) && !p.Implements(marshalerType) && !p.Implements(jsonMarshalerType) && !p.Implements(textMarshalerType) {
 			return encodeByteSlice
 		}
 	}
@@ -572,16 +1067,26 @@ type arrayEncoder struct {
 }
 
 func (ae *arrayEncoder) encode(b *Builder, v reflect.Value, options encoderOptions) {
+	options.state.enter(v)
+	current := -1
+	defer func() {
+		if current >= 0 {
+			addPathSegment(recover(), pathSegment{kind: pathSegmentIndex, index: int64(current)})
+		}
+	}()
 	if err := b.OpenArray(); err != nil {
 		panic(err)
 	}
 	n := v.Len()
 	for i := 0; i < n; i++ {
+		current = i
 		ae.elemEnc(b, v.Index(i), options)
 	}
+	current = -1
 	if err := b.Close(); err != nil {
 		panic(err)
 	}
+	options.state.leave()
 }
 
 func newArrayEncoder(t reflect.Type) encoderFunc {
@@ -598,7 +1103,9 @@ func (pe *ptrEncoder) encode(b *Builder, v reflect.Value, options encoderOptions
 		b.addInternal(nullValue)
 		return
 	}
+	options.state.visit(v)
 	pe.elemEnc(b, v.Elem(), options)
+	options.state.unvisit(v)
 }
 
 func newPtrEncoder(t reflect.Type) encoderFunc {
@@ -24,6 +24,7 @@ package velocypack
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 )
 
@@ -95,10 +96,34 @@ var (
 	InvalidUtf8SequenceError = errors.New("invalid utf8 sequence")
 	// IsInvalidUtf8Sequence returns true if the given error is an InvalidUtf8SequenceError.
 	IsInvalidUtf8Sequence = isCausedByFunc(InvalidUtf8SequenceError)
+	// InvalidBCDError indicates a malformed BCD value or a number that cannot be represented as BCD.
+	InvalidBCDError = errors.New("invalid BCD value")
+	// IsInvalidBCD returns true if the given error is an InvalidBCDError.
+	IsInvalidBCD = isCausedByFunc(InvalidBCDError)
 	// NoJSONEquivalentError is returned when a Velocypack type cannot be converted to JSON.
 	NoJSONEquivalentError = errors.New("no JSON equivalent")
 	// IsNoJSONEquivalent returns true if the given error is an NoJSONEquivalentError.
 	IsNoJSONEquivalent = isCausedByFunc(NoJSONEquivalentError)
+	// InvalidJSONPointerError indicates a malformed JSON Pointer (RFC 6901).
+	InvalidJSONPointerError = errors.New("invalid JSON pointer")
+	// IsInvalidJSONPointer returns true if the given error is an InvalidJSONPointerError.
+	IsInvalidJSONPointer = isPatchCausedByFunc(InvalidJSONPointerError)
+	// InvalidPatchError indicates a malformed patch or patch operation.
+	InvalidPatchError = errors.New("invalid patch")
+	// IsInvalidPatch returns true if the given error is an InvalidPatchError.
+	IsInvalidPatch = isPatchCausedByFunc(InvalidPatchError)
+	// PatchPathNotFoundError indicates that a location referenced by a patch operation does not exist.
+	PatchPathNotFoundError = errors.New("patch path not found")
+	// IsPatchPathNotFound returns true if the given error is an PatchPathNotFoundError.
+	IsPatchPathNotFound = isPatchCausedByFunc(PatchPathNotFoundError)
+	// PatchTestFailedError indicates that a patch "test" operation failed.
+	PatchTestFailedError = errors.New("patch test failed")
+	// IsPatchTestFailed returns true if the given error is an PatchTestFailedError.
+	IsPatchTestFailed = isPatchCausedByFunc(PatchTestFailedError)
+	// MaxDepthExceededError indicates that decoded data is nested deeper than the configured maximum depth.
+	MaxDepthExceededError = errors.New("maximum nesting depth exceeded")
+	// IsMaxDepthExceeded returns true if the given error is an MaxDepthExceededError.
+	IsMaxDepthExceeded = isCausedByFunc(MaxDepthExceededError)
 )
 
 // isCausedByFunc creates an error test function.
@@ -124,6 +149,40 @@ func IsBuilderUnexpectedType(err error) bool {
 	return ok
 }
 
+// ValidationError is returned by Validate when a slice does not contain valid VelocyPack.
+type ValidationError struct {
+	Message string
+	// Offset is the byte offset (relative to the start of the validated slice) of the problem.
+	Offset ValueLength
+}
+
+// Error implements the error interface for ValidationError.
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Message, e.Offset)
+}
+
+// IsValidation returns true if the given error is a ValidationError.
+func IsValidation(err error) bool {
+	_, ok := Cause(err).(ValidationError)
+	return ok
+}
+
+// TagRegistrationError is returned when a tag cannot be registered with RegisterTaggedType.
+type TagRegistrationError struct {
+	Message string
+}
+
+// Error implements the error interface for TagRegistrationError.
+func (e TagRegistrationError) Error() string {
+	return e.Message
+}
+
+// IsTagRegistration returns true if the given error is a TagRegistrationError.
+func IsTagRegistration(err error) bool {
+	_, ok := Cause(err).(TagRegistrationError)
+	return ok
+}
+
 // MarshalerError is returned when a custom VPack Marshaler returns an error.
 type MarshalerError struct {
 	Type reflect.Type
@@ -137,8 +196,11 @@ func (e MarshalerError) Error() string {
 
 // IsMarshaler returns true if the given error is an MarshalerError.
 func IsMarshaler(err error) bool {
-	_, ok := Cause(err).(MarshalerError)
-	return ok
+	switch Cause(err).(type) {
+	case MarshalerError, *MarshalerError:
+		return true
+	}
+	return false
 }
 
 // UnsupportedTypeError is returned when a type is marshaled that cannot be marshaled.
@@ -157,6 +219,33 @@ func IsUnsupportedType(err error) bool {
 	return ok
 }
 
+// An UnsupportedValueError is returned when a value is marshaled that cannot be marshaled,
+// such as a cyclic data structure or a value that is nested too deeply.
+type UnsupportedValueError struct {
+	Value reflect.Value
+	Str   string
+	// Path is the location of Value within the marshaled value, in the syntax
+	// accepted by ParsePath. It is empty for the marshaled value itself, for cycles
+	// and for values encoded by a MarshalVPackTo method.
+	Path string
+
+	segments []pathSegment // Elements of Path, innermost first
+	noPath   bool          // Set when Path is unknown
+}
+
+func (e *UnsupportedValueError) Error() string {
+	if e.Path != "" {
+		return "unsupported value at " + e.Path + ": " + e.Str
+	}
+	return "unsupported value: " + e.Str
+}
+
+// IsUnsupportedValue returns true if the given error is an UnsupportedValueError.
+func IsUnsupportedValue(err error) bool {
+	_, ok := Cause(err).(*UnsupportedValueError)
+	return ok
+}
+
 // An InvalidUnmarshalError describes an invalid argument passed to Unmarshal.
 // (The argument to Unmarshal must be a non-nil pointer.)
 type InvalidUnmarshalError struct {
@@ -202,9 +291,30 @@ func IsUnmarshalType(err error) bool {
 	return ok
 }
 
+// An UnknownFieldError is returned when an object key does not match any field
+// of the destination struct and unknown fields are disallowed.
+type UnknownFieldError struct {
+	Field  string // object key that did not match a field
+	Struct string // name of the struct type being decoded
+}
+
+func (e *UnknownFieldError) Error() string {
+	if e.Struct != "" {
+		return "json: unknown field \"" + e.Field + "\" in Go struct " + e.Struct
+	}
+	return "json: unknown field \"" + e.Field + "\""
+}
+
+// IsUnknownField returns true if the given error is an UnknownFieldError.
+func IsUnknownField(err error) bool {
+	_, ok := Cause(err).(*UnknownFieldError)
+	return ok
+}
+
 // An ParseError is returned when JSON cannot be parsed correctly.
 type ParseError struct {
-	msg    string
+	msg string
+	// Offset is the byte offset in the input at which the error was detected.
 	Offset int64
 }
 
@@ -229,3 +339,55 @@ var (
 	// Cause(nil) must return nil.
 	Cause = func(err error) error { return err }
 )
+
+// PathSyntaxError is returned when a path expression cannot be parsed.
+type PathSyntaxError struct {
+	Message string
+	// Offset is the byte offset in the path expression at which the error was detected.
+	Offset int
+}
+
+// Error implements the error interface for PathSyntaxError.
+func (e PathSyntaxError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Message, e.Offset)
+}
+
+// IsPathSyntax returns true if the given error is a PathSyntaxError.
+func IsPathSyntax(err error) bool {
+	_, ok := Cause(err).(PathSyntaxError)
+	return ok
+}
+
+// PatchError is returned by ApplyPatch when an operation of a patch cannot be applied.
+type PatchError struct {
+	// Index of the failing operation in the patch.
+	Index int
+	// Op is the name of the failing operation (add, remove, replace, move, copy or test).
+	Op string
+	// Path is the path of the failing operation.
+	Path string
+	// Err is the reason of the failure.
+	Err error
+}
+
+// Error implements the error interface for PatchError.
+func (e PatchError) Error() string {
+	return fmt.Sprintf("patch operation %d (%s '%s') failed: %s", e.Index, e.Op, e.Path, e.Err)
+}
+
+// IsPatch returns true if the given error is a PatchError.
+func IsPatch(err error) bool {
+	_, ok := Cause(err).(PatchError)
+	return ok
+}
+
+// isPatchCausedByFunc creates an error test function that also matches the cause of a PatchError.
+func isPatchCausedByFunc(cause error) func(err error) bool {
+	return func(err error) bool {
+		err = Cause(err)
+		if pe, ok := err.(PatchError); ok {
+			err = Cause(pe.Err)
+		}
+		return err == cause
+	}
+}
@@ -0,0 +1,118 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import "strings"
+
+// parseJSONPointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+// The empty pointer refers to the whole document and yields no tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, WithStack(InvalidJSONPointerError)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		if !strings.Contains(t, "~") {
+			continue
+		}
+		// Validate escape sequences
+		for j := 0; j < len(t); j++ {
+			if t[j] == '~' && (j+1 >= len(t) || (t[j+1] != '0' && t[j+1] != '1')) {
+				return nil, WithStack(InvalidJSONPointerError)
+			}
+		}
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// formatJSONPointer creates a JSON Pointer (RFC 6901) from the given reference tokens.
+func formatJSONPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.Replace(strings.Replace(t, "~", "~0", -1), "/", "~1", -1))
+	}
+	return sb.String()
+}
+
+// jsonPointerArrayIndex parses the given reference token as an index in an array of given length.
+// If allowEnd is set, the index may be equal to the length and "-" refers to the end of the array.
+func jsonPointerArrayIndex(token string, length ValueLength, allowEnd bool) (ValueLength, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, WithStack(PatchPathNotFoundError)
+	}
+	var index ValueLength
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if c < '0' || c > '9' {
+			return 0, WithStack(PatchPathNotFoundError)
+		}
+		index = index*10 + ValueLength(c-'0')
+		if index > length {
+			return 0, WithStack(PatchPathNotFoundError)
+		}
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, WithStack(PatchPathNotFoundError)
+	}
+	return index, nil
+}
+
+// getJSONPointer returns the value in the given slice referred to by the given tokens.
+func getJSONPointer(s Slice, tokens []string) (Slice, error) {
+	for _, t := range tokens {
+		switch {
+		case s.IsObject():
+			value, err := s.get(t)
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			if value.IsNone() {
+				return nil, WithStack(PatchPathNotFoundError)
+			}
+			s = value
+		case s.IsArray():
+			length, err := s.Length()
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			index, err := jsonPointerArrayIndex(t, length, false)
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			if s, err = s.At(index); err != nil {
+				return nil, WithStack(err)
+			}
+		default:
+			return nil, WithStack(PatchPathNotFoundError)
+		}
+	}
+	return s, nil
+}
@@ -27,6 +27,8 @@ type ObjectIterator struct {
 	position ValueLength
 	size     ValueLength
 	current  Slice
+	// translator is used to translate integer keys.
+	translator AttributeTranslator
 }
 
 // NewObjectIterator initializes an iterator at position 0 of the given object slice.
@@ -39,9 +41,10 @@ func NewObjectIterator(s Slice, allowRandomIteration ...bool) (*ObjectIterator,
 		return nil, WithStack(err)
 	}
 	i := &ObjectIterator{
-		s:        s,
-		position: 0,
-		size:     size,
+		s:          s,
+		position:   0,
+		size:       size,
+		translator: attributeTranslator,
 	}
 	if size > 0 {
 		if h := s.head(); h == 0x14 {
@@ -53,6 +56,12 @@ func NewObjectIterator(s Slice, allowRandomIteration ...bool) (*ObjectIterator,
 	return i, nil
 }
 
+// SetAttributeTranslator sets the translator used to translate integer keys.
+// By default the translator set with SetAttributeTranslator is used.
+func (i *ObjectIterator) SetAttributeTranslator(t AttributeTranslator) {
+	i.translator = t
+}
+
 // IsValid returns true if the given position of the iterator is valid.
 func (i *ObjectIterator) IsValid() bool {
 	return i.position < i.size
@@ -68,15 +77,19 @@ func (i *ObjectIterator) Key(translate bool) (Slice, error) {
 	if i.position >= i.size {
 		return nil, WithStack(IndexOutOfBoundsError)
 	}
-	if current := i.current; current != nil {
-		if translate {
-			key, err := current.makeKey()
-			return key, WithStack(err)
+	key := i.current
+	if key == nil {
+		var err error
+		key, err = i.s.getNthKey(i.position, false)
+		if err != nil {
+			return nil, WithStack(err)
 		}
-		return current, nil
 	}
-	key, err := i.s.getNthKey(i.position, translate)
-	return key, WithStack(err)
+	if translate {
+		key, err := key.makeKeyWith(i.translator)
+		return key, WithStack(err)
+	}
+	return key, nil
 }
 
 // Value returns the value of the current position of the iterator
@@ -23,11 +23,12 @@
 package velocypack
 
 import (
-	"bytes"
-	"encoding/json"
+	"fmt"
 	"io"
+	"math"
 	"strconv"
-	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // ParserOptions controls how the Parser builds Velocypack.
@@ -36,13 +37,102 @@ type ParserOptions struct {
 	BuildUnindexedArrays bool
 	// If set, all Objects's will be unindexed.
 	BuildUnindexedObjects bool
+	// If set, objects that contain the same attribute name more than once are rejected
+	// with a DuplicateAttributeNameError.
+	CheckAttributeUniqueness bool
+	// If > 0, this is the maximum nesting depth of arrays and objects.
+	// Deeper nested input is rejected with a ParseError.
+	MaxDepth int
+	// If > 0, all attributes of objects at this nesting depth are removed,
+	// leaving empty objects. Depth 1 is the top level object.
+	ClearAttributesAtDepth int
+	// If not empty, only the top level attributes with these names are kept.
+	KeepTopLevelAttributes []string
+	// Top level attributes with these names are removed.
+	ExcludeTopLevelAttributes []string
+	// If set, strings that contain invalid UTF-8 sequences are rejected with a ParseError.
+	// Otherwise every byte that is not part of a valid UTF-8 sequence is replaced
+	// by the Unicode replacement character (U+FFFD).
+	ValidateUTF8Strings bool
+	// LargeNumbers determines how numbers that do not fit in an Int, UInt or Double are handled.
+	LargeNumbers LargeNumberHandling
 }
 
+// LargeNumberHandling determines how the Parser handles numbers that do not fit in an Int, UInt or Double.
+type LargeNumberHandling int
+
+const (
+	// LargeNumbersDefault parses integers that do not fit in an Int or UInt as Double
+	// and rejects numbers outside the range of a Double with a ParseError.
+	LargeNumbersDefault LargeNumberHandling = iota
+	// LargeNumbersAsDouble parses integers that do not fit in an Int or UInt as Double
+	// and numbers outside the range of a Double as the largest (or smallest) finite Double,
+	// so the result can still be dumped as JSON.
+	LargeNumbersAsDouble
+	// LargeNumbersAsBCD parses integers that do not fit in an Int or UInt and numbers
+	// outside the range of a Double (including numbers too small for a Double) as exact BCD values.
+	LargeNumbersAsBCD
+)
+
+// parserReadBufferSize is the size of the buffer used to read JSON from a reader.
+const parserReadBufferSize = 64 * 1024
+
+// parserState describes what the Parser expects next.
+type parserState int
+
+const (
+	// A value (at top level, after a ',' in an array or after a ':' in an object)
+	parserExpectValue parserState = iota
+	// A value or the end of an array (after '[')
+	parserExpectArrayValueOrEnd
+	// A key or the end of an object (after '{')
+	parserExpectObjectKeyOrEnd
+	// A key (after ',' in an object)
+	parserExpectObjectKey
+	// A ':' (after a key)
+	parserExpectColon
+	// A ',' or the end of the current array or object (after a value)
+	parserExpectCommaOrEnd
+)
+
 // Parser is used to build VPack structures from JSON.
+// The JSON is scanned byte by byte and the resulting VPack is written
+// directly into the builder.
 type Parser struct {
 	options ParserOptions
-	decoder *json.Decoder
 	builder *Builder
+	r       io.Reader
+	readErr error
+	// buf contains the input read so far that has not yet been discarded.
+	buf []byte
+	// pos is the position in buf of the next byte to scan.
+	pos int
+	// offset is the offset in the input of buf[0].
+	offset int64
+	// containers holds '[' or '{' for every array or object opened by the parser.
+	containers []byte
+	state      parserState
+	scratch    []byte
+	// keySets holds the attribute names of every open object (indexed by depth-1),
+	// used to check attribute uniqueness.
+	keySets []map[string]struct{}
+	// keepAttributes & excludeAttributes are the sets of top level attribute names to keep/remove.
+	keepAttributes    map[string]struct{}
+	excludeAttributes map[string]struct{}
+	// If skipDepth > 0, the attribute (key & value) that starts at skipFrom in the builder
+	// is removed once its value (in an object at depth skipDepth) is complete.
+	skipDepth int
+	skipFrom  ValueLength
+	// utf8Runs records where raw non-ASCII input was copied into the current string,
+	// used to find the offset of invalid UTF-8 sequences.
+	utf8Runs []parserUTF8Run
+}
+
+// parserUTF8Run records that the input at inputOffset was copied into the
+// current string at dataOffset.
+type parserUTF8Run struct {
+	dataOffset  ValueLength
+	inputOffset int64
 }
 
 // ParseJSON parses JSON from the given reader and returns the
@@ -50,102 +140,809 @@ type Parser struct {
 func ParseJSON(r io.Reader, options ...ParserOptions) (Slice, error) {
 	builder := &Builder{}
 	p := NewParser(r, builder, options...)
-	if err := p.Parse(); err != nil {
-		return nil, WithStack(err)
-	}
-	slice, err := builder.Slice()
-	if err != nil {
-		return nil, WithStack(err)
-	}
-	return slice, nil
+	return parseToSlice(p, builder)
 }
 
 // ParseJSONFromString parses the given JSON string and returns the
 // VPack equivalent.
 func ParseJSONFromString(json string, options ...ParserOptions) (Slice, error) {
-	return ParseJSON(strings.NewReader(json), options...)
+	return ParseJSONFromUTF8([]byte(json), options...)
 }
 
 // ParseJSONFromUTF8 parses the given JSON string and returns the
 // VPack equivalent.
 func ParseJSONFromUTF8(json []byte, options ...ParserOptions) (Slice, error) {
-	return ParseJSON(bytes.NewReader(json), options...)
+	builder := &Builder{}
+	p := newParser(nil, json, builder, options...)
+	return parseToSlice(p, builder)
+}
+
+// parseToSlice runs the given parser and returns the content of its builder.
+func parseToSlice(p *Parser, builder *Builder) (Slice, error) {
+	if err := p.Parse(); err != nil {
+		return nil, WithStack(err)
+	}
+	slice, err := builder.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return slice, nil
 }
 
 // NewParser initializes a new Parser with JSON from the given reader and
 // it will store the parsers output in the given builder.
 func NewParser(r io.Reader, builder *Builder, options ...ParserOptions) *Parser {
-	d := json.NewDecoder(r)
-	d.UseNumber()
+	return newParser(r, make([]byte, 0, parserReadBufferSize), builder, options...)
+}
+
+// newParser initializes a new Parser that scans the given buffer first and then
+// continues with the given reader (if any).
+func newParser(r io.Reader, buf []byte, builder *Builder, options ...ParserOptions) *Parser {
 	p := &Parser{
-		decoder: d,
 		builder: builder,
+		r:       r,
+		buf:     buf,
 	}
 	if len(options) > 0 {
 		p.options = options[0]
 	}
+	p.keepAttributes = stringSet(p.options.KeepTopLevelAttributes)
+	p.excludeAttributes = stringSet(p.options.ExcludeTopLevelAttributes)
 	return p
 }
 
+// stringSet creates a set of the given strings.
+// Returns nil for an empty list.
+func stringSet(list []string) map[string]struct{} {
+	if len(list) == 0 {
+		return nil
+	}
+	result := make(map[string]struct{}, len(list))
+	for _, x := range list {
+		result[x] = struct{}{}
+	}
+	return result
+}
+
 // Parse JSON from the parsers reader and build VPack structures in the
 // parsers builder.
+// Multiple top level values (separated by whitespace) are all added to the builder.
+// When the input ends inside an array or object, the builder is left open.
 func (p *Parser) Parse() error {
 	for {
-		t, err := p.decoder.Token()
-		if err == io.EOF {
-			break
-		} else if serr, ok := err.(*json.SyntaxError); ok {
-			return WithStack(&ParseError{msg: err.Error(), Offset: serr.Offset})
-		} else if err != nil {
-			return WithStack(&ParseError{msg: err.Error()})
-		}
-		switch x := t.(type) {
-		case nil:
-			if err := p.builder.AddValue(NewNullValue()); err != nil {
+		c, ok, err := p.skipWhitespace()
+		if err != nil {
+			return WithStack(err)
+		} else if !ok {
+			return nil
+		}
+		switch p.state {
+		case parserExpectValue:
+			if err := p.parseValue(c); err != nil {
 				return WithStack(err)
 			}
-		case bool:
-			if err := p.builder.AddValue(NewBoolValue(x)); err != nil {
+		case parserExpectArrayValueOrEnd:
+			if c == ']' {
+				if err := p.closeContainer(); err != nil {
+					return WithStack(err)
+				}
+			} else if err := p.parseValue(c); err != nil {
 				return WithStack(err)
 			}
-		case json.Number:
-			if xu, err := strconv.ParseUint(string(x), 10, 64); err == nil {
-				if err := p.builder.AddValue(NewUIntValue(xu)); err != nil {
+		case parserExpectObjectKeyOrEnd, parserExpectObjectKey:
+			if c == '}' && p.state == parserExpectObjectKeyOrEnd {
+				if err := p.closeContainer(); err != nil {
 					return WithStack(err)
 				}
-			} else if xi, err := x.Int64(); err == nil {
-				if err := p.builder.AddValue(NewIntValue(xi)); err != nil {
+			} else if c == '"' {
+				if err := p.parseKey(); err != nil {
 					return WithStack(err)
 				}
+				p.state = parserExpectColon
 			} else {
-				if xf, err := x.Float64(); err == nil {
-					if err := p.builder.AddValue(NewDoubleValue(xf)); err != nil {
+				return p.syntaxError(c, "looking for beginning of object key string")
+			}
+		case parserExpectColon:
+			if c != ':' {
+				return p.syntaxError(c, "after object key")
+			}
+			p.pos++
+			p.state = parserExpectValue
+		case parserExpectCommaOrEnd:
+			if p.containers[len(p.containers)-1] == '[' {
+				switch c {
+				case ',':
+					p.pos++
+					p.state = parserExpectValue
+				case ']':
+					if err := p.closeContainer(); err != nil {
 						return WithStack(err)
 					}
-				} else {
-					return WithStack(&ParseError{msg: err.Error()})
+				default:
+					return p.syntaxError(c, "after array element")
+				}
+			} else {
+				switch c {
+				case ',':
+					p.pos++
+					p.state = parserExpectObjectKey
+				case '}':
+					if err := p.closeContainer(); err != nil {
+						return WithStack(err)
+					}
+				default:
+					return p.syntaxError(c, "after object key:value pair")
 				}
 			}
-		case string:
-			if err := p.builder.AddValue(NewStringValue(x)); err != nil {
-				return WithStack(err)
+		}
+	}
+}
+
+// parseValue parses the value that starts with the given (not yet consumed) byte.
+func (p *Parser) parseValue(c byte) error {
+	switch c {
+	case '[':
+		if err := p.checkDepth(); err != nil {
+			return WithStack(err)
+		}
+		p.pos++
+		if err := p.builder.OpenArray(p.options.BuildUnindexedArrays); err != nil {
+			return WithStack(err)
+		}
+		p.containers = append(p.containers, '[')
+		p.state = parserExpectArrayValueOrEnd
+		return nil
+	case '{':
+		if err := p.checkDepth(); err != nil {
+			return WithStack(err)
+		}
+		p.pos++
+		if err := p.builder.OpenObject(p.options.BuildUnindexedObjects); err != nil {
+			return WithStack(err)
+		}
+		p.containers = append(p.containers, '{')
+		p.state = parserExpectObjectKeyOrEnd
+		if p.options.CheckAttributeUniqueness {
+			depth := len(p.containers)
+			for len(p.keySets) < depth {
+				p.keySets = append(p.keySets, nil)
 			}
-		case json.Delim:
-			switch x {
-			case '[':
-				if err := p.builder.OpenArray(p.options.BuildUnindexedArrays); err != nil {
-					return WithStack(err)
-				}
-			case '{':
-				if err := p.builder.OpenObject(p.options.BuildUnindexedObjects); err != nil {
-					return WithStack(err)
+			if keys := p.keySets[depth-1]; keys == nil {
+				p.keySets[depth-1] = make(map[string]struct{})
+			} else {
+				for k := range keys {
+					delete(keys, k)
 				}
-			case ']', '}':
-				if err := p.builder.Close(); err != nil {
-					return WithStack(err)
+			}
+		}
+		return nil
+	case '"':
+		p.pos++
+		if _, err := p.parseString(); err != nil {
+			return WithStack(err)
+		}
+	case 't':
+		if err := p.parseLiteral("true"); err != nil {
+			return WithStack(err)
+		}
+		if err := p.builder.prepareDirectValue(false); err != nil {
+			return WithStack(err)
+		}
+		p.builder.addTrue()
+	case 'f':
+		if err := p.parseLiteral("false"); err != nil {
+			return WithStack(err)
+		}
+		if err := p.builder.prepareDirectValue(false); err != nil {
+			return WithStack(err)
+		}
+		p.builder.addFalse()
+	case 'n':
+		if err := p.parseLiteral("null"); err != nil {
+			return WithStack(err)
+		}
+		if err := p.builder.prepareDirectValue(false); err != nil {
+			return WithStack(err)
+		}
+		p.builder.addNull()
+	default:
+		if c != '-' && (c < '0' || c > '9') {
+			return p.syntaxError(c, "looking for beginning of value")
+		}
+		if err := p.parseNumber(); err != nil {
+			return WithStack(err)
+		}
+	}
+	p.valueDone()
+	return nil
+}
+
+// valueDone updates the state after a value has been parsed.
+func (p *Parser) valueDone() {
+	if len(p.containers) == 0 {
+		p.state = parserExpectValue
+	} else {
+		p.state = parserExpectCommaOrEnd
+		if p.skipDepth == len(p.containers) {
+			// Remove the excluded attribute from the builder
+			p.builder.buf.Shrink(uint(p.builder.buf.Len() - p.skipFrom))
+			p.builder.cleanupAdd()
+			p.skipDepth = 0
+		}
+	}
+}
+
+// checkDepth checks that another array or object can be opened without
+// exceeding the maximum nesting depth.
+func (p *Parser) checkDepth() error {
+	if p.options.MaxDepth > 0 && len(p.containers) >= p.options.MaxDepth {
+		return WithStack(&ParseError{msg: "maximum nesting depth exceeded", Offset: p.inputOffset()})
+	}
+	return nil
+}
+
+// parseKey parses the key of an attribute in an object and decides whether
+// the attribute is kept.
+func (p *Parser) parseKey() error {
+	p.pos++
+	start, err := p.parseString()
+	if err != nil {
+		return WithStack(err)
+	}
+	if p.skipDepth > 0 {
+		// Attribute is inside an attribute that is already excluded
+		return nil
+	}
+	key, err := Slice(p.builder.buf[start:]).GetStringUTF8()
+	if err != nil {
+		return WithStack(err)
+	}
+	depth := len(p.containers)
+	if p.isExcludedAttribute(key, depth) {
+		p.skipDepth = depth
+		p.skipFrom = start
+		return nil
+	}
+	if p.options.CheckAttributeUniqueness {
+		keys := p.keySets[depth-1]
+		if _, found := keys[string(key)]; found {
+			return WithStack(DuplicateAttributeNameError)
+		}
+		keys[string(key)] = struct{}{}
+	}
+	if t := p.builder.AttributeTranslator; t != nil {
+		if id, found := t.StringToID(string(key)); found {
+			// Replace key by its integer key
+			p.builder.buf.Shrink(uint(p.builder.buf.Len() - start))
+			p.builder.addUInt(id)
+		}
+	}
+	return nil
+}
+
+// isExcludedAttribute returns true if the attribute with given key, in an object
+// at given depth, must be removed.
+func (p *Parser) isExcludedAttribute(key []byte, depth int) bool {
+	if depth == p.options.ClearAttributesAtDepth {
+		return true
+	}
+	if depth == 1 {
+		if p.keepAttributes != nil {
+			if _, found := p.keepAttributes[string(key)]; !found {
+				return true
+			}
+		}
+		if p.excludeAttributes != nil {
+			if _, found := p.excludeAttributes[string(key)]; found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// closeContainer consumes the closing bracket of the current array or object and closes it.
+func (p *Parser) closeContainer() error {
+	p.pos++
+	if err := p.builder.Close(); err != nil {
+		return WithStack(err)
+	}
+	p.containers = p.containers[:len(p.containers)-1]
+	p.valueDone()
+	return nil
+}
+
+// parseLiteral consumes the given literal (true, false or null).
+func (p *Parser) parseLiteral(literal string) error {
+	if ok, err := p.ensure(len(literal)); err != nil {
+		return WithStack(err)
+	} else if !ok {
+		// Find the first mismatch in the available bytes, if any
+		for i := 1; p.pos+i < len(p.buf); i++ {
+			if c := p.buf[p.pos+i]; c != literal[i] {
+				p.pos += i
+				return p.syntaxError(c, fmt.Sprintf("in literal %s (expecting %q)", literal, literal[i]))
+			}
+		}
+		p.pos = len(p.buf)
+		return p.unexpectedEnd()
+	}
+	for i := 1; i < len(literal); i++ {
+		if c := p.buf[p.pos+i]; c != literal[i] {
+			p.pos += i
+			return p.syntaxError(c, fmt.Sprintf("in literal %s (expecting %q)", literal, literal[i]))
+		}
+	}
+	p.pos += len(literal)
+	return nil
+}
+
+// parseNumber parses a number and adds it to the builder.
+// Integers are added as UInt (positive) or Int (negative) when they fit, all
+// other numbers are added as Double.
+func (p *Parser) parseNumber() error {
+	startOffset := p.inputOffset()
+	p.scratch = p.scratch[:0]
+	negative := false
+	isDouble := false
+	overflow := false
+	var mantissa uint64
+
+	c, ok, err := p.peekByte()
+	if err != nil {
+		return WithStack(err)
+	}
+	if c == '-' {
+		negative = true
+		p.consumeNumberByte(c)
+		if c, ok, err = p.peekByte(); err != nil {
+			return WithStack(err)
+		}
+	}
+	// Integer part
+	switch {
+	case !ok:
+		return p.unexpectedEnd()
+	case c == '0':
+		p.consumeNumberByte(c)
+		if c, ok, err = p.peekByte(); err != nil {
+			return WithStack(err)
+		}
+	case c >= '1' && c <= '9':
+		for ok && c >= '0' && c <= '9' {
+			d := uint64(c - '0')
+			if mantissa > (math.MaxUint64-d)/10 {
+				overflow = true
+			}
+			mantissa = mantissa*10 + d
+			p.consumeNumberByte(c)
+			if c, ok, err = p.peekByte(); err != nil {
+				return WithStack(err)
+			}
+		}
+	default:
+		return p.syntaxError(c, "in numeric literal")
+	}
+	// Fraction
+	if ok && c == '.' {
+		isDouble = true
+		p.consumeNumberByte(c)
+		if c, ok, err = p.consumeDigits(); err != nil {
+			return WithStack(err)
+		}
+	}
+	// Exponent
+	if ok && (c == 'e' || c == 'E') {
+		isDouble = true
+		p.consumeNumberByte(c)
+		if c, ok, err = p.peekByte(); err != nil {
+			return WithStack(err)
+		}
+		if ok && (c == '+' || c == '-') {
+			p.consumeNumberByte(c)
+		}
+		if _, _, err := p.consumeDigits(); err != nil {
+			return WithStack(err)
+		}
+	}
+	if err := p.builder.prepareDirectValue(false); err != nil {
+		return WithStack(err)
+	}
+	if !isDouble && !overflow {
+		if !negative {
+			p.builder.addUInt(mantissa)
+			return nil
+		} else if mantissa <= 1<<63 {
+			p.builder.addInt(-int64(mantissa))
+			return nil
+		}
+	}
+	if p.options.LargeNumbers == LargeNumbersAsBCD && !isDouble {
+		// Integer that does not fit in an Int or UInt
+		return p.addBCDNumber(startOffset)
+	}
+	f, err := strconv.ParseFloat(string(p.scratch), 64)
+	if err == nil && f == 0 && p.options.LargeNumbers == LargeNumbersAsBCD && !isZeroNumber(p.scratch) {
+		// Number too small for a Double
+		return p.addBCDNumber(startOffset)
+	}
+	if err != nil {
+		switch p.options.LargeNumbers {
+		case LargeNumbersAsDouble:
+			// f is +/- infinity, clamp it to the range of a Double
+			f = math.Copysign(math.MaxFloat64, f)
+		case LargeNumbersAsBCD:
+			return p.addBCDNumber(startOffset)
+		default:
+			return WithStack(&ParseError{msg: err.Error(), Offset: startOffset})
+		}
+	}
+	p.builder.addDouble(f)
+	return nil
+}
+
+// isZeroNumber returns true if the mantissa of the given number consists of zeros only.
+func isZeroNumber(number []byte) bool {
+	for _, c := range number {
+		if c == 'e' || c == 'E' {
+			break
+		}
+		if c >= '1' && c <= '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// addBCDNumber adds the number that has just been scanned as BCD.
+func (p *Parser) addBCDNumber(startOffset int64) error {
+	d, err := ParseDecimal(string(p.scratch))
+	if err != nil {
+		return WithStack(&ParseError{msg: err.Error(), Offset: startOffset})
+	}
+	p.builder.addBCD(d)
+	return nil
+}
+
+// consumeNumberByte consumes the given byte as part of a number.
+func (p *Parser) consumeNumberByte(c byte) {
+	p.scratch = append(p.scratch, c)
+	p.pos++
+}
+
+// consumeDigits consumes one or more digits as part of a number.
+// It returns the first byte after the digits.
+func (p *Parser) consumeDigits() (byte, bool, error) {
+	c, ok, err := p.peekByte()
+	if err != nil {
+		return 0, false, WithStack(err)
+	} else if !ok {
+		return 0, false, p.unexpectedEnd()
+	} else if c < '0' || c > '9' {
+		return 0, false, p.syntaxError(c, "in numeric literal")
+	}
+	for ok && c >= '0' && c <= '9' {
+		p.consumeNumberByte(c)
+		if c, ok, err = p.peekByte(); err != nil {
+			return 0, false, WithStack(err)
+		}
+	}
+	return c, ok, nil
+}
+
+// parseString parses a string (the opening quote has already been consumed)
+// and writes it directly into the builder.
+// It returns the position of the string in the builders buffer.
+func (p *Parser) parseString() (ValueLength, error) {
+	if err := p.builder.prepareDirectValue(true); err != nil {
+		return 0, WithStack(err)
+	}
+	buf := &p.builder.buf
+	start := buf.Len()
+	// Reserve space for a long string header, fixed when the string is complete.
+	buf.Grow(9)[0] = 0xbf
+	hasNonASCII := false
+	p.utf8Runs = p.utf8Runs[:0]
+	for {
+		if p.pos >= len(p.buf) {
+			if ok, err := p.ensure(1); err != nil {
+				return 0, WithStack(err)
+			} else if !ok {
+				return 0, p.unexpectedEnd()
+			}
+		}
+		// Copy all bytes that need no special treatment at once
+		chunk := p.buf[p.pos:]
+		i := 0
+		runHasNonASCII := false
+		for i < len(chunk) {
+			c := chunk[i]
+			if c == '"' || c == '\\' || c < 0x20 {
+				break
+			}
+			if c >= utf8.RuneSelf {
+				runHasNonASCII = true
+			}
+			i++
+		}
+		if runHasNonASCII {
+			hasNonASCII = true
+			if p.options.ValidateUTF8Strings {
+				p.utf8Runs = append(p.utf8Runs, parserUTF8Run{dataOffset: buf.Len() - (start + 9), inputOffset: p.inputOffset()})
+			}
+		}
+		buf.Write(chunk[:i])
+		p.pos += i
+		if i == len(chunk) {
+			continue
+		}
+		c := chunk[i]
+		if c == '"' {
+			p.pos++
+			break
+		} else if c != '\\' {
+			return 0, p.syntaxError(c, "in string literal")
+		}
+		p.pos++
+		if err := p.parseEscape(); err != nil {
+			return 0, WithStack(err)
+		}
+	}
+
+	if hasNonASCII && !utf8.Valid((*buf)[start+9:]) {
+		if p.options.ValidateUTF8Strings {
+			return 0, p.invalidUTF8Error((*buf)[start+9:])
+		}
+		// Replace invalid UTF-8 sequences
+		valid := toValidUTF8((*buf)[start+9:])
+		buf.Shrink(uint(buf.Len() - (start + 9)))
+		buf.Write(valid)
+	}
+	strLen := buf.Len() - (start + 9)
+	if strLen > 126 {
+		// long string
+		setLength((*buf)[start+1:], strLen, 8)
+	} else {
+		// short string, remove the space reserved for the length
+		(*buf)[start] = byte(0x40 + strLen)
+		copy((*buf)[start+1:], (*buf)[start+9:])
+		buf.Shrink(8)
+	}
+	return start, nil
+}
+
+// invalidUTF8Error creates a ParseError for the first invalid UTF-8 sequence in the given string data.
+func (p *Parser) invalidUTF8Error(data []byte) error {
+	// Find the first invalid byte
+	var index ValueLength
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		data = data[size:]
+		index += ValueLength(size)
+	}
+	// Invalid bytes are always copied from the input, find the offset of that input
+	offset := p.inputOffset()
+	for _, run := range p.utf8Runs {
+		if run.dataOffset > index {
+			break
+		}
+		offset = run.inputOffset + int64(index-run.dataOffset)
+	}
+	return WithStack(&ParseError{msg: "invalid UTF-8 sequence in string", Offset: offset})
+}
+
+// parseEscape parses an escape sequence in a string (the backslash has already been consumed)
+// and writes the unescaped value into the builder.
+func (p *Parser) parseEscape() error {
+	c, ok, err := p.peekByte()
+	if err != nil {
+		return WithStack(err)
+	} else if !ok {
+		return p.unexpectedEnd()
+	}
+	buf := &p.builder.buf
+	switch c {
+	case '"', '\\', '/':
+		buf.WriteByte(c)
+	case 'b':
+		buf.WriteByte('\b')
+	case 'f':
+		buf.WriteByte('\f')
+	case 'n':
+		buf.WriteByte('\n')
+	case 'r':
+		buf.WriteByte('\r')
+	case 't':
+		buf.WriteByte('\t')
+	case 'u':
+		p.pos++
+		r, err := p.parseHex4()
+		if err != nil {
+			return WithStack(err)
+		}
+		if utf16.IsSurrogate(r) {
+			// Try to combine with a following \uXXXX into a surrogate pair
+			r1 := r
+			r = utf8.RuneError
+			if ok, err := p.ensure(6); err != nil {
+				return WithStack(err)
+			} else if ok && p.buf[p.pos] == '\\' && p.buf[p.pos+1] == 'u' {
+				if r2, valid := decodeHex4(p.buf[p.pos+2 : p.pos+6]); valid {
+					if dec := utf16.DecodeRune(r1, r2); dec != utf8.RuneError {
+						r = dec
+						p.pos += 6
+					}
 				}
 			}
 		}
+		var tmp [utf8.UTFMax]byte
+		n := utf8.EncodeRune(tmp[:], r)
+		buf.Write(tmp[:n])
+		return nil
+	default:
+		return p.syntaxError(c, "in string escape code")
 	}
+	p.pos++
 	return nil
 }
+
+// parseHex4 parses the 4 hexadecimal digits of a \u escape sequence.
+func (p *Parser) parseHex4() (rune, error) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		c, ok, err := p.peekByte()
+		if err != nil {
+			return 0, WithStack(err)
+		} else if !ok {
+			return 0, p.unexpectedEnd()
+		}
+		d, valid := hexDigitValue(c)
+		if !valid {
+			return 0, p.syntaxError(c, "in \\u hexadecimal character escape")
+		}
+		r = r<<4 | d
+		p.pos++
+	}
+	return r, nil
+}
+
+// decodeHex4 decodes the 4 hexadecimal digits of a \u escape sequence.
+func decodeHex4(s []byte) (rune, bool) {
+	var r rune
+	for _, c := range s {
+		d, valid := hexDigitValue(c)
+		if !valid {
+			return 0, false
+		}
+		r = r<<4 | d
+	}
+	return r, true
+}
+
+// hexDigitValue returns the value of the given hexadecimal digit.
+func hexDigitValue(c byte) (rune, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return rune(c - '0'), true
+	case c >= 'a' && c <= 'f':
+		return rune(c - 'a' + 10), true
+	case c >= 'A' && c <= 'F':
+		return rune(c - 'A' + 10), true
+	}
+	return 0, false
+}
+
+// toValidUTF8 replaces every byte of the given data that is not part of a valid
+// UTF-8 sequence with the Unicode replacement character.
+func toValidUTF8(data []byte) []byte {
+	result := make([]byte, 0, len(data)+8)
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			result = append(result, "\uFFFD"...)
+		} else {
+			result = append(result, data[:size]...)
+		}
+		data = data[size:]
+	}
+	return result
+}
+
+// isWhitespace returns true if the given byte is JSON whitespace.
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// skipWhitespace skips all whitespace and returns the next byte (without consuming it).
+// Returns false if the input ends.
+func (p *Parser) skipWhitespace() (byte, bool, error) {
+	for {
+		for p.pos < len(p.buf) {
+			if c := p.buf[p.pos]; !isWhitespace(c) {
+				return c, true, nil
+			}
+			p.pos++
+		}
+		if ok, err := p.ensure(1); err != nil {
+			return 0, false, WithStack(err)
+		} else if !ok {
+			return 0, false, nil
+		}
+	}
+}
+
+// peekByte returns the next byte without consuming it.
+// Returns false if the input ends.
+func (p *Parser) peekByte() (byte, bool, error) {
+	if p.pos < len(p.buf) {
+		return p.buf[p.pos], true, nil
+	}
+	if ok, err := p.ensure(1); err != nil || !ok {
+		return 0, false, WithStack(err)
+	}
+	return p.buf[p.pos], true, nil
+}
+
+// ensure makes sure that at least n unscanned bytes are available in the buffer,
+// reading more input when needed.
+// Returns false if the input ends before that.
+func (p *Parser) ensure(n int) (bool, error) {
+	for len(p.buf)-p.pos < n {
+		if p.r == nil || p.readErr == io.EOF {
+			return false, nil
+		} else if p.readErr != nil {
+			return false, WithStack(&ParseError{msg: p.readErr.Error(), Offset: p.inputOffset()})
+		}
+		if p.pos > 0 {
+			// Discard the bytes that have been scanned
+			remaining := copy(p.buf[:cap(p.buf)], p.buf[p.pos:])
+			p.offset += int64(p.pos)
+			p.buf = p.buf[:remaining]
+			p.pos = 0
+		}
+		if len(p.buf) == cap(p.buf) {
+			buf := make([]byte, len(p.buf), 2*cap(p.buf)+n)
+			copy(buf, p.buf)
+			p.buf = buf
+		}
+		read, err := p.r.Read(p.buf[len(p.buf):cap(p.buf)])
+		p.buf = p.buf[:len(p.buf)+read]
+		if err != nil {
+			p.readErr = err
+		}
+	}
+	return true, nil
+}
+
+// inputOffset returns the offset in the input of the next byte to scan.
+func (p *Parser) inputOffset() int64 {
+	return p.offset + int64(p.pos)
+}
+
+// syntaxError creates a ParseError for the given (unexpected) byte at the current position.
+func (p *Parser) syntaxError(c byte, context string) error {
+	return WithStack(&ParseError{msg: fmt.Sprintf("invalid character %s %s", quoteChar(c), context), Offset: p.inputOffset()})
+}
+
+// unexpectedEnd creates a ParseError for input that ends in the middle of a value.
+func (p *Parser) unexpectedEnd() error {
+	return WithStack(&ParseError{msg: "unexpected end of JSON input", Offset: p.inputOffset()})
+}
+
+// quoteChar formats c as a quoted character literal.
+func quoteChar(c byte) string {
+	// special cases - different from quoted strings
+	if c == '\'' {
+		return `'\''`
+	}
+	if c == '"' {
+		return `'"'`
+	}
+
+	// use quoted string with different quotation marks
+	s := strconv.Quote(string(c))
+	return "'" + s[1:len(s)-1] + "'"
+}
@@ -0,0 +1,372 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a precompiled attribute path expression that can be used to query slices.
+// Paths are safe for concurrent use and are intended to be compiled once and reused.
+//
+// The path language supports:
+//   - `name` & `.name` select an attribute of an object
+//   - `["name"]` & `['name']` select an attribute with a name that contains special characters
+//   - `[3]` selects an element of an array, `[-1]` selects the last element
+//   - `[*]` & `.*` select all elements of an array or all attribute values of an object
+//
+// Example: `users[3].address.city`, `items[*].price`, `tags[-1]`.
+type Path struct {
+	raw      string
+	segments []pathSegment
+}
+
+type pathSegmentKind int
+
+const (
+	pathSegmentAttribute pathSegmentKind = iota
+	pathSegmentIndex
+	pathSegmentWildcard
+)
+
+// pathSegment is a single step of a Path.
+type pathSegment struct {
+	kind      pathSegmentKind
+	attribute string
+	index     int64
+}
+
+// ParsePath compiles the given path expression.
+// An empty path selects the queried slice itself.
+func ParsePath(path string) (Path, error) {
+	p := pathParser{input: path}
+	segments, err := p.parse()
+	if err != nil {
+		return Path{}, WithStack(err)
+	}
+	return Path{raw: path, segments: segments}, nil
+}
+
+// MustParsePath compiles the given path expression, panicking on errors.
+func MustParsePath(path string) Path {
+	p, err := ParsePath(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the path expression the path was compiled from.
+func (p Path) String() string {
+	return p.raw
+}
+
+// Query returns all values in the given slice that are selected by the path.
+// The returned slices refer to the memory of the given slice (no data is copied).
+// Attributes that do not exist, array indices that are out of range and steps
+// into values of an unexpected type do not yield a result.
+func (p Path) Query(s Slice) ([]Slice, error) {
+	result, err := p.AppendQuery(nil, s)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// AppendQuery appends all values in the given slice that are selected by the path to dst
+// and returns the extended list. Use this to avoid allocations when querying in a loop.
+func (p Path) AppendQuery(dst []Slice, s Slice) ([]Slice, error) {
+	result, err := queryPath(dst, s, p.segments)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// QueryFirst returns the first value in the given slice that is selected by the path.
+// Returns a None slice if the path does not select any value.
+func (p Path) QueryFirst(s Slice) (Slice, error) {
+	var buf [1]Slice
+	result, err := queryPath(buf[:0], s, p.segments)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	if len(result) == 0 {
+		return NoneSlice(), nil
+	}
+	return result[0], nil
+}
+
+// Query returns all values in the slice that are selected by the given path expression.
+// See Path for a description of the path language.
+func (s Slice) Query(path string) ([]Slice, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := p.Query(s)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// queryPath appends all values in s selected by the given segments to dst.
+func queryPath(dst []Slice, s Slice, segments []pathSegment) ([]Slice, error) {
+	if len(segments) == 0 {
+		return append(dst, s), nil
+	}
+	seg, rest := segments[0], segments[1:]
+	switch seg.kind {
+	case pathSegmentAttribute:
+		if !s.IsObject() {
+			return dst, nil
+		}
+		value, err := s.get(seg.attribute)
+		if err != nil {
+			return nil, WithStack(err)
+		}
+		if value.IsNone() {
+			return dst, nil
+		}
+		return queryPath(dst, value, rest)
+	case pathSegmentIndex:
+		if !s.IsArray() {
+			return dst, nil
+		}
+		index := seg.index
+		if index < 0 {
+			length, err := s.Length()
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			index += int64(length)
+			if index < 0 {
+				return dst, nil
+			}
+		}
+		value, err := s.At(ValueLength(index))
+		if IsIndexOutOfBounds(err) {
+			return dst, nil
+		} else if err != nil {
+			return nil, WithStack(err)
+		}
+		return queryPath(dst, value, rest)
+	case pathSegmentWildcard:
+		if s.IsArray() {
+			it, err := NewArrayIterator(s)
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			for it.IsValid() {
+				value, err := it.Value()
+				if err != nil {
+					return nil, WithStack(err)
+				}
+				if dst, err = queryPath(dst, value, rest); err != nil {
+					return nil, WithStack(err)
+				}
+				if err := it.Next(); err != nil {
+					return nil, WithStack(err)
+				}
+			}
+		} else if s.IsObject() {
+			it, err := NewObjectIterator(s, true)
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			for it.IsValid() {
+				value, err := it.Value()
+				if err != nil {
+					return nil, WithStack(err)
+				}
+				if dst, err = queryPath(dst, value, rest); err != nil {
+					return nil, WithStack(err)
+				}
+				if err := it.Next(); err != nil {
+					return nil, WithStack(err)
+				}
+			}
+		}
+		return dst, nil
+	default:
+		return nil, WithStack(InternalError)
+	}
+}
+
+// formatPath returns the path expression that selects the given segments.
+// Attribute names that cannot be written unquoted are written as `["name"]`.
+func formatPath(segments []pathSegment) string {
+	var sb strings.Builder
+	for i, seg := range segments {
+		switch seg.kind {
+		case pathSegmentAttribute:
+			if seg.attribute == "" || seg.attribute == "*" || strings.ContainsAny(seg.attribute, ".[]") {
+				sb.WriteString(`["`)
+				for j := 0; j < len(seg.attribute); j++ {
+					if c := seg.attribute[j]; c == '"' || c == '\\' {
+						sb.WriteByte('\\')
+					}
+					sb.WriteByte(seg.attribute[j])
+				}
+				sb.WriteString(`"]`)
+			} else {
+				if i > 0 {
+					sb.WriteByte('.')
+				}
+				sb.WriteString(seg.attribute)
+			}
+		case pathSegmentIndex:
+			sb.WriteByte('[')
+			sb.WriteString(strconv.FormatInt(seg.index, 10))
+			sb.WriteByte(']')
+		case pathSegmentWildcard:
+			sb.WriteString("[*]")
+		}
+	}
+	return sb.String()
+}
+
+// pathParser compiles a path expression into segments.
+type pathParser struct {
+	input string
+	pos   int
+}
+
+func (p *pathParser) parse() ([]pathSegment, error) {
+	var segments []pathSegment
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '[':
+			seg, err := p.parseBracket()
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			segments = append(segments, seg)
+		case c == '.' && len(segments) > 0:
+			p.pos++
+			seg, err := p.parseName()
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			segments = append(segments, seg)
+		case len(segments) == 0:
+			seg, err := p.parseName()
+			if err != nil {
+				return nil, WithStack(err)
+			}
+			segments = append(segments, seg)
+		default:
+			return nil, WithStack(p.errorf("expected '.' or '['"))
+		}
+	}
+	return segments, nil
+}
+
+// parseName parses an unquoted attribute name (or `*`) at the current position.
+func (p *pathParser) parseName() (pathSegment, error) {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != '.' && p.input[p.pos] != '[' {
+		if p.input[p.pos] == ']' {
+			return pathSegment{}, WithStack(p.errorf("unexpected ']'"))
+		}
+		p.pos++
+	}
+	name := p.input[start:p.pos]
+	if name == "" {
+		p.pos = start
+		return pathSegment{}, WithStack(p.errorf("expected attribute name"))
+	}
+	if name == "*" {
+		return pathSegment{kind: pathSegmentWildcard}, nil
+	}
+	return pathSegment{kind: pathSegmentAttribute, attribute: name}, nil
+}
+
+// parseBracket parses a `[...]` segment at the current position.
+func (p *pathParser) parseBracket() (pathSegment, error) {
+	p.pos++ // '['
+	if p.pos >= len(p.input) {
+		return pathSegment{}, WithStack(p.errorf("unexpected end of path"))
+	}
+	var seg pathSegment
+	switch c := p.input[p.pos]; {
+	case c == '*':
+		p.pos++
+		seg = pathSegment{kind: pathSegmentWildcard}
+	case c == '"' || c == '\'':
+		name, err := p.parseQuoted(c)
+		if err != nil {
+			return pathSegment{}, WithStack(err)
+		}
+		seg = pathSegment{kind: pathSegmentAttribute, attribute: name}
+	default:
+		start := p.pos
+		end := strings.IndexByte(p.input[start:], ']')
+		if end < 0 {
+			return pathSegment{}, WithStack(p.errorf("expected ']'"))
+		}
+		index, err := strconv.ParseInt(p.input[start:start+end], 10, 64)
+		if err != nil {
+			return pathSegment{}, WithStack(p.errorf("invalid array index '%s'", p.input[start:start+end]))
+		}
+		p.pos = start + end
+		seg = pathSegment{kind: pathSegmentIndex, index: index}
+	}
+	if p.pos >= len(p.input) || p.input[p.pos] != ']' {
+		return pathSegment{}, WithStack(p.errorf("expected ']'"))
+	}
+	p.pos++
+	return seg, nil
+}
+
+// parseQuoted parses a quoted attribute name at the current position.
+// A backslash escapes the next character.
+func (p *pathParser) parseQuoted(quote byte) (string, error) {
+	p.pos++ // opening quote
+	var sb strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch c {
+		case quote:
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.input) {
+				return "", WithStack(p.errorf("unexpected end of path"))
+			}
+			c = p.input[p.pos]
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return "", WithStack(p.errorf("unterminated quoted attribute name"))
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return PathSyntaxError{Message: fmt.Sprintf(format, args...), Offset: p.pos}
+}
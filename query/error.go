@@ -0,0 +1,47 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package query
+
+import (
+	"fmt"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// SyntaxError is returned when a query expression cannot be compiled.
+type SyntaxError struct {
+	Message string
+	// Offset is the byte offset in the expression at which the error was detected.
+	Offset int
+}
+
+// Error implements the error interface for SyntaxError.
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Message, e.Offset)
+}
+
+// IsSyntax returns true if the given error is a SyntaxError.
+func IsSyntax(err error) bool {
+	_, ok := velocypack.Cause(err).(SyntaxError)
+	return ok
+}
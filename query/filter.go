@@ -0,0 +1,195 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package query
+
+import (
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// logicalExpr is a filter expression that yields true or false.
+type logicalExpr interface {
+	test(ctx *evalContext, current velocypack.Slice) (bool, error)
+}
+
+// valueExpr is an operand of a comparison.
+type valueExpr interface {
+	values(ctx *evalContext, current velocypack.Slice) ([]velocypack.Slice, error)
+}
+
+// orExpr is `left || right`.
+type orExpr struct {
+	left, right logicalExpr
+}
+
+func (e orExpr) test(ctx *evalContext, current velocypack.Slice) (bool, error) {
+	if ok, err := e.left.test(ctx, current); err != nil {
+		return false, velocypack.WithStack(err)
+	} else if ok {
+		return true, nil
+	}
+	ok, err := e.right.test(ctx, current)
+	return ok, velocypack.WithStack(err)
+}
+
+// andExpr is `left && right`.
+type andExpr struct {
+	left, right logicalExpr
+}
+
+func (e andExpr) test(ctx *evalContext, current velocypack.Slice) (bool, error) {
+	if ok, err := e.left.test(ctx, current); err != nil {
+		return false, velocypack.WithStack(err)
+	} else if !ok {
+		return false, nil
+	}
+	ok, err := e.right.test(ctx, current)
+	return ok, velocypack.WithStack(err)
+}
+
+// notExpr is `!expr`.
+type notExpr struct {
+	expr logicalExpr
+}
+
+func (e notExpr) test(ctx *evalContext, current velocypack.Slice) (bool, error) {
+	ok, err := e.expr.test(ctx, current)
+	return !ok, velocypack.WithStack(err)
+}
+
+// existsExpr is true when a path selects at least one value.
+type existsExpr struct {
+	path pathExpr
+}
+
+func (e existsExpr) test(ctx *evalContext, current velocypack.Slice) (bool, error) {
+	values, err := e.path.values(ctx, current)
+	if err != nil {
+		return false, velocypack.WithStack(err)
+	}
+	return len(values) > 0, nil
+}
+
+// compareOp is a comparison operator.
+type compareOp int
+
+const (
+	opEqual compareOp = iota
+	opNotEqual
+	opLess
+	opLessOrEqual
+	opGreater
+	opGreaterOrEqual
+)
+
+// comparisonExpr is `left <op> right`.
+type comparisonExpr struct {
+	op          compareOp
+	left, right valueExpr
+}
+
+func (e comparisonExpr) test(ctx *evalContext, current velocypack.Slice) (bool, error) {
+	left, err := e.left.values(ctx, current)
+	if err != nil {
+		return false, velocypack.WithStack(err)
+	}
+	right, err := e.right.values(ctx, current)
+	if err != nil {
+		return false, velocypack.WithStack(err)
+	}
+	if len(left) > 1 || len(right) > 1 {
+		// Only single values can be compared
+		return false, nil
+	}
+	switch e.op {
+	case opEqual:
+		eq, err := equalValues(left, right)
+		return eq, velocypack.WithStack(err)
+	case opNotEqual:
+		eq, err := equalValues(left, right)
+		return !eq, velocypack.WithStack(err)
+	}
+	if len(left) == 0 || len(right) == 0 || !isOrdered(left[0], right[0]) {
+		return false, nil
+	}
+	c, err := left[0].Compare(right[0])
+	if err != nil {
+		return false, velocypack.WithStack(err)
+	}
+	switch e.op {
+	case opLess:
+		return c < 0, nil
+	case opLessOrEqual:
+		return c <= 0, nil
+	case opGreater:
+		return c > 0, nil
+	case opGreaterOrEqual:
+		return c >= 0, nil
+	default:
+		return false, velocypack.WithStack(velocypack.InternalError)
+	}
+}
+
+// equalValues returns true if both lists are empty or both contain a single, equal value.
+func equalValues(left, right []velocypack.Slice) (bool, error) {
+	if len(left) == 0 || len(right) == 0 {
+		return len(left) == len(right), nil
+	}
+	c, err := left[0].Compare(right[0])
+	if err != nil {
+		return false, velocypack.WithStack(err)
+	}
+	return c == 0, nil
+}
+
+// isOrdered returns true if both values are numbers or both values are strings.
+func isOrdered(a, b velocypack.Slice) bool {
+	isNumber := func(s velocypack.Slice) bool { return s.IsNumber() || s.IsBCD() }
+	return (isNumber(a) && isNumber(b)) || (a.IsString() && b.IsString())
+}
+
+// literalExpr is a constant value.
+type literalExpr struct {
+	value velocypack.Slice
+}
+
+func (e literalExpr) values(ctx *evalContext, current velocypack.Slice) ([]velocypack.Slice, error) {
+	return []velocypack.Slice{e.value}, nil
+}
+
+// pathExpr is a path relative to the current value (`@`) or the root value (`$`).
+type pathExpr struct {
+	relative bool
+	segments []segment
+}
+
+func (e pathExpr) values(ctx *evalContext, current velocypack.Slice) ([]velocypack.Slice, error) {
+	start := ctx.root
+	if e.relative {
+		start = current
+	}
+	result, err := ctx.evaluate(start, e.segments)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,480 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// parser compiles a JSONPath expression.
+type parser struct {
+	input string
+	pos   int
+}
+
+// parseQuery parses a complete query, starting with `$`.
+func (p *parser) parseQuery() ([]segment, error) {
+	p.skipSpace()
+	if !p.consume("$") {
+		return nil, velocypack.WithStack(p.errorf("expected '$'"))
+	}
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, velocypack.WithStack(p.errorf("unexpected '%c'", p.peek()))
+	}
+	return segments, nil
+}
+
+// parseSegments parses a (possibly empty) list of segments.
+func (p *parser) parseSegments() ([]segment, error) {
+	var segments []segment
+	for {
+		// Whitespace is allowed between segments
+		save := p.pos
+		p.skipSpace()
+		switch {
+		case p.consume(".."):
+			var seg segment
+			var err error
+			if p.peek() == '[' {
+				seg, err = p.parseBracket()
+			} else {
+				seg, err = p.parseDotMember()
+			}
+			if err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+			seg.descendant = true
+			segments = append(segments, seg)
+		case p.consume("."):
+			seg, err := p.parseDotMember()
+			if err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+			segments = append(segments, seg)
+		case p.peek() == '[':
+			seg, err := p.parseBracket()
+			if err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+			segments = append(segments, seg)
+		default:
+			p.pos = save
+			return segments, nil
+		}
+	}
+}
+
+// parseDotMember parses the name or `*` following a `.` or `..`.
+func (p *parser) parseDotMember() (segment, error) {
+	if p.consume("*") {
+		return segment{selectors: []selector{wildcardSelector{}}}, nil
+	}
+	start := p.pos
+	for !p.eof() {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isNameChar(r, p.pos == start) {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return segment{}, velocypack.WithStack(p.errorf("expected attribute name or '*'"))
+	}
+	return segment{selectors: []selector{nameSelector{name: p.input[start:p.pos]}}}, nil
+}
+
+// isNameChar returns true if the given rune can be used in an attribute name in dot notation.
+func isNameChar(r rune, first bool) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r >= 0x80 && r != utf8.RuneError:
+		return true
+	case r >= '0' && r <= '9':
+		return !first
+	default:
+		return false
+	}
+}
+
+// parseBracket parses a `[...]` segment with one or more comma separated selectors.
+func (p *parser) parseBracket() (segment, error) {
+	p.pos++ // '['
+	var seg segment
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return segment{}, velocypack.WithStack(err)
+		}
+		seg.selectors = append(seg.selectors, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return seg, nil
+		}
+		if !p.consume(",") {
+			return segment{}, velocypack.WithStack(p.errorf("expected ',' or ']'"))
+		}
+	}
+}
+
+// parseSelector parses a single selector inside brackets.
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		return nameSelector{name: name}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		return filterSelector{filter: filter}, nil
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	case p.eof():
+		return nil, velocypack.WithStack(p.errorf("unexpected end of expression"))
+	default:
+		return nil, velocypack.WithStack(p.errorf("unexpected '%c'", c))
+	}
+}
+
+// parseIndexOrSlice parses an array index or a `start:end:step` slice.
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	var values [3]int64
+	var has [3]bool
+	part := 0
+	for {
+		p.skipSpace()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			v, err := p.parseInteger()
+			if err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+			values[part], has[part] = v, true
+		}
+		p.skipSpace()
+		if part < 2 && p.consume(":") {
+			part++
+			continue
+		}
+		break
+	}
+	if part == 0 {
+		if !has[0] {
+			return nil, velocypack.WithStack(p.errorf("expected array index"))
+		}
+		return indexSelector{index: values[0]}, nil
+	}
+	sel := sliceSelector{
+		start: values[0], hasStart: has[0],
+		end: values[1], hasEnd: has[1],
+		step: 1,
+	}
+	if has[2] {
+		sel.step = values[2]
+	}
+	return sel, nil
+}
+
+// parseInteger parses an optionally negative integer.
+func (p *parser) parseInteger() (int64, error) {
+	start := p.pos
+	p.consume("-")
+	for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	text := p.input[start:p.pos]
+	v, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		p.pos = start
+		return 0, velocypack.WithStack(p.errorf("invalid integer '%s'", text))
+	}
+	return v, nil
+}
+
+// parseString parses a single or double quoted string.
+func (p *parser) parseString() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.input[p.pos]
+		switch c {
+		case quote:
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			p.pos++
+			if p.eof() {
+				return "", velocypack.WithStack(p.errorf("unexpected end of expression"))
+			}
+			switch esc := p.input[p.pos]; esc {
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if p.pos+5 > len(p.input) {
+					return "", velocypack.WithStack(p.errorf("invalid unicode escape"))
+				}
+				v, err := strconv.ParseUint(p.input[p.pos+1:p.pos+5], 16, 16)
+				if err != nil {
+					return "", velocypack.WithStack(p.errorf("invalid unicode escape"))
+				}
+				sb.WriteRune(rune(v))
+				p.pos += 4
+			case '\\', '/', '\'', '"':
+				sb.WriteByte(esc)
+			default:
+				return "", velocypack.WithStack(p.errorf("invalid escape '\\%c'", esc))
+			}
+		default:
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", velocypack.WithStack(p.errorf("unterminated string"))
+}
+
+// parseOr parses `and ('||' and)*`.
+func (p *parser) parseOr() (logicalExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		left = orExpr{left: left, right: right}
+	}
+}
+
+// parseAnd parses `unary ('&&' unary)*`.
+func (p *parser) parseAnd() (logicalExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		left = andExpr{left: left, right: right}
+	}
+}
+
+// parseUnary parses `'!' unary`, a parenthesized expression, a comparison or an existence test.
+func (p *parser) parseUnary() (logicalExpr, error) {
+	p.skipSpace()
+	if p.consume("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		return notExpr{expr: expr}, nil
+	}
+	if p.consume("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, velocypack.WithStack(p.errorf("expected ')'"))
+		}
+		return expr, nil
+	}
+	start := p.pos
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	p.skipSpace()
+	op, ok := p.parseCompareOp()
+	if !ok {
+		path, isPath := left.(pathExpr)
+		if !isPath {
+			p.pos = start
+			return nil, velocypack.WithStack(p.errorf("expected path or comparison"))
+		}
+		return existsExpr{path: path}, nil
+	}
+	p.skipSpace()
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return comparisonExpr{op: op, left: left, right: right}, nil
+}
+
+// parseCompareOp parses a comparison operator.
+func (p *parser) parseCompareOp() (compareOp, bool) {
+	switch {
+	case p.consume("=="):
+		return opEqual, true
+	case p.consume("!="):
+		return opNotEqual, true
+	case p.consume("<="):
+		return opLessOrEqual, true
+	case p.consume(">="):
+		return opGreaterOrEqual, true
+	case p.consume("<"):
+		return opLess, true
+	case p.consume(">"):
+		return opGreater, true
+	default:
+		return 0, false
+	}
+}
+
+// parseValue parses a path or a literal.
+func (p *parser) parseValue() (valueExpr, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		return pathExpr{relative: c == '@', segments: segments}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		return literalExpr{value: velocypack.StringSlice(s)}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case p.consume("true"):
+		return literalExpr{value: velocypack.TrueSlice()}, nil
+	case p.consume("false"):
+		return literalExpr{value: velocypack.FalseSlice()}, nil
+	case p.consume("null"):
+		return literalExpr{value: velocypack.NullSlice()}, nil
+	case p.eof():
+		return nil, velocypack.WithStack(p.errorf("unexpected end of expression"))
+	default:
+		return nil, velocypack.WithStack(p.errorf("unexpected '%c'", c))
+	}
+}
+
+// parseNumber parses a JSON number literal.
+func (p *parser) parseNumber() (valueExpr, error) {
+	start := p.pos
+	p.consume("-")
+	digits := func() {
+		for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+			p.pos++
+		}
+	}
+	digits()
+	if p.consume(".") {
+		digits()
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		digits()
+	}
+	value, err := velocypack.ParseJSONFromString(p.input[start:p.pos])
+	if err != nil || !value.IsNumber() {
+		text := p.input[start:p.pos]
+		p.pos = start
+		return nil, velocypack.WithStack(p.errorf("invalid number '%s'", text))
+	}
+	return literalExpr{value: value}, nil
+}
+
+// skipSpace skips all whitespace at the current position.
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume advances past the given token if the input continues with it.
+func (p *parser) consume(token string) bool {
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// peek returns the byte at the current position or 0 at the end of the input.
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// eof returns true if the entire input has been consumed.
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return SyntaxError{Message: fmt.Sprintf(format, args...), Offset: p.pos}
+}
@@ -0,0 +1,189 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+// Package query implements JSONPath expressions that are evaluated directly on VelocyPack slices.
+//
+// Supported syntax:
+//   - `$` the root value, `@` the current value (in filters)
+//   - `.name`, `['name']` & `["name"]` select an attribute of an object
+//   - `.*` & `[*]` select all elements of an array or all attribute values of an object
+//   - `[3]` & `[-1]` select an array element, negative indices count from the end
+//   - `[start:end:step]` selects a slice of an array, all parts are optional
+//   - `[0,2]` & `['a','b']` select the union of multiple selectors
+//   - `..name`, `..*` & `..[...]` apply a selector to a value and all of its descendants
+//   - `[?(expr)]` & `[?expr]` select all elements/attribute values for which the filter is true
+//
+// Filter expressions support relative (`@.total`) & absolute (`$.limit`) paths, literals
+// (numbers, 'strings', "strings", true, false, null), the comparison operators
+// `==`, `!=`, `<`, `<=`, `>`, `>=`, the logical operators `&&`, `||`, `!` and parentheses.
+// A path without comparison tests for existence.
+// Values are compared using the VelocyPack value ordering (see velocypack.Compare).
+// Ordering comparisons are only true when both sides are numbers or both sides are strings.
+//
+// Example: `$.orders[?(@.total > 100)].id`.
+package query
+
+import (
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// Query is a compiled JSONPath expression.
+// Queries are safe for concurrent use and are intended to be compiled once and reused.
+type Query struct {
+	raw      string
+	segments []segment
+}
+
+// Compile compiles the given JSONPath expression.
+func Compile(expr string) (*Query, error) {
+	p := parser{input: expr}
+	segments, err := p.parseQuery()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return &Query{raw: expr, segments: segments}, nil
+}
+
+// MustCompile compiles the given JSONPath expression, panicking on errors.
+func MustCompile(expr string) *Query {
+	q, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// Evaluate compiles the given JSONPath expression and evaluates it on the given slice.
+func Evaluate(s velocypack.Slice, expr string) ([]velocypack.Slice, error) {
+	q, err := Compile(expr)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	result, err := q.Evaluate(s)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return result, nil
+}
+
+// String returns the expression the query was compiled from.
+func (q *Query) String() string {
+	return q.raw
+}
+
+// Evaluate returns all values in the given slice that are selected by the query.
+// The returned slices refer to the memory of the given slice (no data is copied).
+func (q *Query) Evaluate(s velocypack.Slice) ([]velocypack.Slice, error) {
+	ctx := &evalContext{root: s}
+	result, err := ctx.evaluate(s, q.segments)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return result, nil
+}
+
+// evalContext holds the state of a single query evaluation.
+type evalContext struct {
+	root velocypack.Slice
+}
+
+// evaluate applies all segments, starting with the given value.
+func (ctx *evalContext) evaluate(start velocypack.Slice, segments []segment) ([]velocypack.Slice, error) {
+	nodes := []velocypack.Slice{start}
+	for _, seg := range segments {
+		var next []velocypack.Slice
+		for _, node := range nodes {
+			var err error
+			if seg.descendant {
+				err = visitDescendants(node, func(d velocypack.Slice) error {
+					var err error
+					next, err = seg.apply(ctx, d, next)
+					return err
+				})
+			} else {
+				next, err = seg.apply(ctx, node, next)
+			}
+			if err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+		}
+		nodes = next
+		if len(nodes) == 0 {
+			break
+		}
+	}
+	return nodes, nil
+}
+
+// forEachChild calls the given function for all elements of an array or all attribute values of an object.
+// Other values have no children.
+func forEachChild(s velocypack.Slice, fn func(child velocypack.Slice) error) error {
+	if s.IsArray() {
+		it, err := velocypack.NewArrayIterator(s)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		for it.IsValid() {
+			value, err := it.Value()
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			if err := fn(value); err != nil {
+				return velocypack.WithStack(err)
+			}
+			if err := it.Next(); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+	} else if s.IsObject() {
+		it, err := velocypack.NewObjectIterator(s, true)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		for it.IsValid() {
+			value, err := it.Value()
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			if err := fn(value); err != nil {
+				return velocypack.WithStack(err)
+			}
+			if err := it.Next(); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// visitDescendants calls the given function for the given value and all of its descendants (in pre-order).
+func visitDescendants(s velocypack.Slice, fn func(d velocypack.Slice) error) error {
+	if err := fn(s); err != nil {
+		return velocypack.WithStack(err)
+	}
+	if err := forEachChild(s, func(child velocypack.Slice) error {
+		return visitDescendants(child, fn)
+	}); err != nil {
+		return velocypack.WithStack(err)
+	}
+	return nil
+}
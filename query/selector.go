@@ -0,0 +1,200 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package query
+
+import (
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// segment is a single step of a query, consisting of one or more selectors.
+type segment struct {
+	// descendant is set for `..` segments.
+	descendant bool
+	selectors  []selector
+}
+
+// apply appends the values selected from the given node to dst.
+func (seg segment) apply(ctx *evalContext, node velocypack.Slice, dst []velocypack.Slice) ([]velocypack.Slice, error) {
+	for _, sel := range seg.selectors {
+		var err error
+		if dst, err = sel.selectFrom(ctx, node, dst); err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+	}
+	return dst, nil
+}
+
+// selector selects zero or more values from a node.
+type selector interface {
+	selectFrom(ctx *evalContext, node velocypack.Slice, dst []velocypack.Slice) ([]velocypack.Slice, error)
+}
+
+// nameSelector selects an attribute of an object.
+type nameSelector struct {
+	name string
+}
+
+func (sel nameSelector) selectFrom(ctx *evalContext, node velocypack.Slice, dst []velocypack.Slice) ([]velocypack.Slice, error) {
+	if !node.IsObject() {
+		return dst, nil
+	}
+	value, err := node.Get(sel.name)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	if value.IsNone() {
+		return dst, nil
+	}
+	return append(dst, value), nil
+}
+
+// wildcardSelector selects all elements of an array or all attribute values of an object.
+type wildcardSelector struct{}
+
+func (sel wildcardSelector) selectFrom(ctx *evalContext, node velocypack.Slice, dst []velocypack.Slice) ([]velocypack.Slice, error) {
+	if err := forEachChild(node, func(child velocypack.Slice) error {
+		dst = append(dst, child)
+		return nil
+	}); err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return dst, nil
+}
+
+// indexSelector selects an element of an array.
+type indexSelector struct {
+	index int64
+}
+
+func (sel indexSelector) selectFrom(ctx *evalContext, node velocypack.Slice, dst []velocypack.Slice) ([]velocypack.Slice, error) {
+	if !node.IsArray() {
+		return dst, nil
+	}
+	length, err := node.Length()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	index := sel.index
+	if index < 0 {
+		index += int64(length)
+	}
+	if index < 0 || index >= int64(length) {
+		return dst, nil
+	}
+	value, err := node.At(velocypack.ValueLength(index))
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return append(dst, value), nil
+}
+
+// sliceSelector selects a range of elements of an array.
+type sliceSelector struct {
+	start, end       int64
+	hasStart, hasEnd bool
+	step             int64
+}
+
+func (sel sliceSelector) selectFrom(ctx *evalContext, node velocypack.Slice, dst []velocypack.Slice) ([]velocypack.Slice, error) {
+	if !node.IsArray() || sel.step == 0 {
+		return dst, nil
+	}
+	length, err := node.Length()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	n := int64(length)
+	normalize := func(i int64) int64 {
+		if i < 0 {
+			return n + i
+		}
+		return i
+	}
+	clamp := func(i, min, max int64) int64 {
+		if i < min {
+			return min
+		}
+		if i > max {
+			return max
+		}
+		return i
+	}
+	add := func(i int64) error {
+		value, err := node.At(velocypack.ValueLength(i))
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		dst = append(dst, value)
+		return nil
+	}
+	if sel.step > 0 {
+		lower, upper := int64(0), n
+		if sel.hasStart {
+			lower = clamp(normalize(sel.start), 0, n)
+		}
+		if sel.hasEnd {
+			upper = clamp(normalize(sel.end), 0, n)
+		}
+		for i := lower; i < upper; i += sel.step {
+			if err := add(i); err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+		}
+	} else {
+		upper, lower := n-1, int64(-1)
+		if sel.hasStart {
+			upper = clamp(normalize(sel.start), -1, n-1)
+		}
+		if sel.hasEnd {
+			lower = clamp(normalize(sel.end), -1, n-1)
+		}
+		for i := upper; i > lower; i += sel.step {
+			if err := add(i); err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+		}
+	}
+	return dst, nil
+}
+
+// filterSelector selects all elements of an array or all attribute values of an object
+// for which the filter expression is true.
+type filterSelector struct {
+	filter logicalExpr
+}
+
+func (sel filterSelector) selectFrom(ctx *evalContext, node velocypack.Slice, dst []velocypack.Slice) ([]velocypack.Slice, error) {
+	if err := forEachChild(node, func(child velocypack.Slice) error {
+		ok, err := sel.filter.test(ctx, child)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		if ok {
+			dst = append(dst, child)
+		}
+		return nil
+	}); err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return dst, nil
+}
@@ -0,0 +1,789 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package schema
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// node is a compiled (sub)schema.
+type node struct {
+	// path is the JSON Pointer of this schema in the root schema.
+	path string
+	// boolean is set for the boolean schemas true & false.
+	boolean *bool
+	ref     *node
+
+	types      []string
+	vpackTypes []velocypack.ValueType
+	enum       []velocypack.Slice
+	constValue velocypack.Slice
+
+	multipleOf       float64
+	maximum          velocypack.Slice
+	exclusiveMaximum velocypack.Slice
+	minimum          velocypack.Slice
+	exclusiveMinimum velocypack.Slice
+
+	maxLength int
+	minLength int
+	pattern   *regexp.Regexp
+
+	maxItems    int
+	minItems    int
+	uniqueItems bool
+	prefixItems []*node
+	items       *node
+	contains    *node
+	maxContains int
+	minContains int
+
+	maxProperties        int
+	minProperties        int
+	required             []string
+	properties           map[string]*node
+	patternProperties    []patternProperty
+	additionalProperties *node
+	propertyNames        *node
+	dependentRequired    map[string][]string
+	dependentSchemas     map[string]*node
+
+	allOf    []*node
+	anyOf    []*node
+	oneOf    []*node
+	not      *node
+	ifNode   *node
+	thenNode *node
+	elseNode *node
+
+	minDate         *time.Time
+	maxDate         *time.Time
+	maxBinaryLength int
+	minBinaryLength int
+}
+
+// patternProperty is a compiled entry of the `patternProperties` keyword.
+type patternProperty struct {
+	pattern *regexp.Regexp
+	schema  *node
+}
+
+// compiler holds the state of compiling a schema.
+type compiler struct {
+	root velocypack.Slice
+	// nodes contains all compiled schemas by their JSON Pointer.
+	nodes map[string]*node
+	// anchors contains the JSON Pointers of all `$anchor` definitions.
+	anchors map[string]string
+	// refs contains all nodes with an unresolved `$ref`.
+	refs []unresolvedRef
+}
+
+// unresolvedRef is a `$ref` that is resolved after compiling the schema.
+type unresolvedRef struct {
+	node *node
+	ref  string
+}
+
+// compileRoot compiles the root schema and resolves all references.
+func (c *compiler) compileRoot() (*node, error) {
+	if err := c.collectAnchors(c.root, nil); err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	root, err := c.compile(c.root, nil)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	// Resolving a reference can compile new schemas, which can add new references
+	for i := 0; i < len(c.refs); i++ {
+		r := c.refs[i]
+		target, err := c.resolve(r.ref, r.node.path)
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		r.node.ref = target
+	}
+	if err := c.checkCycles(); err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return root, nil
+}
+
+// checkCycles returns a CompileError when a schema applies itself to the same value,
+// through references and in-place applicators, without validating a part of the value
+// in between. Validating a value against such a schema would never end.
+func (c *compiler) checkCycles() error {
+	pointers := make([]string, 0, len(c.nodes))
+	for pointer := range c.nodes {
+		pointers = append(pointers, pointer)
+	}
+	sort.Strings(pointers)
+	// state is 1 for nodes that are being checked and 2 for nodes that have been checked.
+	state := make(map[*node]int, len(c.nodes))
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		switch state[n] {
+		case 1:
+			return velocypack.WithStack(CompileError{Message: "schema refers to itself without validating a part of the value", SchemaPath: n.path})
+		case 2:
+			return nil
+		}
+		state[n] = 1
+		for _, child := range n.inPlaceSchemas() {
+			if err := visit(child); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+		state[n] = 2
+		return nil
+	}
+	for _, pointer := range pointers {
+		if err := visit(c.nodes[pointer]); err != nil {
+			return velocypack.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// inPlaceSchemas returns the schemas that are applied to the same value as the node itself.
+func (n *node) inPlaceSchemas() []*node {
+	var result []*node
+	if n.ref != nil {
+		result = append(result, n.ref)
+	}
+	result = append(result, n.allOf...)
+	result = append(result, n.anyOf...)
+	result = append(result, n.oneOf...)
+	for _, child := range []*node{n.not, n.ifNode, n.thenNode, n.elseNode} {
+		if child != nil {
+			result = append(result, child)
+		}
+	}
+	keys := make([]string, 0, len(n.dependentSchemas))
+	for key := range n.dependentSchemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result = append(result, n.dependentSchemas[key])
+	}
+	return result
+}
+
+// collectAnchors records the location of all `$anchor` definitions in the given schema.
+func (c *compiler) collectAnchors(s velocypack.Slice, path []string) error {
+	if s.IsObject() {
+		anchor, err := s.Get("$anchor")
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		if anchor.IsString() {
+			name, err := anchor.GetString()
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			c.anchors[name] = formatPointer(path)
+		}
+		it, err := velocypack.NewObjectIterator(s, true)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		for it.IsValid() {
+			key, err := keyString(it)
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			value, err := it.Value()
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			if key != "enum" && key != "const" {
+				if err := c.collectAnchors(value, childPath(path, key)); err != nil {
+					return velocypack.WithStack(err)
+				}
+			}
+			if err := it.Next(); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+	} else if s.IsArray() {
+		it, err := velocypack.NewArrayIterator(s)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		for i := 0; it.IsValid(); i++ {
+			value, err := it.Value()
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			if err := c.collectAnchors(value, childPath(path, strconv.Itoa(i))); err != nil {
+				return velocypack.WithStack(err)
+			}
+			if err := it.Next(); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolve compiles the schema referred to by the given `$ref` value.
+func (c *compiler) resolve(ref string, schemaPath string) (*node, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, velocypack.WithStack(CompileError{Message: fmt.Sprintf("unsupported reference '%s', only references within the schema are supported", ref), SchemaPath: schemaPath})
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, velocypack.WithStack(CompileError{Message: fmt.Sprintf("invalid reference '%s'", ref), SchemaPath: schemaPath})
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		pointer, found := c.anchors[fragment]
+		if !found {
+			return nil, velocypack.WithStack(CompileError{Message: fmt.Sprintf("unknown anchor '%s'", fragment), SchemaPath: schemaPath})
+		}
+		fragment = pointer
+	}
+	tokens := parsePointer(fragment)
+	s := c.root
+	for _, t := range tokens {
+		var next velocypack.Slice
+		if s.IsObject() {
+			if next, err = s.Get(t); err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+		} else if s.IsArray() {
+			if index, err := strconv.Atoi(t); err == nil && index >= 0 {
+				if length, err := s.Length(); err != nil {
+					return nil, velocypack.WithStack(err)
+				} else if velocypack.ValueLength(index) < length {
+					if next, err = s.At(velocypack.ValueLength(index)); err != nil {
+						return nil, velocypack.WithStack(err)
+					}
+				}
+			}
+		}
+		if next.IsNone() {
+			return nil, velocypack.WithStack(CompileError{Message: fmt.Sprintf("reference '%s' not found", ref), SchemaPath: schemaPath})
+		}
+		s = next
+	}
+	result, err := c.compile(s, tokens)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return result, nil
+}
+
+// compile compiles the schema at the given location.
+func (c *compiler) compile(s velocypack.Slice, path []string) (*node, error) {
+	pointer := formatPointer(path)
+	if n, found := c.nodes[pointer]; found {
+		return n, nil
+	}
+	n := &node{
+		path:            pointer,
+		maxLength:       -1,
+		maxItems:        -1,
+		maxContains:     -1,
+		minContains:     -1,
+		maxProperties:   -1,
+		maxBinaryLength: -1,
+	}
+	c.nodes[pointer] = n
+	if s.IsBool() {
+		v := s.IsTrue()
+		n.boolean = &v
+		return n, nil
+	}
+	if !s.IsObject() {
+		return nil, velocypack.WithStack(CompileError{Message: "schema must be an object or a boolean", SchemaPath: pointer})
+	}
+	var itemsIsArray bool
+	var additionalItems *node
+	it, err := velocypack.NewObjectIterator(s, true)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	for it.IsValid() {
+		key, err := keyString(it)
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		value, err := it.Value()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		kw := childPath(path, key)
+		if err := c.compileKeyword(n, key, value, kw); err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		switch key {
+		case "items":
+			itemsIsArray = value.IsArray()
+		case "additionalItems":
+			if additionalItems, err = c.compile(value, kw); err != nil {
+				return nil, velocypack.WithStack(err)
+			}
+		}
+		if err := it.Next(); err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+	}
+	if itemsIsArray {
+		// Draft 2019-09 form of items, additionalItems applies to all other items
+		n.items = additionalItems
+	}
+	return n, nil
+}
+
+// compileKeyword compiles a single keyword of a schema into the given node.
+func (c *compiler) compileKeyword(n *node, key string, value velocypack.Slice, kw []string) error {
+	var err error
+	switch key {
+	case "$ref":
+		ref, err := c.stringValue(value, kw)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		c.refs = append(c.refs, unresolvedRef{node: n, ref: ref})
+	case "type":
+		n.types, err = c.stringOrStringArray(value, kw)
+		for _, t := range n.types {
+			switch t {
+			case "null", "boolean", "object", "array", "number", "integer", "string":
+			default:
+				return velocypack.WithStack(c.errorf(kw, "unknown type '%s'", t))
+			}
+		}
+	case "vpackType":
+		names, err := c.stringOrStringArray(value, kw)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		for _, name := range names {
+			t, found := valueTypeByName(name)
+			if !found {
+				return velocypack.WithStack(c.errorf(kw, "unknown VelocyPack type '%s'", name))
+			}
+			n.vpackTypes = append(n.vpackTypes, t)
+		}
+	case "enum":
+		if !value.IsArray() {
+			return velocypack.WithStack(c.errorf(kw, "expected an array"))
+		}
+		it, err := velocypack.NewArrayIterator(value)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		n.enum = []velocypack.Slice{}
+		for it.IsValid() {
+			v, err := it.Value()
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			n.enum = append(n.enum, v)
+			if err := it.Next(); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+	case "const":
+		n.constValue = value
+	case "multipleOf":
+		if err := c.assertNumber(value, kw); err != nil {
+			return velocypack.WithStack(err)
+		}
+		if n.multipleOf, err = toFloat64(value); err != nil {
+			return velocypack.WithStack(err)
+		}
+		if !(n.multipleOf > 0) || math.IsInf(n.multipleOf, 0) {
+			return velocypack.WithStack(c.errorf(kw, "expected a number greater than 0"))
+		}
+	case "maximum":
+		n.maximum, err = value, c.assertNumber(value, kw)
+	case "exclusiveMaximum":
+		n.exclusiveMaximum, err = value, c.assertNumber(value, kw)
+	case "minimum":
+		n.minimum, err = value, c.assertNumber(value, kw)
+	case "exclusiveMinimum":
+		n.exclusiveMinimum, err = value, c.assertNumber(value, kw)
+	case "maxLength":
+		n.maxLength, err = c.nonNegativeInt(value, kw)
+	case "minLength":
+		n.minLength, err = c.nonNegativeInt(value, kw)
+	case "pattern":
+		n.pattern, err = c.regexp(value, kw)
+	case "maxItems":
+		n.maxItems, err = c.nonNegativeInt(value, kw)
+	case "minItems":
+		n.minItems, err = c.nonNegativeInt(value, kw)
+	case "uniqueItems":
+		if !value.IsBool() {
+			return velocypack.WithStack(c.errorf(kw, "expected a boolean"))
+		}
+		n.uniqueItems = value.IsTrue()
+	case "prefixItems":
+		n.prefixItems, err = c.schemaArray(value, kw)
+	case "items":
+		if value.IsArray() {
+			n.prefixItems, err = c.schemaArray(value, kw)
+		} else {
+			n.items, err = c.compile(value, kw)
+		}
+	case "contains":
+		n.contains, err = c.compile(value, kw)
+	case "maxContains":
+		n.maxContains, err = c.nonNegativeInt(value, kw)
+	case "minContains":
+		n.minContains, err = c.nonNegativeInt(value, kw)
+	case "maxProperties":
+		n.maxProperties, err = c.nonNegativeInt(value, kw)
+	case "minProperties":
+		n.minProperties, err = c.nonNegativeInt(value, kw)
+	case "required":
+		n.required, err = c.stringArray(value, kw)
+	case "properties":
+		n.properties, err = c.schemaMap(value, kw)
+	case "patternProperties":
+		schemas, err := c.schemaMap(value, kw)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		for pattern, schema := range schemas {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return velocypack.WithStack(c.errorf(childPath(kw, pattern), "invalid pattern: %s", err))
+			}
+			n.patternProperties = append(n.patternProperties, patternProperty{pattern: re, schema: schema})
+		}
+	case "additionalProperties":
+		n.additionalProperties, err = c.compile(value, kw)
+	case "propertyNames":
+		n.propertyNames, err = c.compile(value, kw)
+	case "dependentRequired":
+		err = c.compileDependencies(n, value, kw, true)
+	case "dependentSchemas":
+		err = c.compileDependencies(n, value, kw, false)
+	case "dependencies":
+		// Draft 7 form of dependentRequired & dependentSchemas
+		if !value.IsObject() {
+			return velocypack.WithStack(c.errorf(kw, "expected an object"))
+		}
+		err = c.compileDependencies(n, value, kw, false)
+	case "allOf":
+		n.allOf, err = c.schemaArray(value, kw)
+	case "anyOf":
+		n.anyOf, err = c.schemaArray(value, kw)
+	case "oneOf":
+		n.oneOf, err = c.schemaArray(value, kw)
+	case "not":
+		n.not, err = c.compile(value, kw)
+	case "if":
+		n.ifNode, err = c.compile(value, kw)
+	case "then":
+		n.thenNode, err = c.compile(value, kw)
+	case "else":
+		n.elseNode, err = c.compile(value, kw)
+	case "$defs", "definitions":
+		// Compile definitions, so errors are reported even when they are not referenced
+		_, err = c.schemaMap(value, kw)
+	case "vpackMinDate":
+		n.minDate, err = c.date(value, kw)
+	case "vpackMaxDate":
+		n.maxDate, err = c.date(value, kw)
+	case "vpackMaxBinaryLength":
+		n.maxBinaryLength, err = c.nonNegativeInt(value, kw)
+	case "vpackMinBinaryLength":
+		n.minBinaryLength, err = c.nonNegativeInt(value, kw)
+	}
+	return velocypack.WithStack(err)
+}
+
+// compileDependencies compiles a `dependentRequired`, `dependentSchemas` or `dependencies` keyword.
+// Array values are added to dependentRequired, other values to dependentSchemas.
+func (c *compiler) compileDependencies(n *node, value velocypack.Slice, kw []string, requiredOnly bool) error {
+	if !value.IsObject() {
+		return velocypack.WithStack(c.errorf(kw, "expected an object"))
+	}
+	it, err := velocypack.NewObjectIterator(value, true)
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	for it.IsValid() {
+		key, err := keyString(it)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		v, err := it.Value()
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		if requiredOnly || v.IsArray() {
+			names, err := c.stringArray(v, childPath(kw, key))
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			if n.dependentRequired == nil {
+				n.dependentRequired = make(map[string][]string)
+			}
+			n.dependentRequired[key] = names
+		} else {
+			schema, err := c.compile(v, childPath(kw, key))
+			if err != nil {
+				return velocypack.WithStack(err)
+			}
+			if n.dependentSchemas == nil {
+				n.dependentSchemas = make(map[string]*node)
+			}
+			n.dependentSchemas[key] = schema
+		}
+		if err := it.Next(); err != nil {
+			return velocypack.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// schemaArray compiles a non-empty array of schemas.
+func (c *compiler) schemaArray(value velocypack.Slice, kw []string) ([]*node, error) {
+	if !value.IsArray() || value.IsEmptyArray() {
+		return nil, velocypack.WithStack(c.errorf(kw, "expected a non-empty array of schemas"))
+	}
+	it, err := velocypack.NewArrayIterator(value)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	var result []*node
+	for i := 0; it.IsValid(); i++ {
+		v, err := it.Value()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		schema, err := c.compile(v, childPath(kw, strconv.Itoa(i)))
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		result = append(result, schema)
+		if err := it.Next(); err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+	}
+	return result, nil
+}
+
+// schemaMap compiles an object of schemas.
+func (c *compiler) schemaMap(value velocypack.Slice, kw []string) (map[string]*node, error) {
+	if !value.IsObject() {
+		return nil, velocypack.WithStack(c.errorf(kw, "expected an object"))
+	}
+	it, err := velocypack.NewObjectIterator(value, true)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	result := make(map[string]*node)
+	for it.IsValid() {
+		key, err := keyString(it)
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		v, err := it.Value()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		if result[key], err = c.compile(v, childPath(kw, key)); err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		if err := it.Next(); err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+	}
+	return result, nil
+}
+
+// stringValue returns the value of a string keyword.
+func (c *compiler) stringValue(value velocypack.Slice, kw []string) (string, error) {
+	if !value.IsString() {
+		return "", velocypack.WithStack(c.errorf(kw, "expected a string"))
+	}
+	s, err := value.GetString()
+	if err != nil {
+		return "", velocypack.WithStack(err)
+	}
+	return s, nil
+}
+
+// stringArray returns the value of a keyword that must be an array of strings.
+func (c *compiler) stringArray(value velocypack.Slice, kw []string) ([]string, error) {
+	if !value.IsArray() {
+		return nil, velocypack.WithStack(c.errorf(kw, "expected an array of strings"))
+	}
+	it, err := velocypack.NewArrayIterator(value)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	result := []string{}
+	for it.IsValid() {
+		v, err := it.Value()
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		s, err := c.stringValue(v, kw)
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		result = append(result, s)
+		if err := it.Next(); err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+	}
+	return result, nil
+}
+
+// stringOrStringArray returns the value of a keyword that must be a string or an array of strings.
+func (c *compiler) stringOrStringArray(value velocypack.Slice, kw []string) ([]string, error) {
+	if value.IsString() {
+		s, err := c.stringValue(value, kw)
+		if err != nil {
+			return nil, velocypack.WithStack(err)
+		}
+		return []string{s}, nil
+	}
+	result, err := c.stringArray(value, kw)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return result, nil
+}
+
+// assertNumber returns an error if the given keyword value is not a number.
+func (c *compiler) assertNumber(value velocypack.Slice, kw []string) error {
+	if !isNumber(value) {
+		return velocypack.WithStack(c.errorf(kw, "expected a number"))
+	}
+	return nil
+}
+
+// nonNegativeInt returns the value of a keyword that must be a non-negative integer.
+func (c *compiler) nonNegativeInt(value velocypack.Slice, kw []string) (int, error) {
+	if !isNumber(value) {
+		return 0, velocypack.WithStack(c.errorf(kw, "expected a non-negative integer"))
+	}
+	f, err := toFloat64(value)
+	if err != nil {
+		return 0, velocypack.WithStack(err)
+	}
+	if f < 0 || f != math.Trunc(f) || f > math.MaxInt32 {
+		return 0, velocypack.WithStack(c.errorf(kw, "expected a non-negative integer"))
+	}
+	return int(f), nil
+}
+
+// regexp returns the compiled value of a keyword that must contain a regular expression.
+func (c *compiler) regexp(value velocypack.Slice, kw []string) (*regexp.Regexp, error) {
+	s, err := c.stringValue(value, kw)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, velocypack.WithStack(c.errorf(kw, "invalid pattern: %s", err))
+	}
+	return re, nil
+}
+
+// date returns the value of a keyword that must contain an RFC 3339 timestamp.
+func (c *compiler) date(value velocypack.Slice, kw []string) (*time.Time, error) {
+	s, err := c.stringValue(value, kw)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, velocypack.WithStack(c.errorf(kw, "invalid RFC 3339 timestamp '%s'", s))
+	}
+	return &t, nil
+}
+
+func (c *compiler) errorf(kw []string, format string, args ...interface{}) error {
+	return CompileError{Message: fmt.Sprintf(format, args...), SchemaPath: formatPointer(kw)}
+}
+
+// valueTypeByName returns the VelocyPack value type with given name (case insensitive).
+func valueTypeByName(name string) (velocypack.ValueType, bool) {
+	for t := velocypack.None; t <= velocypack.Tagged; t++ {
+		if strings.EqualFold(t.String(), name) {
+			return t, true
+		}
+	}
+	return velocypack.None, false
+}
+
+// keyString returns the current key of the given iterator as string.
+func keyString(it *velocypack.ObjectIterator) (string, error) {
+	key, err := it.Key(true)
+	if err != nil {
+		return "", velocypack.WithStack(err)
+	}
+	s, err := key.GetString()
+	if err != nil {
+		return "", velocypack.WithStack(err)
+	}
+	return s, nil
+}
+
+// childPath returns a copy of the given path, extended with the given token.
+func childPath(path []string, token string) []string {
+	result := make([]string, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, token)
+}
+
+// formatPointer creates a JSON Pointer (RFC 6901) from the given reference tokens.
+func formatPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.Replace(strings.Replace(t, "~", "~0", -1), "/", "~1", -1))
+	}
+	return sb.String()
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens
+}
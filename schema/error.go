@@ -0,0 +1,83 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// CompileError is returned when a schema cannot be compiled.
+type CompileError struct {
+	Message string
+	// SchemaPath is the JSON Pointer of the invalid part of the schema.
+	SchemaPath string
+}
+
+// Error implements the error interface for CompileError.
+func (e CompileError) Error() string {
+	return fmt.Sprintf("invalid schema at '%s': %s", e.SchemaPath, e.Message)
+}
+
+// IsCompile returns true if the given error is a CompileError.
+func IsCompile(err error) bool {
+	_, ok := velocypack.Cause(err).(CompileError)
+	return ok
+}
+
+// Violation describes a single schema violation found by Validate.
+type Violation struct {
+	// Path is the JSON Pointer of the invalid value in the validated slice.
+	Path string
+	// SchemaPath is the JSON Pointer of the failing keyword in the schema.
+	SchemaPath string
+	Message    string
+}
+
+// String returns a human readable representation of the violation.
+func (v Violation) String() string {
+	return fmt.Sprintf("'%s': %s (%s)", v.Path, v.Message, v.SchemaPath)
+}
+
+// ValidationError is returned by Validate when a slice does not conform to a schema.
+type ValidationError struct {
+	// Violations contains all violations that were found.
+	Violations []Violation
+}
+
+// Error implements the error interface for ValidationError.
+func (e ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.String()
+	}
+	return "schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// IsValidation returns true if the given error is a ValidationError.
+func IsValidation(err error) bool {
+	_, ok := velocypack.Cause(err).(ValidationError)
+	return ok
+}
@@ -0,0 +1,115 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+// Package schema validates VelocyPack slices against a JSON Schema (draft 2019-09 & 2020-12).
+//
+// Supported keywords:
+//   - core: `$ref` (to locations within the same schema, using JSON Pointers or `$anchor`), `$defs`, `definitions`
+//   - applicators: `allOf`, `anyOf`, `oneOf`, `not`, `if`, `then`, `else`, `properties`, `patternProperties`,
+//     `additionalProperties`, `propertyNames`, `prefixItems`, `items`, `additionalItems`, `contains`,
+//     `dependentSchemas`, `dependencies`
+//   - validation: `type`, `enum`, `const`, `multipleOf`, `maximum`, `exclusiveMaximum`, `minimum`,
+//     `exclusiveMinimum`, `maxLength`, `minLength`, `pattern`, `maxItems`, `minItems`, `uniqueItems`,
+//     `maxContains`, `minContains`, `maxProperties`, `minProperties`, `required`, `dependentRequired`
+//
+// Other keywords (such as `format` & `unevaluatedProperties`) are ignored.
+// Regular expressions use the syntax of the Go regexp package.
+//
+// VelocyPack values that have no JSON equivalent (such as UTCDate & Binary) do not match any of the JSON
+// types of the `type` keyword. They can be validated using the following extension keywords:
+//   - `vpackType`: name (or array of names) of VelocyPack value types, e.g. "UTCDate" or "Binary" (see velocypack.ValueType)
+//   - `vpackMinDate`, `vpackMaxDate`: inclusive bounds (RFC 3339) for UTCDate values
+//   - `vpackMinBinaryLength`, `vpackMaxBinaryLength`: bounds for the length of Binary values
+package schema
+
+import (
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// Schema is a compiled JSON Schema.
+// Schemas are safe for concurrent use.
+type Schema struct {
+	root *node
+}
+
+// Compile compiles the given JSON Schema.
+func Compile(s velocypack.Slice) (*Schema, error) {
+	size, err := s.ByteSize()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	// Take a copy, since compiled keywords refer to parts of the schema
+	c := &compiler{
+		root:    append(velocypack.Slice(nil), s[:size]...),
+		nodes:   make(map[string]*node),
+		anchors: make(map[string]string),
+	}
+	root, err := c.compileRoot()
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return &Schema{root: root}, nil
+}
+
+// CompileJSON compiles the given JSON Schema, given in JSON format.
+func CompileJSON(json string) (*Schema, error) {
+	s, err := velocypack.ParseJSONFromString(json)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	result, err := Compile(s)
+	if err != nil {
+		return nil, velocypack.WithStack(err)
+	}
+	return result, nil
+}
+
+// MustCompileJSON compiles the given JSON Schema, given in JSON format, panicking on errors.
+func MustCompileJSON(json string) *Schema {
+	s, err := CompileJSON(json)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate checks the given slice against the schema.
+// It returns a ValidationError containing all violations if the slice does not conform to the schema.
+func (s *Schema) Validate(v velocypack.Slice) error {
+	var vs validation
+	if err := s.root.validate(&vs, v, nil); err != nil {
+		return velocypack.WithStack(err)
+	}
+	if len(vs.violations) > 0 {
+		return velocypack.WithStack(ValidationError{Violations: vs.violations})
+	}
+	return nil
+}
+
+// IsValid returns true if the given slice conforms to the schema.
+func (s *Schema) IsValid(v velocypack.Slice) (bool, error) {
+	ok, err := s.root.isValid(v)
+	if err != nil {
+		return false, velocypack.WithStack(err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,525 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package schema
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// validation collects the violations found while validating a slice.
+type validation struct {
+	violations []Violation
+}
+
+// addf records a violation of the given keyword of the given schema.
+func (vs *validation) addf(path []string, n *node, keyword string, format string, args ...interface{}) {
+	schemaPath := n.path
+	if keyword != "" {
+		schemaPath = formatPointer(childPath(parsePointer(n.path), keyword))
+	}
+	vs.violations = append(vs.violations, Violation{
+		Path:       formatPointer(path),
+		SchemaPath: schemaPath,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// isValid returns true if the given slice conforms to the schema of the given node.
+func (n *node) isValid(s velocypack.Slice) (bool, error) {
+	var vs validation
+	if err := n.validate(&vs, s, nil); err != nil {
+		return false, velocypack.WithStack(err)
+	}
+	return len(vs.violations) == 0, nil
+}
+
+// validate checks the given slice against the schema of the node and records all violations.
+func (n *node) validate(vs *validation, s velocypack.Slice, path []string) error {
+	if n.boolean != nil {
+		if !*n.boolean {
+			vs.addf(path, n, "", "no value is allowed")
+		}
+		return nil
+	}
+	if n.ref != nil {
+		if err := n.ref.validate(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	}
+	if err := n.validateGeneric(vs, s, path); err != nil {
+		return velocypack.WithStack(err)
+	}
+	switch {
+	case isNumber(s):
+		if err := n.validateNumber(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	case s.IsString():
+		if err := n.validateString(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	case s.IsArray():
+		if err := n.validateArray(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	case s.IsObject():
+		if err := n.validateObject(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	case s.IsUTCDate():
+		if err := n.validateUTCDate(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	case s.IsBinary():
+		if err := n.validateBinary(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	}
+	if err := n.validateApplicators(vs, s, path); err != nil {
+		return velocypack.WithStack(err)
+	}
+	return nil
+}
+
+// validateGeneric checks the keywords that apply to all types of values.
+func (n *node) validateGeneric(vs *validation, s velocypack.Slice, path []string) error {
+	if len(n.types) > 0 {
+		found := false
+		for _, t := range n.types {
+			if ok, err := hasJSONType(s, t); err != nil {
+				return velocypack.WithStack(err)
+			} else if ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vs.addf(path, n, "type", "expected type %v, got '%s'", n.types, s.Type())
+		}
+	}
+	if len(n.vpackTypes) > 0 {
+		found := false
+		for _, t := range n.vpackTypes {
+			if s.Type() == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vs.addf(path, n, "vpackType", "expected VelocyPack type %v, got '%s'", n.vpackTypes, s.Type())
+		}
+	}
+	if n.enum != nil {
+		found := false
+		for _, v := range n.enum {
+			if eq, err := s.Equals(v); err != nil {
+				return velocypack.WithStack(err)
+			} else if eq {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vs.addf(path, n, "enum", "value is not one of the allowed values")
+		}
+	}
+	if n.constValue != nil {
+		if eq, err := s.Equals(n.constValue); err != nil {
+			return velocypack.WithStack(err)
+		} else if !eq {
+			vs.addf(path, n, "const", "value must be %s", jsonString(n.constValue))
+		}
+	}
+	return nil
+}
+
+// validateNumber checks the keywords that apply to numbers.
+func (n *node) validateNumber(vs *validation, s velocypack.Slice, path []string) error {
+	if n.maximum != nil && velocypack.Compare(s, n.maximum) > 0 {
+		vs.addf(path, n, "maximum", "value must be <= %s", jsonString(n.maximum))
+	}
+	if n.exclusiveMaximum != nil && velocypack.Compare(s, n.exclusiveMaximum) >= 0 {
+		vs.addf(path, n, "exclusiveMaximum", "value must be < %s", jsonString(n.exclusiveMaximum))
+	}
+	if n.minimum != nil && velocypack.Compare(s, n.minimum) < 0 {
+		vs.addf(path, n, "minimum", "value must be >= %s", jsonString(n.minimum))
+	}
+	if n.exclusiveMinimum != nil && velocypack.Compare(s, n.exclusiveMinimum) <= 0 {
+		vs.addf(path, n, "exclusiveMinimum", "value must be > %s", jsonString(n.exclusiveMinimum))
+	}
+	if n.multipleOf > 0 {
+		v, err := toFloat64(s)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		q := v / n.multipleOf
+		if math.IsInf(q, 0) || math.IsNaN(q) || math.Abs(q-math.Round(q)) > 1e-9*math.Max(1, math.Abs(q)) {
+			vs.addf(path, n, "multipleOf", "value must be a multiple of %v", n.multipleOf)
+		}
+	}
+	return nil
+}
+
+// validateString checks the keywords that apply to strings.
+func (n *node) validateString(vs *validation, s velocypack.Slice, path []string) error {
+	if n.maxLength < 0 && n.minLength == 0 && n.pattern == nil {
+		return nil
+	}
+	str, err := s.GetString()
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	length := utf8.RuneCountInString(str)
+	if n.maxLength >= 0 && length > n.maxLength {
+		vs.addf(path, n, "maxLength", "string must have at most %d characters", n.maxLength)
+	}
+	if length < n.minLength {
+		vs.addf(path, n, "minLength", "string must have at least %d characters", n.minLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(str) {
+		vs.addf(path, n, "pattern", "string must match pattern '%s'", n.pattern)
+	}
+	return nil
+}
+
+// validateArray checks the keywords that apply to arrays.
+func (n *node) validateArray(vs *validation, s velocypack.Slice, path []string) error {
+	length, err := s.Length()
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	if n.maxItems >= 0 && int(length) > n.maxItems {
+		vs.addf(path, n, "maxItems", "array must have at most %d items", n.maxItems)
+	}
+	if int(length) < n.minItems {
+		vs.addf(path, n, "minItems", "array must have at least %d items", n.minItems)
+	}
+	if !n.uniqueItems && n.prefixItems == nil && n.items == nil && n.contains == nil {
+		return nil
+	}
+	var seen []velocypack.Slice
+	containsCount := 0
+	it, err := velocypack.NewArrayIterator(s)
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	for i := 0; it.IsValid(); i++ {
+		item, err := it.Value()
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		itemPath := childPath(path, strconv.Itoa(i))
+		if n.uniqueItems {
+			for _, x := range seen {
+				if eq, err := x.Equals(item); err != nil {
+					return velocypack.WithStack(err)
+				} else if eq {
+					vs.addf(itemPath, n, "uniqueItems", "array items must be unique")
+					break
+				}
+			}
+			seen = append(seen, item)
+		}
+		if i < len(n.prefixItems) {
+			if err := n.prefixItems[i].validate(vs, item, itemPath); err != nil {
+				return velocypack.WithStack(err)
+			}
+		} else if n.items != nil {
+			if err := n.items.validate(vs, item, itemPath); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+		if n.contains != nil {
+			if ok, err := n.contains.isValid(item); err != nil {
+				return velocypack.WithStack(err)
+			} else if ok {
+				containsCount++
+			}
+		}
+		if err := it.Next(); err != nil {
+			return velocypack.WithStack(err)
+		}
+	}
+	if n.contains != nil {
+		// Without minContains, contains requires at least one matching item.
+		minContains, keyword := n.minContains, "minContains"
+		if minContains < 0 {
+			minContains, keyword = 1, "contains"
+		}
+		if containsCount < minContains {
+			vs.addf(path, n, keyword, "array must contain at least %d matching items, got %d", minContains, containsCount)
+		}
+		if n.maxContains >= 0 && containsCount > n.maxContains {
+			vs.addf(path, n, "maxContains", "array must contain at most %d matching items, got %d", n.maxContains, containsCount)
+		}
+	}
+	return nil
+}
+
+// validateObject checks the keywords that apply to objects.
+func (n *node) validateObject(vs *validation, s velocypack.Slice, path []string) error {
+	length, err := s.Length()
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	if n.maxProperties >= 0 && int(length) > n.maxProperties {
+		vs.addf(path, n, "maxProperties", "object must have at most %d properties", n.maxProperties)
+	}
+	if int(length) < n.minProperties {
+		vs.addf(path, n, "minProperties", "object must have at least %d properties", n.minProperties)
+	}
+	for _, name := range n.required {
+		if v, err := s.Get(name); err != nil {
+			return velocypack.WithStack(err)
+		} else if v.IsNone() {
+			vs.addf(path, n, "required", "missing required property '%s'", name)
+		}
+	}
+	it, err := velocypack.NewObjectIterator(s, true)
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	for it.IsValid() {
+		key, err := keyString(it)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		value, err := it.Value()
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		valuePath := childPath(path, key)
+		matched := false
+		if schema, found := n.properties[key]; found {
+			matched = true
+			if err := schema.validate(vs, value, valuePath); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+		for _, pp := range n.patternProperties {
+			if pp.pattern.MatchString(key) {
+				matched = true
+				if err := pp.schema.validate(vs, value, valuePath); err != nil {
+					return velocypack.WithStack(err)
+				}
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			if err := n.additionalProperties.validate(vs, value, valuePath); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+		if n.propertyNames != nil {
+			if err := n.propertyNames.validate(vs, velocypack.StringSlice(key), valuePath); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+		if names, found := n.dependentRequired[key]; found {
+			for _, name := range names {
+				if v, err := s.Get(name); err != nil {
+					return velocypack.WithStack(err)
+				} else if v.IsNone() {
+					vs.addf(path, n, "dependentRequired", "property '%s' requires property '%s'", key, name)
+				}
+			}
+		}
+		if schema, found := n.dependentSchemas[key]; found {
+			if err := schema.validate(vs, s, path); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+		if err := it.Next(); err != nil {
+			return velocypack.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// validateUTCDate checks the extension keywords that apply to UTCDate values.
+func (n *node) validateUTCDate(vs *validation, s velocypack.Slice, path []string) error {
+	if n.minDate == nil && n.maxDate == nil {
+		return nil
+	}
+	t, err := s.GetUTCDate()
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	if n.minDate != nil && t.Before(*n.minDate) {
+		vs.addf(path, n, "vpackMinDate", "date must not be before %s", n.minDate.UTC())
+	}
+	if n.maxDate != nil && t.After(*n.maxDate) {
+		vs.addf(path, n, "vpackMaxDate", "date must not be after %s", n.maxDate.UTC())
+	}
+	return nil
+}
+
+// validateBinary checks the extension keywords that apply to Binary values.
+func (n *node) validateBinary(vs *validation, s velocypack.Slice, path []string) error {
+	length, err := s.GetBinaryLength()
+	if err != nil {
+		return velocypack.WithStack(err)
+	}
+	if n.maxBinaryLength >= 0 && int(length) > n.maxBinaryLength {
+		vs.addf(path, n, "vpackMaxBinaryLength", "binary value must have at most %d bytes", n.maxBinaryLength)
+	}
+	if int(length) < n.minBinaryLength {
+		vs.addf(path, n, "vpackMinBinaryLength", "binary value must have at least %d bytes", n.minBinaryLength)
+	}
+	return nil
+}
+
+// validateApplicators checks the keywords that combine subschemas.
+func (n *node) validateApplicators(vs *validation, s velocypack.Slice, path []string) error {
+	for _, schema := range n.allOf {
+		if err := schema.validate(vs, s, path); err != nil {
+			return velocypack.WithStack(err)
+		}
+	}
+	if len(n.anyOf) > 0 {
+		found := false
+		for _, schema := range n.anyOf {
+			if ok, err := schema.isValid(s); err != nil {
+				return velocypack.WithStack(err)
+			} else if ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vs.addf(path, n, "anyOf", "value must match at least one schema")
+		}
+	}
+	if len(n.oneOf) > 0 {
+		count := 0
+		for _, schema := range n.oneOf {
+			if ok, err := schema.isValid(s); err != nil {
+				return velocypack.WithStack(err)
+			} else if ok {
+				count++
+			}
+		}
+		if count != 1 {
+			vs.addf(path, n, "oneOf", "value must match exactly one schema, matched %d", count)
+		}
+	}
+	if n.not != nil {
+		if ok, err := n.not.isValid(s); err != nil {
+			return velocypack.WithStack(err)
+		} else if ok {
+			vs.addf(path, n, "not", "value must not match schema")
+		}
+	}
+	if n.ifNode != nil {
+		ok, err := n.ifNode.isValid(s)
+		if err != nil {
+			return velocypack.WithStack(err)
+		}
+		if ok && n.thenNode != nil {
+			if err := n.thenNode.validate(vs, s, path); err != nil {
+				return velocypack.WithStack(err)
+			}
+		} else if !ok && n.elseNode != nil {
+			if err := n.elseNode.validate(vs, s, path); err != nil {
+				return velocypack.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// hasJSONType returns true if the given slice has the given JSON Schema type.
+func hasJSONType(s velocypack.Slice, t string) (bool, error) {
+	switch t {
+	case "null":
+		return s.IsNull(), nil
+	case "boolean":
+		return s.IsBool(), nil
+	case "object":
+		return s.IsObject(), nil
+	case "array":
+		return s.IsArray(), nil
+	case "number":
+		return isNumber(s), nil
+	case "string":
+		return s.IsString(), nil
+	case "integer":
+		switch {
+		case s.IsInteger():
+			return true, nil
+		case s.IsDouble():
+			f, err := s.GetDouble()
+			if err != nil {
+				return false, velocypack.WithStack(err)
+			}
+			return f == math.Trunc(f) && !math.IsInf(f, 0), nil
+		case s.IsBCD():
+			d, err := s.GetBCD()
+			if err != nil {
+				return false, velocypack.WithStack(err)
+			}
+			_, ok := d.Int()
+			return ok, nil
+		}
+	}
+	return false, nil
+}
+
+// isNumber returns true if the given slice contains a number (including BCD).
+func isNumber(s velocypack.Slice) bool {
+	return s.IsNumber() || s.IsBCD()
+}
+
+// toFloat64 returns the value of the given number slice as float64.
+func toFloat64(s velocypack.Slice) (float64, error) {
+	switch {
+	case s.IsDouble():
+		v, err := s.GetDouble()
+		return v, velocypack.WithStack(err)
+	case s.IsUInt():
+		v, err := s.GetUInt()
+		return float64(v), velocypack.WithStack(err)
+	case s.IsBCD():
+		d, err := s.GetBCD()
+		if err != nil {
+			return 0, velocypack.WithStack(err)
+		}
+		return d.Float64(), nil
+	default:
+		v, err := s.GetInt()
+		return float64(v), velocypack.WithStack(err)
+	}
+}
+
+// jsonString returns the given slice as JSON, for use in messages.
+func jsonString(s velocypack.Slice) string {
+	json, err := s.JSONString()
+	if err != nil {
+		return s.String()
+	}
+	return json
+}
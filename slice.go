@@ -27,6 +27,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"math"
+	"math/big"
 	"time"
 )
 
@@ -101,15 +102,17 @@ func (s Slice) ByteSize() (ValueLength, error) {
 		return ValueLength(1 + ValueLength(h) - 0xbf + ValueLength(readIntegerNonEmpty(s[1:], uint(h)-0xbf))), nil
 
 	case BCD:
-		if h <= 0xcf {
-			// positive BCD
-			vpackAssert(h >= 0xc8 && h < 0xcf)
-			return ValueLength(1 + ValueLength(h) - 0xc7 + ValueLength(readIntegerNonEmpty(s[1:], uint(h)-0xc7))), nil
-		}
+		// head, mantissa length, 4 byte exponent, mantissa
+		lengthSize := bcdLengthSize(h)
+		return ValueLength(1 + ValueLength(lengthSize) + 4 + ValueLength(readIntegerNonEmpty(s[1:], lengthSize))), nil
 
-		// negative BCD
-		vpackAssert(h >= 0xd0 && h < 0xd7)
-		return ValueLength(1 + ValueLength(h) - 0xcf + ValueLength(readIntegerNonEmpty(s[1:], uint(h)-0xcf))), nil
+	case Tagged:
+		_, value := s.firstTag()
+		size, err := value.ByteSize()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		return ValueLength(len(s)-len(value)) + size, nil
 
 	case Custom:
 		vpackAssert(h >= 0xf4)
@@ -274,6 +277,113 @@ func (s Slice) GetUTCDate() (time.Time, error) {
 	return time.Unix(sec, nsec).UTC(), nil
 }
 
+// bcdLengthSize returns the number of bytes used to store the mantissa length of a BCD value.
+func bcdLengthSize(h byte) uint {
+	if h <= 0xcf {
+		// positive BCD
+		vpackAssert(h >= 0xc8)
+		return uint(h) - 0xc7
+	}
+	// negative BCD
+	vpackAssert(h <= 0xd7)
+	return uint(h) - 0xcf
+}
+
+// GetBCD returns the value for a BCD object.
+// Integer slices are returned as a Decimal with exponent 0.
+func (s Slice) GetBCD() (Decimal, error) {
+	if s.IsInteger() {
+		var d Decimal
+		if err := d.UnmarshalVPack(s); err != nil {
+			return Decimal{}, WithStack(err)
+		}
+		return d, nil
+	}
+	if !s.IsBCD() {
+		return Decimal{}, InvalidTypeError{"Expecting type BCD"}
+	}
+	h := s.head()
+	lengthSize := bcdLengthSize(h)
+	length := readIntegerNonEmpty(s[1:], lengthSize)
+	exponent := int32(binary.LittleEndian.Uint32(s[1+lengthSize:]))
+	packed := s[1+lengthSize+4 : 1+uint64(lengthSize)+4+length]
+
+	// unpack 2 digits per byte, most significant digit first
+	digits := make([]byte, 0, 2*len(packed)+1)
+	for _, b := range packed {
+		hi, lo := b>>4, b&0x0f
+		if hi > 9 || lo > 9 {
+			return Decimal{}, WithStack(InvalidBCDError)
+		}
+		digits = append(digits, '0'+hi, '0'+lo)
+	}
+	if len(digits) == 0 {
+		digits = append(digits, '0')
+	}
+	mantissa, ok := new(big.Int).SetString(string(digits), 10)
+	if !ok {
+		return Decimal{}, WithStack(InvalidBCDError)
+	}
+	if h >= 0xd0 {
+		mantissa.Neg(mantissa)
+	}
+	return Decimal{Mantissa: mantissa, Exponent: exponent}, nil
+}
+
+// firstTag returns the outermost tag of a tagged slice and the slice wrapped by that tag.
+// For slices that are not tagged, 0 and the slice itself are returned.
+func (s Slice) firstTag() (uint64, Slice) {
+	switch s.head() {
+	case 0xee:
+		// 1 byte tag
+		return uint64(s[1]), s[2:]
+	case 0xef:
+		// 8 byte tag
+		return readIntegerFixed(s[1:], 8), s[9:]
+	}
+	return 0, s
+}
+
+// GetFirstTag returns the outermost tag of the slice.
+// Returns 0 if the slice is not tagged.
+func (s Slice) GetFirstTag() uint64 {
+	tag, _ := s.firstTag()
+	return tag
+}
+
+// GetTags returns all tags of the slice, starting with the outermost tag.
+// Returns an empty list if the slice is not tagged.
+func (s Slice) GetTags() []uint64 {
+	var tags []uint64
+	for s.IsTagged() {
+		var tag uint64
+		tag, s = s.firstTag()
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// HasTag returns true if the slice is tagged with the given tag.
+func (s Slice) HasTag(tag uint64) bool {
+	for s.IsTagged() {
+		var t uint64
+		t, s = s.firstTag()
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// UnwrapTags returns the value wrapped by all tags of the slice.
+// If the slice is not tagged, the slice itself is returned.
+func (s Slice) UnwrapTags() Slice {
+	for s.IsTagged() {
+		_, s = s.firstTag()
+	}
+	return s
+}
+
 // GetStringUTF8 return the value for a String object as a []byte with UTF-8 values.
 // This function is a bit faster than GetString, since the conversion from
 // []byte to string needs a memory allocation.
@@ -368,6 +478,39 @@ func (s Slice) GetBinary() ([]byte, error) {
 	return s[1+lengthSize : 1+uint64(lengthSize)+length], nil
 }
 
+// GetCustomPayload returns the payload of a Custom value, that is the bytes following
+// the head byte and (for heads 0xf4-0xff) the payload length.
+func (s Slice) GetCustomPayload() ([]byte, error) {
+	if !s.IsCustom() {
+		return nil, InvalidTypeError{"Expecting type Custom"}
+	}
+
+	h := s.head()
+	if l := fixedTypeLengths[h]; l != 0 {
+		return s[1:l], nil
+	}
+	lengthSize := customLengthSize(h)
+	length := readIntegerFixed(s[1:], lengthSize)
+	checkOverflow(ValueLength(length))
+	return s[1+lengthSize : 1+uint64(lengthSize)+length], nil
+}
+
+// customLengthSize returns the number of bytes used to store the payload length
+// of a Custom value with given head, or 0 if the payload has a fixed length.
+func customLengthSize(h byte) uint {
+	switch {
+	case h >= 0xfd:
+		return 8
+	case h >= 0xfa:
+		return 4
+	case h >= 0xf7:
+		return 2
+	case h >= 0xf4:
+		return 1
+	}
+	return 0
+}
+
 // GetBinaryLength return the length for a Binary object
 func (s Slice) GetBinaryLength() (ValueLength, error) {
 	if !s.IsBinary() {
@@ -775,14 +918,20 @@ func (s Slice) getNthValue(index ValueLength) (Slice, error) {
 }
 
 func (s Slice) makeKey() (Slice, error) {
+	key, err := s.makeKeyWith(attributeTranslator)
+	return key, WithStack(err)
+}
+
+// makeKeyWith returns the given key as string, using the given translator for integer keys.
+func (s Slice) makeKeyWith(t AttributeTranslator) (Slice, error) {
 	if s.IsString() {
 		return s, nil
 	}
 	if s.IsSmallInt() || s.IsUInt() {
-		if attributeTranslator == nil {
+		if t == nil {
 			return nil, WithStack(NeedAttributeTranslatorError)
 		}
-		return s.translateUnchecked(), nil
+		return s.translateUncheckedWith(t), nil
 	}
 
 	return nil, InvalidTypeError{"Cannot translate key of this type"}
@@ -918,8 +1067,13 @@ func (s Slice) getUIntUnchecked() uint64 {
 
 // translates an integer key into a string, without checks
 func (s Slice) translateUnchecked() Slice {
+	return s.translateUncheckedWith(attributeTranslator)
+}
+
+// translates an integer key into a string using the given translator, without checks
+func (s Slice) translateUncheckedWith(t AttributeTranslator) Slice {
 	id := s.getUIntUnchecked()
-	key := attributeTranslator.IDToString(id)
+	key := t.IDToString(id)
 	if key == "" {
 		return nil
 	}
@@ -0,0 +1,615 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// EqualsOptions configures how Slice.Equals compares slices.
+type EqualsOptions struct {
+	// If set, integers, doubles and BCD values are never equal to each other,
+	// even if they have the same numeric value.
+	// Integers of different types (SmallInt, Int & UInt) are always compared by value.
+	StrictNumberTypes bool
+	// If set, objects are only equal if their attributes are stored in the same order.
+	StrictKeyOrder bool
+	// If set, tags are ignored. Otherwise tagged values are only equal if they have the same tags.
+	IgnoreTags bool
+	// AttributeTranslator is used to translate integer object keys.
+	// If not set, the translator set with SetAttributeTranslator is used.
+	AttributeTranslator AttributeTranslator
+}
+
+// Equals returns true if the given slice contains the same value as this slice.
+// Numbers are compared by value, regardless of their type (see EqualsOptions.StrictNumberTypes).
+// Objects are equal if they contain the same attributes with equal values,
+// regardless of the way they are stored (indexed, compact or sorted).
+func (s Slice) Equals(other Slice, options ...EqualsOptions) (bool, error) {
+	var opts EqualsOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.AttributeTranslator == nil {
+		opts.AttributeTranslator = attributeTranslator
+	}
+	eq, err := sliceEquals(s, other, opts)
+	if err != nil {
+		return false, WithStack(err)
+	}
+	return eq, nil
+}
+
+// sliceEquals returns true if a and b contain the same value.
+func sliceEquals(a, b Slice, opts EqualsOptions) (bool, error) {
+	if opts.IgnoreTags {
+		a, b = a.UnwrapTags(), b.UnwrapTags()
+	}
+	for a.IsTagged() || b.IsTagged() {
+		if !a.IsTagged() || !b.IsTagged() {
+			return false, nil
+		}
+		tagA, valueA := a.firstTag()
+		tagB, valueB := b.firstTag()
+		if tagA != tagB {
+			return false, nil
+		}
+		a, b = valueA, valueB
+	}
+
+	if isComparableNumber(a) && isComparableNumber(b) {
+		if opts.StrictNumberTypes && numberClass(a) != numberClass(b) {
+			return false, nil
+		}
+		c, err := compareNumbers(a, b)
+		if err != nil {
+			return false, WithStack(err)
+		}
+		return c == 0, nil
+	}
+
+	t := a.Type()
+	if t != b.Type() {
+		return false, nil
+	}
+	switch t {
+	case Bool:
+		return a.head() == b.head(), nil
+	case String:
+		x, err := a.GetStringUTF8()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		y, err := b.GetStringUTF8()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		return bytes.Equal(x, y), nil
+	case Binary:
+		x, err := a.GetBinary()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		y, err := b.GetBinary()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		return bytes.Equal(x, y), nil
+	case Array:
+		eq, err := arrayEquals(a, b, opts)
+		return eq, WithStack(err)
+	case Object:
+		eq, err := objectEquals(a, b, opts)
+		return eq, WithStack(err)
+	default:
+		// Compare raw bytes (UTCDate, Custom) or head only (Null, MinKey, MaxKey, ...)
+		x, err := a.ByteSize()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		y, err := b.ByteSize()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		return bytes.Equal(a[:x], b[:y]), nil
+	}
+}
+
+// arrayEquals returns true if the given arrays have equal members in the same order.
+func arrayEquals(a, b Slice, opts EqualsOptions) (bool, error) {
+	itA, err := NewArrayIterator(a)
+	if err != nil {
+		return false, WithStack(err)
+	}
+	itB, err := NewArrayIterator(b)
+	if err != nil {
+		return false, WithStack(err)
+	}
+	if itA.size != itB.size {
+		return false, nil
+	}
+	for itA.IsValid() {
+		x, err := itA.Value()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		y, err := itB.Value()
+		if err != nil {
+			return false, WithStack(err)
+		}
+		if eq, err := sliceEquals(x, y, opts); err != nil {
+			return false, WithStack(err)
+		} else if !eq {
+			return false, nil
+		}
+		if err := itA.Next(); err != nil {
+			return false, WithStack(err)
+		}
+		if err := itB.Next(); err != nil {
+			return false, WithStack(err)
+		}
+	}
+	return true, nil
+}
+
+// objectEquals returns true if the given objects have the same attributes with equal values.
+func objectEquals(a, b Slice, opts EqualsOptions) (bool, error) {
+	attrsA, err := objectAttributes(a, opts.AttributeTranslator)
+	if err != nil {
+		return false, WithStack(err)
+	}
+	attrsB, err := objectAttributes(b, opts.AttributeTranslator)
+	if err != nil {
+		return false, WithStack(err)
+	}
+	if len(attrsA) != len(attrsB) {
+		return false, nil
+	}
+	if !opts.StrictKeyOrder {
+		sortAttributes(attrsA)
+		sortAttributes(attrsB)
+	}
+	for i, x := range attrsA {
+		y := attrsB[i]
+		if !bytes.Equal(x.key, y.key) {
+			return false, nil
+		}
+		if eq, err := sliceEquals(x.value, y.value, opts); err != nil {
+			return false, WithStack(err)
+		} else if !eq {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// objectAttribute is a single key+value of an object.
+type objectAttribute struct {
+	key   []byte
+	value Slice
+}
+
+// objectAttributes returns all attributes of the given object in the order in which they are stored.
+func objectAttributes(s Slice, t AttributeTranslator) ([]objectAttribute, error) {
+	it, err := NewObjectIterator(s, true)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	it.SetAttributeTranslator(t)
+	attrs := make([]objectAttribute, 0, it.size)
+	for it.IsValid() {
+		key, err := it.Key(true)
+		if err != nil {
+			return nil, WithStack(err)
+		}
+		k, err := key.GetStringUTF8()
+		if err != nil {
+			return nil, WithStack(err)
+		}
+		value, err := it.Value()
+		if err != nil {
+			return nil, WithStack(err)
+		}
+		attrs = append(attrs, objectAttribute{key: k, value: value})
+		if err := it.Next(); err != nil {
+			return nil, WithStack(err)
+		}
+	}
+	return attrs, nil
+}
+
+// sortAttributes sorts the given attributes by key.
+func sortAttributes(attrs []objectAttribute) {
+	sort.SliceStable(attrs, func(i, j int) bool {
+		return bytes.Compare(attrs[i].key, attrs[j].key) < 0
+	})
+}
+
+// Compare compares the values of the given slices, using the ordering of ArangoDB (AQL).
+// It returns -1 if a < b, 0 if a == b and 1 if a > b.
+//
+// Values of different types are ordered as follows:
+//
+//	MinKey < None, Illegal & Null < Bool < numbers & UTCDate < String < Binary < Custom < Array < Object < MaxKey
+//
+// Numbers are compared by value, regardless of their type. UTCDate values are compared as
+// the number of milliseconds since the Unix epoch.
+// Strings are compared by their UTF-8 bytes.
+// Arrays are compared member by member, where a missing member is treated as Null.
+// Objects are compared by the values of all their attribute names (in sorted order),
+// where a missing attribute is treated as Null.
+// Tags are ignored.
+//
+// The given slices must contain valid VelocyPack, Compare panics otherwise.
+// Use Slice.Compare to get an error instead.
+func Compare(a, b Slice) int {
+	c, err := compareSlices(a, b)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Compare returns -1, 0 or 1 when this slice is less than, equal to or greater than
+// the given slice, in the ordering used by Compare.
+// It returns an error when one of the slices does not contain valid VelocyPack.
+func (s Slice) Compare(other Slice) (int, error) {
+	c, err := compareSlices(s, other)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	return c, nil
+}
+
+// compareWeight returns the position of the type of the given slice in the ordering used by Compare.
+func compareWeight(s Slice) int {
+	switch s.Type() {
+	case MinKey:
+		return -1
+	case Bool:
+		return 1
+	case SmallInt, Int, UInt, Double, BCD, UTCDate:
+		return 2
+	case String:
+		return 3
+	case Binary:
+		return 4
+	case Custom:
+		return 5
+	case Array:
+		return 6
+	case Object:
+		return 7
+	case MaxKey:
+		return 8
+	default:
+		// None, Illegal & Null
+		return 0
+	}
+}
+
+// compareSlices implements Compare.
+func compareSlices(a, b Slice) (int, error) {
+	a, b = a.UnwrapTags(), b.UnwrapTags()
+	wa, wb := compareWeight(a), compareWeight(b)
+	if wa != wb {
+		return compareInts(wa, wb), nil
+	}
+	switch a.Type() {
+	case Bool:
+		return compareInts(int(a.head()), int(b.head())), nil
+	case SmallInt, Int, UInt, Double, BCD, UTCDate:
+		c, err := compareNumbers(a, b)
+		return c, WithStack(err)
+	case String:
+		x, err := a.GetStringUTF8()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		y, err := b.GetStringUTF8()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		return bytes.Compare(x, y), nil
+	case Binary:
+		x, err := a.GetBinary()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		y, err := b.GetBinary()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		return bytes.Compare(x, y), nil
+	case Custom:
+		x, err := a.ByteSize()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		y, err := b.ByteSize()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		return bytes.Compare(a[:x], b[:y]), nil
+	case Array:
+		c, err := compareArrays(a, b)
+		return c, WithStack(err)
+	case Object:
+		c, err := compareObjects(a, b)
+		return c, WithStack(err)
+	default:
+		return 0, nil
+	}
+}
+
+// compareArrays compares the given arrays member by member.
+func compareArrays(a, b Slice) (int, error) {
+	itA, err := NewArrayIterator(a)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	itB, err := NewArrayIterator(b)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	for itA.IsValid() || itB.IsValid() {
+		x, y := NoneSlice(), NoneSlice()
+		if itA.IsValid() {
+			if x, err = itA.Value(); err != nil {
+				return 0, WithStack(err)
+			}
+			if err := itA.Next(); err != nil {
+				return 0, WithStack(err)
+			}
+		}
+		if itB.IsValid() {
+			if y, err = itB.Value(); err != nil {
+				return 0, WithStack(err)
+			}
+			if err := itB.Next(); err != nil {
+				return 0, WithStack(err)
+			}
+		}
+		if c, err := compareSlices(x, y); err != nil {
+			return 0, WithStack(err)
+		} else if c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
+
+// compareObjects compares the given objects by the values of all attribute names, in sorted order.
+func compareObjects(a, b Slice) (int, error) {
+	attrsA, err := objectAttributes(a, attributeTranslator)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	attrsB, err := objectAttributes(b, attributeTranslator)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	sortAttributes(attrsA)
+	sortAttributes(attrsB)
+	for len(attrsA) > 0 || len(attrsB) > 0 {
+		x, y := NoneSlice(), NoneSlice()
+		switch {
+		case len(attrsB) == 0:
+			x, attrsA = attrsA[0].value, attrsA[1:]
+		case len(attrsA) == 0:
+			y, attrsB = attrsB[0].value, attrsB[1:]
+		default:
+			switch bytes.Compare(attrsA[0].key, attrsB[0].key) {
+			case -1:
+				x, attrsA = attrsA[0].value, attrsA[1:]
+			case 1:
+				y, attrsB = attrsB[0].value, attrsB[1:]
+			default:
+				x, attrsA = attrsA[0].value, attrsA[1:]
+				y, attrsB = attrsB[0].value, attrsB[1:]
+			}
+		}
+		if c, err := compareSlices(x, y); err != nil {
+			return 0, WithStack(err)
+		} else if c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
+
+// isComparableNumber returns true if the given slice is compared by numeric value.
+func isComparableNumber(s Slice) bool {
+	return s.IsNumber() || s.IsBCD()
+}
+
+// numberClass returns the kind of number (integer, double or BCD) of the given slice.
+func numberClass(s Slice) ValueType {
+	if s.IsInteger() {
+		return Int
+	}
+	return s.Type()
+}
+
+// compareNumbers compares the numeric values of the given number, BCD or UTCDate slices.
+func compareNumbers(a, b Slice) (int, error) {
+	if isIntegral(a) && isIntegral(b) {
+		c, err := compareIntegers(a, b)
+		return c, WithStack(err)
+	}
+	if a.IsDouble() && b.IsDouble() {
+		x, err := a.GetDouble()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		y, err := b.GetDouble()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		return compareFloats(x, y), nil
+	}
+	x, xf, err := numberRat(a)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	y, yf, err := numberRat(b)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	switch {
+	case x == nil && y == nil:
+		return compareFloats(xf, yf), nil
+	case x == nil:
+		// a is NaN or infinite
+		if math.IsInf(xf, 1) {
+			return 1, nil
+		}
+		return -1, nil
+	case y == nil:
+		// b is NaN or infinite
+		if math.IsInf(yf, 1) {
+			return -1, nil
+		}
+		return 1, nil
+	default:
+		return x.Cmp(y), nil
+	}
+}
+
+// isIntegral returns true if the given slice is an integer or UTCDate.
+func isIntegral(s Slice) bool {
+	return s.IsInteger() || s.IsUTCDate()
+}
+
+// integerValue returns the value of the given integer or UTCDate slice.
+// If the value does not fit in an int64, it is returned as uint64 with isUnsigned set.
+func integerValue(s Slice) (v int64, u uint64, isUnsigned bool, err error) {
+	if s.IsUTCDate() {
+		return toInt64(readIntegerFixed(s[1:], 8)), 0, false, nil
+	}
+	if s.IsUInt() {
+		u, err := s.GetUInt()
+		if err != nil {
+			return 0, 0, false, WithStack(err)
+		}
+		if u > math.MaxInt64 {
+			return 0, u, true, nil
+		}
+		return int64(u), 0, false, nil
+	}
+	v, err = s.GetInt()
+	if err != nil {
+		return 0, 0, false, WithStack(err)
+	}
+	return v, 0, false, nil
+}
+
+// compareIntegers compares the given integer or UTCDate slices.
+func compareIntegers(a, b Slice) (int, error) {
+	x, xu, xUnsigned, err := integerValue(a)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	y, yu, yUnsigned, err := integerValue(b)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	switch {
+	case xUnsigned && yUnsigned:
+		if xu < yu {
+			return -1, nil
+		} else if xu > yu {
+			return 1, nil
+		}
+		return 0, nil
+	case xUnsigned:
+		return 1, nil
+	case yUnsigned:
+		return -1, nil
+	default:
+		if x < y {
+			return -1, nil
+		} else if x > y {
+			return 1, nil
+		}
+		return 0, nil
+	}
+}
+
+// numberRat returns the value of the given number, BCD or UTCDate slice as rational number.
+// For doubles that are NaN or infinite, nil is returned together with the double.
+func numberRat(s Slice) (*big.Rat, float64, error) {
+	switch {
+	case s.IsDouble():
+		f, err := s.GetDouble()
+		if err != nil {
+			return nil, 0, WithStack(err)
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, f, nil
+		}
+		return new(big.Rat).SetFloat64(f), 0, nil
+	case s.IsBCD():
+		d, err := s.GetBCD()
+		if err != nil {
+			return nil, 0, WithStack(err)
+		}
+		return d.Rat(), 0, nil
+	default:
+		v, u, isUnsigned, err := integerValue(s)
+		if err != nil {
+			return nil, 0, WithStack(err)
+		}
+		if isUnsigned {
+			return new(big.Rat).SetInt(new(big.Int).SetUint64(u)), 0, nil
+		}
+		return new(big.Rat).SetInt64(v), 0, nil
+	}
+}
+
+// compareFloats compares the given doubles, where NaN is less than any other number.
+func compareFloats(x, y float64) int {
+	switch {
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x) || x < y:
+		return -1
+	case math.IsNaN(y) || x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareInts compares the given ints.
+func compareInts(x, y int) int {
+	if x < y {
+		return -1
+	} else if x > y {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,327 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// ChangeType specifies the kind of a Change.
+type ChangeType int
+
+const (
+	// ChangeAdded indicates a value that only exists in the new slice.
+	ChangeAdded ChangeType = iota
+	// ChangeRemoved indicates a value that only exists in the old slice.
+	ChangeRemoved
+	// ChangeModified indicates a value that is different in the old & new slice.
+	ChangeModified
+)
+
+// String returns a human readable name of the change type.
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes a single difference found by Diff.
+type Change struct {
+	Type ChangeType
+	// Path contains the attribute names & array indices leading to the changed value.
+	// An empty path refers to the entire value.
+	Path []string
+	// OldValue is the value in the old slice (None for added values).
+	OldValue Slice
+	// NewValue is the value in the new slice (None for removed values).
+	NewValue Slice
+}
+
+// Pointer returns the path of the change as JSON Pointer (RFC 6901).
+func (c Change) Pointer() string {
+	return formatJSONPointer(c.Path)
+}
+
+// Diff returns the differences between old slice a and new slice b.
+// Objects are compared attribute by attribute, regardless of the way they are stored
+// (indexed, compact or sorted). Arrays are compared element by element.
+// Numbers are compared by value (see Slice.Equals).
+// The returned values refer to the memory of the given slices (no data is copied).
+//
+// The changes are ordered such that they can be applied one after another,
+// e.g. array elements are removed from the end towards the start.
+func Diff(a, b Slice) ([]Change, error) {
+	var changes []Change
+	if err := diffValues(nil, a, b, &changes); err != nil {
+		return nil, WithStack(err)
+	}
+	return changes, nil
+}
+
+// DiffPatch returns the differences between old slice a and new slice b as JSON Patch (RFC 6902).
+// Applying the result to a using ApplyPatch yields b.
+func DiffPatch(a, b Slice) (Slice, error) {
+	changes, err := Diff(a, b)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	builder := NewBuilder(0)
+	if err := builder.OpenArray(); err != nil {
+		return nil, WithStack(err)
+	}
+	for _, c := range changes {
+		if err := builder.OpenObject(); err != nil {
+			return nil, WithStack(err)
+		}
+		var op string
+		switch c.Type {
+		case ChangeAdded:
+			op = "add"
+		case ChangeRemoved:
+			op = "remove"
+		default:
+			op = "replace"
+		}
+		if err := builder.addInternalKeyValue("op", NewStringValue(op)); err != nil {
+			return nil, WithStack(err)
+		}
+		if err := builder.addInternalKeyValue("path", NewStringValue(c.Pointer())); err != nil {
+			return nil, WithStack(err)
+		}
+		if c.Type != ChangeRemoved {
+			if err := builder.addInternalKeyValue("value", NewSliceValue(c.NewValue)); err != nil {
+				return nil, WithStack(err)
+			}
+		}
+		if err := builder.Close(); err != nil {
+			return nil, WithStack(err)
+		}
+	}
+	if err := builder.Close(); err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := builder.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// DiffMergePatch returns the differences between old slice a and new slice b as JSON Merge Patch (RFC 7396).
+// Applying the result to a using DeepMerge yields b.
+// Since a merge patch cannot express changes inside arrays, modified arrays are included as a whole.
+// A merge patch also cannot express null values inside objects, since null removes an attribute.
+func DiffMergePatch(a, b Slice) (Slice, error) {
+	builder := NewBuilder(0)
+	if err := builder.addMergePatch(a, b); err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := builder.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// diffValues appends the differences between a & b at the given path to changes.
+func diffValues(path []string, a, b Slice, changes *[]Change) error {
+	switch {
+	case a.IsObject() && b.IsObject():
+		return WithStack(diffObjects(path, a, b, changes))
+	case a.IsArray() && b.IsArray():
+		return WithStack(diffArrays(path, a, b, changes))
+	}
+	eq, err := a.Equals(b)
+	if err != nil {
+		return WithStack(err)
+	}
+	if !eq {
+		*changes = append(*changes, Change{Type: ChangeModified, Path: path, OldValue: a, NewValue: b})
+	}
+	return nil
+}
+
+// diffObjects appends the differences between objects a & b at the given path to changes.
+// Attributes are visited in sorted order.
+func diffObjects(path []string, a, b Slice, changes *[]Change) error {
+	attrsA, err := objectAttributes(a, attributeTranslator)
+	if err != nil {
+		return WithStack(err)
+	}
+	attrsB, err := objectAttributes(b, attributeTranslator)
+	if err != nil {
+		return WithStack(err)
+	}
+	sortAttributes(attrsA)
+	sortAttributes(attrsB)
+	i, j := 0, 0
+	for i < len(attrsA) || j < len(attrsB) {
+		var c int
+		switch {
+		case i >= len(attrsA):
+			c = 1
+		case j >= len(attrsB):
+			c = -1
+		default:
+			c = bytes.Compare(attrsA[i].key, attrsB[j].key)
+		}
+		switch {
+		case c < 0:
+			*changes = append(*changes, Change{Type: ChangeRemoved, Path: childPath(path, string(attrsA[i].key)), OldValue: attrsA[i].value})
+			i++
+		case c > 0:
+			*changes = append(*changes, Change{Type: ChangeAdded, Path: childPath(path, string(attrsB[j].key)), NewValue: attrsB[j].value})
+			j++
+		default:
+			if err := diffValues(childPath(path, string(attrsA[i].key)), attrsA[i].value, attrsB[j].value, changes); err != nil {
+				return WithStack(err)
+			}
+			i++
+			j++
+		}
+	}
+	return nil
+}
+
+// diffArrays appends the differences between arrays a & b at the given path to changes.
+func diffArrays(path []string, a, b Slice, changes *[]Change) error {
+	lenA, err := a.Length()
+	if err != nil {
+		return WithStack(err)
+	}
+	lenB, err := b.Length()
+	if err != nil {
+		return WithStack(err)
+	}
+	// Compare common elements
+	for i := ValueLength(0); i < lenA && i < lenB; i++ {
+		va, err := a.At(i)
+		if err != nil {
+			return WithStack(err)
+		}
+		vb, err := b.At(i)
+		if err != nil {
+			return WithStack(err)
+		}
+		if err := diffValues(childPath(path, strconv.FormatUint(uint64(i), 10)), va, vb, changes); err != nil {
+			return WithStack(err)
+		}
+	}
+	// Remove elements from the end, so indices of earlier removals stay valid
+	for i := lenA; i > lenB; i-- {
+		va, err := a.At(i - 1)
+		if err != nil {
+			return WithStack(err)
+		}
+		*changes = append(*changes, Change{Type: ChangeRemoved, Path: childPath(path, strconv.FormatUint(uint64(i-1), 10)), OldValue: va})
+	}
+	// Add new elements
+	for i := lenA; i < lenB; i++ {
+		vb, err := b.At(i)
+		if err != nil {
+			return WithStack(err)
+		}
+		*changes = append(*changes, Change{Type: ChangeAdded, Path: childPath(path, strconv.FormatUint(uint64(i), 10)), NewValue: vb})
+	}
+	return nil
+}
+
+// childPath returns a copy of the given path, extended with the given token.
+func childPath(path []string, token string) []string {
+	result := make([]string, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, token)
+}
+
+// addMergePatch adds a merge patch that turns from into to, to the builder.
+func (b *Builder) addMergePatch(from, to Slice) error {
+	if !from.IsObject() || !to.IsObject() {
+		return WithStack(b.addInternal(NewSliceValue(to)))
+	}
+	attrsFrom, err := objectAttributes(from, attributeTranslator)
+	if err != nil {
+		return WithStack(err)
+	}
+	attrsTo, err := objectAttributes(to, attributeTranslator)
+	if err != nil {
+		return WithStack(err)
+	}
+	sortAttributes(attrsFrom)
+	sortAttributes(attrsTo)
+	if err := b.OpenObject(); err != nil {
+		return WithStack(err)
+	}
+	i, j := 0, 0
+	for i < len(attrsFrom) || j < len(attrsTo) {
+		var c int
+		switch {
+		case i >= len(attrsFrom):
+			c = 1
+		case j >= len(attrsTo):
+			c = -1
+		default:
+			c = bytes.Compare(attrsFrom[i].key, attrsTo[j].key)
+		}
+		switch {
+		case c < 0:
+			// Removed attribute
+			if err := b.addInternalKeyValue(string(attrsFrom[i].key), NewNullValue()); err != nil {
+				return WithStack(err)
+			}
+			i++
+		case c > 0:
+			// Added attribute
+			if err := b.addInternalKeyValue(string(attrsTo[j].key), NewSliceValue(attrsTo[j].value)); err != nil {
+				return WithStack(err)
+			}
+			j++
+		default:
+			eq, err := attrsFrom[i].value.Equals(attrsTo[j].value)
+			if err != nil {
+				return WithStack(err)
+			}
+			if !eq {
+				if err := b.AddValue(NewStringValue(string(attrsTo[j].key))); err != nil {
+					return WithStack(err)
+				}
+				if err := b.addMergePatch(attrsFrom[i].value, attrsTo[j].value); err != nil {
+					return WithStack(err)
+				}
+			}
+			i++
+			j++
+		}
+	}
+	if err := b.Close(); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
@@ -67,3 +67,29 @@ func StringSlice(s string) Slice {
 	copy(buf[1+8:], raw)
 	return buf
 }
+
+// CustomSlice creates a slice of type Custom with given head (0xf0-0xff) and payload.
+// For heads 0xf0, 0xf1, 0xf2 & 0xf3 the payload must be exactly 1, 2, 4 or 8 bytes long.
+// For the other heads the payload length is stored in 1 (0xf4-0xf6), 2 (0xf7-0xf9),
+// 4 (0xfa-0xfc) or 8 (0xfd-0xff) bytes.
+func CustomSlice(head byte, payload []byte) (Slice, error) {
+	if head < 0xf0 {
+		return nil, WithStack(BuilderUnexpectedTypeError{"Head of a custom value must be in range 0xf0-0xff"})
+	}
+	l := uint64(len(payload))
+	if fixed := fixedTypeLengths[head]; fixed != 0 {
+		if l != uint64(fixed-1) {
+			return nil, WithStack(BuilderUnexpectedTypeError{"Invalid payload length for custom value"})
+		}
+		return Slice(append([]byte{head}, payload...)), nil
+	}
+	lengthSize := customLengthSize(head)
+	if lengthSize < 8 && l >= uint64(1)<<(8*lengthSize) {
+		return nil, WithStack(BuilderUnexpectedTypeError{"Payload too long for custom value"})
+	}
+	buf := make([]byte, 1+lengthSize+uint(l))
+	buf[0] = head
+	setLength(buf[1:], ValueLength(l), lengthSize)
+	copy(buf[1+lengthSize:], payload)
+	return buf, nil
+}
@@ -0,0 +1,228 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// DefaultHashSeed is the seed used by NormalizedHash.
+const DefaultHashSeed uint64 = 0xdeadbeef
+
+// hashFunc is a 64-bit hash function with a seed.
+type hashFunc func(data []byte, seed uint64) uint64
+
+// Hash64 returns a 64-bit xxHash of the value of the slice, using the given seed.
+// The hash is computed from the value, not from the encoding of the slice, so slices that
+// are equal according to Equals (with default options) have the same hash.
+func (s Slice) Hash64(seed uint64) (uint64, error) {
+	h, err := normalizedHash(s, seed, xxHash64)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	return h, nil
+}
+
+// HashFNV64 returns a 64-bit FNV-1a hash of the value of the slice, using the given seed.
+// Like Hash64, slices that are equal according to Equals have the same hash.
+func (s Slice) HashFNV64(seed uint64) (uint64, error) {
+	h, err := normalizedHash(s, seed, fnv1a64)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	return h, nil
+}
+
+// NormalizedHash returns a 64-bit xxHash of the value of the slice, using DefaultHashSeed.
+func (s Slice) NormalizedHash() (uint64, error) {
+	h, err := normalizedHash(s, DefaultHashSeed, xxHash64)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	return h, nil
+}
+
+// normalizedHash hashes the value of the given slice with the given hash function.
+// Every value is hashed with a type specific marker, followed by a canonical representation
+// of its value.
+func normalizedHash(s Slice, seed uint64, hash hashFunc) (uint64, error) {
+	var buf [9]byte
+	switch s.Type() {
+	case None, Illegal, Null:
+		buf[0] = 0x18
+		return hash(buf[:1], seed), nil
+	case Bool, MinKey, MaxKey:
+		buf[0] = s.head()
+		return hash(buf[:1], seed), nil
+	case SmallInt, Int, UInt, Double, BCD:
+		// All numbers are hashed as double, since numbers that are equal by value
+		// must have the same hash.
+		f, err := hashableFloat64(s)
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		buf[0] = 0x1b
+		binary.LittleEndian.PutUint64(buf[1:], math.Float64bits(f))
+		return hash(buf[:], seed), nil
+	case String:
+		v, err := s.GetStringUTF8()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		buf[0] = 0xbf
+		return hash(v, hash(buf[:1], seed)), nil
+	case Binary:
+		v, err := s.GetBinary()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		buf[0] = 0xc7
+		return hash(v, hash(buf[:1], seed)), nil
+	case Tagged:
+		tag, value := s.firstTag()
+		buf[0] = 0xef
+		binary.LittleEndian.PutUint64(buf[1:], tag)
+		h, err := normalizedHash(value, hash(buf[:], seed), hash)
+		return h, WithStack(err)
+	case Array:
+		it, err := NewArrayIterator(s)
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		buf[0] = 0x13
+		binary.LittleEndian.PutUint64(buf[1:], uint64(it.size))
+		h := hash(buf[:], seed)
+		for it.IsValid() {
+			v, err := it.Value()
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			if h, err = normalizedHash(v, h, hash); err != nil {
+				return 0, WithStack(err)
+			}
+			if err := it.Next(); err != nil {
+				return 0, WithStack(err)
+			}
+		}
+		return h, nil
+	case Object:
+		// Attribute hashes are combined in an order independent way.
+		it, err := NewObjectIterator(s, true)
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		var sum uint64
+		for it.IsValid() {
+			key, err := it.Key(true)
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			k, err := key.GetStringUTF8()
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			v, err := it.Value()
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			h, err := normalizedHash(v, hash(k, seed), hash)
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			sum += h
+			if err := it.Next(); err != nil {
+				return 0, WithStack(err)
+			}
+		}
+		buf[0] = 0x14
+		binary.LittleEndian.PutUint64(buf[1:], uint64(it.size))
+		h := hash(buf[:], seed)
+		binary.LittleEndian.PutUint64(buf[1:], sum)
+		return hash(buf[:], h), nil
+	default:
+		// UTCDate, Custom: hash raw bytes
+		l, err := s.ByteSize()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		return hash(s[:l], seed), nil
+	}
+}
+
+// hashableFloat64 returns the value of the given number slice as double, normalized such
+// that numbers that are equal by value result in the same double.
+func hashableFloat64(s Slice) (float64, error) {
+	var f float64
+	switch {
+	case s.IsDouble():
+		v, err := s.GetDouble()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		f = v
+	case s.IsBCD():
+		d, err := s.GetBCD()
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		f = d.Float64()
+	default:
+		v, u, isUnsigned, err := integerValue(s)
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		if isUnsigned {
+			f = float64(u)
+		} else {
+			f = float64(v)
+		}
+	}
+	if f == 0 {
+		// Normalize -0
+		return 0, nil
+	}
+	if math.IsNaN(f) {
+		return math.NaN(), nil
+	}
+	return f, nil
+}
+
+const (
+	fnvOffset64 uint64 = 14695981039346656037
+	fnvPrime64  uint64 = 1099511628211
+)
+
+// fnv1a64 returns the 64-bit FNV-1a hash of the given seed (as 8 little endian bytes), followed by the given data.
+func fnv1a64(data []byte, seed uint64) uint64 {
+	h := fnvOffset64
+	for i := uint(0); i < 8; i++ {
+		h ^= (seed >> (8 * i)) & 0xff
+		h *= fnvPrime64
+	}
+	for _, c := range data {
+		h ^= uint64(c)
+		h *= fnvPrime64
+	}
+	return h
+}
@@ -0,0 +1,187 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"errors"
+	"iter"
+)
+
+// Values returns an iterator over the elements of an array or the attribute values of an object.
+// Values are returned in the order in which they are stored, which for objects is not necessarily sorted.
+// When the slice is not an array or object, or when it contains invalid data, the iterator yields a nil slice and an error
+// and stops.
+// Iterating indexed and compact containers does not allocate.
+func (s Slice) Values() iter.Seq2[Slice, error] {
+	return func(yield func(Slice, error) bool) {
+		isObject := s.IsObject()
+		if !isObject && !s.IsArray() {
+			yield(nil, WithStack(InvalidTypeError{"Expecting type Array or Object"}))
+			return
+		}
+		s.forEachMember(isObject, func(key, value Slice, err error) bool {
+			return yield(value, err)
+		})
+	}
+}
+
+// Entries returns an iterator over the keys and values of an object.
+// Keys are translated to strings (see SetAttributeTranslator).
+// Attributes are returned in the order in which they are stored, which is not necessarily sorted.
+// When the slice is not an object, the iterator yields nothing.
+// Iteration stops when the object contains invalid data, use Values or ObjectIterator when such errors must be detected.
+// Iterating indexed and compact objects with string keys does not allocate.
+func (s Slice) Entries() iter.Seq2[Slice, Slice] {
+	return func(yield func(Slice, Slice) bool) {
+		if !s.IsObject() {
+			return
+		}
+		s.forEachMember(true, func(key, value Slice, err error) bool {
+			if err != nil {
+				return false
+			}
+			key, err = key.makeKey()
+			if err != nil {
+				return false
+			}
+			return yield(key, value)
+		})
+	}
+}
+
+// forEachMember calls fn for all members of an array or object (with the given isObject flag) in the
+// order in which they are stored. For arrays, key is always nil.
+// When an error occurs, fn is called with the error and iteration stops.
+// Iteration also stops when fn returns false.
+func (s Slice) forEachMember(isObject bool, fn func(key, value Slice, err error) bool) {
+	n, err := s.Length()
+	if err != nil {
+		fn(nil, nil, WithStack(err))
+		return
+	}
+	if n == 0 {
+		return
+	}
+	var current Slice
+	if h := s.head(); h == 0x13 || h == 0x14 {
+		// compact Array or Object
+		offset, err := s.getNthOffset(0)
+		if err != nil {
+			fn(nil, nil, WithStack(err))
+			return
+		}
+		current = s[offset:]
+	} else {
+		// Members are stored consecutively after the header, the index table can be sorted
+		current = s[s.findDataOffset(h):]
+	}
+	for i := ValueLength(0); i < n; i++ {
+		var key Slice
+		if isObject {
+			size, err := current.ByteSize()
+			if err != nil {
+				fn(nil, nil, WithStack(err))
+				return
+			}
+			key, current = current[:size], current[size:]
+		}
+		size, err := current.ByteSize()
+		if err != nil {
+			fn(nil, nil, WithStack(err))
+			return
+		}
+		if !fn(key, current[:size], nil) {
+			return
+		}
+		current = current[size:]
+	}
+}
+
+// PathElement is an element of the path of a value visited by Walk.
+type PathElement struct {
+	// Key is the attribute name, for values inside an object. It is empty for array elements.
+	Key string
+	// Index is the position of the value inside its array or object (in storage order).
+	Index ValueLength
+}
+
+// WalkFunc is the type of function called by Walk for every visited value.
+// The path contains the elements leading from the root to the given value, it is empty for the root.
+// The path slice is reused between calls, copy it when it must be retained.
+// When the function returns SkipChildren for an array or object, its children are not visited.
+// Any other error stops the walk and is returned by Walk.
+type WalkFunc func(path []PathElement, value Slice) error
+
+// SkipChildren is returned by a WalkFunc to skip the children of the current array or object.
+var SkipChildren = errors.New("skip children")
+
+// Walk traverses the given slice depth-first, calling the visitor for the slice itself
+// and for all of its (nested) array elements and attribute values.
+// Children are visited in the order in which they are stored.
+func Walk(s Slice, visitor WalkFunc) error {
+	path := make([]PathElement, 0, 8)
+	if err := walk(s, path, visitor); err != nil && err != SkipChildren {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// walk visits the given value and its children.
+func walk(s Slice, path []PathElement, visitor WalkFunc) error {
+	if err := visitor(path, s); err == SkipChildren {
+		return nil
+	} else if err != nil {
+		return WithStack(err)
+	}
+	isObject := s.IsObject()
+	if !isObject && !s.IsArray() {
+		return nil
+	}
+	var walkErr error
+	index := ValueLength(0)
+	s.forEachMember(isObject, func(key, value Slice, err error) bool {
+		if err != nil {
+			walkErr = err
+			return false
+		}
+		elem := PathElement{Index: index}
+		if isObject {
+			k, err := key.makeKey()
+			if err != nil {
+				walkErr = err
+				return false
+			}
+			if elem.Key, err = k.GetString(); err != nil {
+				walkErr = err
+				return false
+			}
+		}
+		index++
+		if err := walk(value, append(path, elem), visitor); err != nil {
+			walkErr = err
+			return false
+		}
+		return true
+	})
+	return WithStack(walkErr)
+}
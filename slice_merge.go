@@ -25,6 +25,7 @@ package velocypack
 // Merge creates a slice that contains all fields from all given slices.
 // When a field exists (with same name) in an earlier slice, it is ignored.
 // All slices must be objects.
+// Merge is shallow, nested objects are not merged. Use DeepMerge for a recursive merge.
 func Merge(slices ...Slice) (Slice, error) {
 	// Calculate overall length
 	l := ValueLength(0)
@@ -97,3 +98,212 @@ func Merge(slices ...Slice) (Slice, error) {
 	}
 	return result, nil
 }
+
+// ArrayMergeStrategy specifies how DeepMerge combines 2 arrays.
+type ArrayMergeStrategy int
+
+const (
+	// ArrayMergeReplace uses the winning array as a whole (see MergeOptions.FirstWins).
+	ArrayMergeReplace ArrayMergeStrategy = iota
+	// ArrayMergeConcat appends all elements of the patch array to the elements of the base array.
+	ArrayMergeConcat
+	// ArrayMergeUnion adds all elements of the base array followed by all elements of the patch array,
+	// skipping elements that are equal to an element that was already added.
+	ArrayMergeUnion
+)
+
+// MergeOptions contains options for DeepMerge.
+type MergeOptions struct {
+	// If set, values from the base win over values from the patch.
+	// Otherwise values from the patch win (last-wins).
+	FirstWins bool
+	// If set, attributes with a null value in the patch are stored as null.
+	// Otherwise a null value in the patch removes the attribute from the result (RFC 7396).
+	KeepNull bool
+	// ArrayStrategy specifies how arrays that exist in both base & patch are combined.
+	ArrayStrategy ArrayMergeStrategy
+}
+
+// DeepMerge merges the given patch into the given base and returns the result.
+// Objects are merged recursively. When a value exists in both base & patch and
+// they cannot be merged, the value from the patch is used (unless MergeOptions.FirstWins is set).
+// With the default options, DeepMerge implements JSON Merge Patch (RFC 7396).
+func DeepMerge(base, patch Slice, options ...MergeOptions) (Slice, error) {
+	var opts MergeOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	baseSize, err := base.ByteSize()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	patchSize, err := patch.ByteSize()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	b := NewBuilder(uint(baseSize + patchSize))
+	if err := b.addMerged(base, patch, opts); err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := b.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// addMerged adds the result of merging patch into base to the builder.
+// The base can be a None slice, when the value only exists in the patch.
+func (b *Builder) addMerged(base, patch Slice, opts MergeOptions) error {
+	switch {
+	case patch.IsObject() && (base.IsObject() || base.IsNone()):
+		if base.IsNone() {
+			if opts.KeepNull {
+				return WithStack(b.addInternal(NewSliceValue(patch)))
+			}
+			// Merge with an empty object to remove null values
+			base = EmptyObjectSlice()
+		}
+		return WithStack(b.addMergedObject(base, patch, opts))
+	case patch.IsArray() && base.IsArray() && opts.ArrayStrategy != ArrayMergeReplace:
+		return WithStack(b.addMergedArray(base, patch, opts))
+	case opts.FirstWins && !base.IsNone():
+		return WithStack(b.addInternal(NewSliceValue(base)))
+	default:
+		if patch.IsObject() && !opts.KeepNull {
+			// Merge with an empty object to remove null values
+			return WithStack(b.addMergedObject(EmptyObjectSlice(), patch, opts))
+		}
+		return WithStack(b.addInternal(NewSliceValue(patch)))
+	}
+}
+
+// addMergedObject adds the result of merging the attributes of patch into those of base to the builder.
+func (b *Builder) addMergedObject(base, patch Slice, opts MergeOptions) error {
+	if err := b.OpenObject(); err != nil {
+		return WithStack(err)
+	}
+	// Add attributes of base, merged with those of patch
+	it, err := NewObjectIterator(base, true)
+	if err != nil {
+		return WithStack(err)
+	}
+	for it.IsValid() {
+		keySlice, err := it.Key(true)
+		if err != nil {
+			return WithStack(err)
+		}
+		key, err := keySlice.GetString()
+		if err != nil {
+			return WithStack(err)
+		}
+		baseValue, err := it.Value()
+		if err != nil {
+			return WithStack(err)
+		}
+		patchValue, err := patch.get(key)
+		if err != nil {
+			return WithStack(err)
+		}
+		switch {
+		case patchValue.IsNone():
+			if err := b.addInternalKeyValue(key, NewSliceValue(baseValue)); err != nil {
+				return WithStack(err)
+			}
+		case patchValue.IsNull() && !opts.KeepNull && !opts.FirstWins:
+			// Remove attribute
+		default:
+			if err := b.AddValue(NewStringValue(key)); err != nil {
+				return WithStack(err)
+			}
+			if err := b.addMerged(baseValue, patchValue, opts); err != nil {
+				return WithStack(err)
+			}
+		}
+		if err := it.Next(); err != nil {
+			return WithStack(err)
+		}
+	}
+	// Add attributes that only exist in patch
+	it, err = NewObjectIterator(patch, true)
+	if err != nil {
+		return WithStack(err)
+	}
+	for it.IsValid() {
+		keySlice, err := it.Key(true)
+		if err != nil {
+			return WithStack(err)
+		}
+		key, err := keySlice.GetString()
+		if err != nil {
+			return WithStack(err)
+		}
+		patchValue, err := it.Value()
+		if err != nil {
+			return WithStack(err)
+		}
+		baseValue, err := base.get(key)
+		if err != nil {
+			return WithStack(err)
+		}
+		if baseValue.IsNone() && (opts.KeepNull || !patchValue.IsNull()) {
+			if err := b.AddValue(NewStringValue(key)); err != nil {
+				return WithStack(err)
+			}
+			if err := b.addMerged(nil, patchValue, opts); err != nil {
+				return WithStack(err)
+			}
+		}
+		if err := it.Next(); err != nil {
+			return WithStack(err)
+		}
+	}
+	if err := b.Close(); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// addMergedArray adds the concatenation or union of the elements of base & patch to the builder.
+func (b *Builder) addMergedArray(base, patch Slice, opts MergeOptions) error {
+	if err := b.OpenArray(); err != nil {
+		return WithStack(err)
+	}
+	var added []Slice
+	for _, s := range []Slice{base, patch} {
+		it, err := NewArrayIterator(s)
+		if err != nil {
+			return WithStack(err)
+		}
+		for it.IsValid() {
+			value, err := it.Value()
+			if err != nil {
+				return WithStack(err)
+			}
+			add := true
+			if opts.ArrayStrategy == ArrayMergeUnion {
+				for _, x := range added {
+					if eq, err := x.Equals(value); err != nil {
+						return WithStack(err)
+					} else if eq {
+						add = false
+						break
+					}
+				}
+			}
+			if add {
+				if err := b.addInternal(NewSliceValue(value)); err != nil {
+					return WithStack(err)
+				}
+				added = append(added, value)
+			}
+			if err := it.Next(); err != nil {
+				return WithStack(err)
+			}
+		}
+	}
+	if err := b.Close(); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+// patchMode specifies how a value is modified at the end of a JSON pointer.
+type patchMode int
+
+const (
+	patchModeAdd patchMode = iota
+	patchModeRemove
+	patchModeReplace
+)
+
+// ApplyPatch applies the given JSON Patch (RFC 6902) to the given document and returns the patched document.
+// The patch must be an array of operation objects, each with an "op" & "path" attribute
+// and a "value" or "from" attribute, depending on the operation.
+// Supported operations are add, remove, replace, move, copy & test.
+// When an operation fails, a PatchError is returned that identifies the operation.
+func ApplyPatch(doc Slice, patch Slice) (Slice, error) {
+	if err := patch.AssertType(Array); err != nil {
+		return nil, WithStack(err)
+	}
+	it, err := NewArrayIterator(patch)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	for index := 0; it.IsValid(); index++ {
+		op, err := it.Value()
+		if err != nil {
+			return nil, WithStack(err)
+		}
+		if doc, err = applyPatchOperation(doc, op, index); err != nil {
+			return nil, WithStack(err)
+		}
+		if err := it.Next(); err != nil {
+			return nil, WithStack(err)
+		}
+	}
+	return doc, nil
+}
+
+// applyPatchOperation applies a single patch operation to the given document.
+func applyPatchOperation(doc Slice, op Slice, index int) (Slice, error) {
+	pe := PatchError{Index: index}
+	fail := func(err error) (Slice, error) {
+		pe.Err = Cause(err)
+		return nil, WithStack(pe)
+	}
+	if !op.IsObject() {
+		return fail(InvalidPatchError)
+	}
+	var err error
+	if pe.Op, err = patchStringMember(op, "op"); err != nil {
+		return fail(err)
+	}
+	if pe.Path, err = patchStringMember(op, "path"); err != nil {
+		return fail(err)
+	}
+	path, err := parseJSONPointer(pe.Path)
+	if err != nil {
+		return fail(err)
+	}
+	value, err := op.Get("value")
+	if err != nil {
+		return fail(err)
+	}
+	needValue := pe.Op == "add" || pe.Op == "replace" || pe.Op == "test"
+	if needValue && value.IsNone() {
+		return fail(InvalidPatchError)
+	}
+
+	var result Slice
+	switch pe.Op {
+	case "add":
+		result, err = patchAt(doc, path, value, patchModeAdd)
+	case "remove":
+		result, err = patchAt(doc, path, nil, patchModeRemove)
+	case "replace":
+		result, err = patchAt(doc, path, value, patchModeReplace)
+	case "move", "copy":
+		var fromPointer string
+		var from []string
+		if fromPointer, err = patchStringMember(op, "from"); err != nil {
+			return fail(err)
+		}
+		if from, err = parseJSONPointer(fromPointer); err != nil {
+			return fail(err)
+		}
+		if value, err = getJSONPointer(doc, from); err != nil {
+			return fail(err)
+		}
+		if pe.Op == "copy" {
+			result, err = patchAt(doc, path, value, patchModeAdd)
+			break
+		}
+		if isJSONPointerPrefix(from, path) {
+			if len(from) == len(path) {
+				// Moving a value onto itself
+				return doc, nil
+			}
+			// A value cannot be moved into one of its children
+			return fail(InvalidPatchError)
+		}
+		if result, err = patchAt(doc, from, nil, patchModeRemove); err != nil {
+			return fail(err)
+		}
+		result, err = patchAt(result, path, value, patchModeAdd)
+	case "test":
+		actual, err := getJSONPointer(doc, path)
+		if err != nil {
+			return fail(err)
+		}
+		if eq, err := actual.Equals(value); err != nil {
+			return fail(err)
+		} else if !eq {
+			return fail(PatchTestFailedError)
+		}
+		result = doc
+	default:
+		return fail(InvalidPatchError)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return result, nil
+}
+
+// patchStringMember returns the string value of the attribute with given name of a patch operation.
+func patchStringMember(op Slice, name string) (string, error) {
+	s, err := op.Get(name)
+	if err != nil {
+		return "", WithStack(err)
+	}
+	if !s.IsString() {
+		return "", WithStack(InvalidPatchError)
+	}
+	v, err := s.GetString()
+	if err != nil {
+		return "", WithStack(err)
+	}
+	return v, nil
+}
+
+// isJSONPointerPrefix returns true if the pointer with given prefix tokens is equal to,
+// or a parent of the pointer with given tokens.
+func isJSONPointerPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i, t := range prefix {
+		if tokens[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+// patchAt creates a copy of the given document with the value at the location referred to by the given tokens
+// added, removed or replaced.
+func patchAt(doc Slice, tokens []string, value Slice, mode patchMode) (Slice, error) {
+	if len(tokens) == 0 {
+		// Operation on the entire document
+		if mode == patchModeRemove {
+			return nil, WithStack(InvalidPatchError)
+		}
+		return value, nil
+	}
+	b := NewBuilder(0)
+	if err := b.addPatched(doc, tokens, value, mode); err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := b.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// addPatched adds a copy of the given slice to the builder, with the value at the location
+// referred to by the given tokens added, removed or replaced.
+func (b *Builder) addPatched(s Slice, tokens []string, value Slice, mode patchMode) error {
+	token, rest := tokens[0], tokens[1:]
+	switch {
+	case s.IsObject():
+		existing, err := s.get(token)
+		if err != nil {
+			return WithStack(err)
+		}
+		exists := !existing.IsNone()
+		if !exists && (len(rest) > 0 || mode != patchModeAdd) {
+			return WithStack(PatchPathNotFoundError)
+		}
+		if err := b.OpenObject(); err != nil {
+			return WithStack(err)
+		}
+		it, err := NewObjectIterator(s, true)
+		if err != nil {
+			return WithStack(err)
+		}
+		for it.IsValid() {
+			keySlice, err := it.Key(true)
+			if err != nil {
+				return WithStack(err)
+			}
+			key, err := keySlice.GetString()
+			if err != nil {
+				return WithStack(err)
+			}
+			v, err := it.Value()
+			if err != nil {
+				return WithStack(err)
+			}
+			if key != token {
+				if err := b.addInternalKeyValue(key, NewSliceValue(v)); err != nil {
+					return WithStack(err)
+				}
+			} else if len(rest) > 0 {
+				if err := b.AddValue(NewStringValue(key)); err != nil {
+					return WithStack(err)
+				}
+				if err := b.addPatched(v, rest, value, mode); err != nil {
+					return WithStack(err)
+				}
+			} else if mode != patchModeRemove {
+				// Replace existing value
+				if err := b.addInternalKeyValue(key, NewSliceValue(value)); err != nil {
+					return WithStack(err)
+				}
+			}
+			if err := it.Next(); err != nil {
+				return WithStack(err)
+			}
+		}
+		if !exists {
+			// Add new attribute
+			if err := b.addInternalKeyValue(token, NewSliceValue(value)); err != nil {
+				return WithStack(err)
+			}
+		}
+		if err := b.Close(); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	case s.IsArray():
+		length, err := s.Length()
+		if err != nil {
+			return WithStack(err)
+		}
+		index, err := jsonPointerArrayIndex(token, length, len(rest) == 0 && mode == patchModeAdd)
+		if err != nil {
+			return WithStack(err)
+		}
+		if err := b.OpenArray(); err != nil {
+			return WithStack(err)
+		}
+		it, err := NewArrayIterator(s)
+		if err != nil {
+			return WithStack(err)
+		}
+		for i := ValueLength(0); it.IsValid(); i++ {
+			v, err := it.Value()
+			if err != nil {
+				return WithStack(err)
+			}
+			if i != index {
+				if err := b.addInternal(NewSliceValue(v)); err != nil {
+					return WithStack(err)
+				}
+			} else if len(rest) > 0 {
+				if err := b.addPatched(v, rest, value, mode); err != nil {
+					return WithStack(err)
+				}
+			} else if mode != patchModeRemove {
+				if err := b.addInternal(NewSliceValue(value)); err != nil {
+					return WithStack(err)
+				}
+				if mode == patchModeAdd {
+					// Insert before existing element
+					if err := b.addInternal(NewSliceValue(v)); err != nil {
+						return WithStack(err)
+					}
+				}
+			}
+			if err := it.Next(); err != nil {
+				return WithStack(err)
+			}
+		}
+		if index == length {
+			// Append to the end of the array
+			if err := b.addInternal(NewSliceValue(value)); err != nil {
+				return WithStack(err)
+			}
+		}
+		if err := b.Close(); err != nil {
+			return WithStack(err)
+		}
+		return nil
+	default:
+		return WithStack(PatchPathNotFoundError)
+	}
+}
@@ -0,0 +1,232 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+// projectionNode is a node in a tree of attribute paths used by Project & Omit.
+type projectionNode struct {
+	// leaf is set when the entire value at this node is selected.
+	leaf     bool
+	names    []string
+	children map[string]*projectionNode
+}
+
+// newProjectionTree creates a tree from the given attribute paths.
+// Paths use the syntax of ParsePath, but can only contain attribute names.
+func newProjectionTree(paths []string) (*projectionNode, error) {
+	root := &projectionNode{}
+	for _, path := range paths {
+		p, err := ParsePath(path)
+		if err != nil {
+			return nil, WithStack(err)
+		}
+		if len(p.segments) == 0 {
+			return nil, WithStack(PathSyntaxError{Message: "expected attribute name"})
+		}
+		node := root
+		for _, seg := range p.segments {
+			if seg.kind != pathSegmentAttribute {
+				return nil, WithStack(PathSyntaxError{Message: "expected attribute name, got array index or wildcard"})
+			}
+			if node.leaf {
+				// A parent is already selected entirely
+				break
+			}
+			node = node.child(seg.attribute)
+		}
+		node.leaf = true
+		node.names, node.children = nil, nil
+	}
+	return root, nil
+}
+
+// child returns the child node with given name, creating it if needed.
+func (n *projectionNode) child(name string) *projectionNode {
+	if c, found := n.children[name]; found {
+		return c
+	}
+	if n.children == nil {
+		n.children = make(map[string]*projectionNode)
+	}
+	c := &projectionNode{}
+	n.children[name] = c
+	n.names = append(n.names, name)
+	return c
+}
+
+// Project creates an object that contains only the given attribute paths of the given object.
+// Paths use the syntax of ParsePath (e.g. `address.city` or `["a.b"]`), but can only contain attribute names.
+// Attributes are added in the order of the given paths. Paths that do not exist in the given object are ignored.
+func Project(s Slice, paths ...string) (Slice, error) {
+	if err := s.AssertType(Object); err != nil {
+		return nil, WithStack(err)
+	}
+	tree, err := newProjectionTree(paths)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	b := NewBuilder(0)
+	if err := b.addProjected(s, tree); err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := b.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// addProjected adds an object with all attributes of s selected by the given node to the builder.
+func (b *Builder) addProjected(s Slice, node *projectionNode) error {
+	if err := b.OpenObject(); err != nil {
+		return WithStack(err)
+	}
+	for _, name := range node.names {
+		child := node.children[name]
+		value, err := s.Get(name)
+		if err != nil {
+			return WithStack(err)
+		}
+		if child.leaf {
+			if !value.IsNone() {
+				if err := b.AddKeyValue(name, NewSliceValue(value)); err != nil {
+					return WithStack(err)
+				}
+			}
+			continue
+		}
+		if found, err := hasProjected(value, child); err != nil {
+			return WithStack(err)
+		} else if found {
+			if err := b.AddValue(NewStringValue(name)); err != nil {
+				return WithStack(err)
+			}
+			if err := b.addProjected(value, child); err != nil {
+				return WithStack(err)
+			}
+		}
+	}
+	if err := b.Close(); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// hasProjected returns true if s is an object that contains at least one attribute selected by the given node.
+func hasProjected(s Slice, node *projectionNode) (bool, error) {
+	if !s.IsObject() {
+		return false, nil
+	}
+	for _, name := range node.names {
+		child := node.children[name]
+		value, err := s.Get(name)
+		if err != nil {
+			return false, WithStack(err)
+		}
+		if value.IsNone() {
+			continue
+		}
+		if child.leaf {
+			return true, nil
+		}
+		if found, err := hasProjected(value, child); err != nil {
+			return false, WithStack(err)
+		} else if found {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Omit creates a copy of the given object without the given attribute paths.
+// Paths use the syntax of ParsePath (e.g. `address.city` or `["a.b"]`), but can only contain attribute names.
+// Paths that do not exist in the given object are ignored.
+func Omit(s Slice, paths ...string) (Slice, error) {
+	if err := s.AssertType(Object); err != nil {
+		return nil, WithStack(err)
+	}
+	tree, err := newProjectionTree(paths)
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	b := NewBuilder(0)
+	if err := b.addOmitted(s, tree); err != nil {
+		return nil, WithStack(err)
+	}
+	result, err := b.Slice()
+	if err != nil {
+		return nil, WithStack(err)
+	}
+	return result, nil
+}
+
+// addOmitted adds a copy of object s without the attributes selected by the given node to the builder.
+func (b *Builder) addOmitted(s Slice, node *projectionNode) error {
+	if err := b.OpenObject(); err != nil {
+		return WithStack(err)
+	}
+	it, err := NewObjectIterator(s, true)
+	if err != nil {
+		return WithStack(err)
+	}
+	for it.IsValid() {
+		keySlice, err := it.Key(true)
+		if err != nil {
+			return WithStack(err)
+		}
+		key, err := keySlice.GetString()
+		if err != nil {
+			return WithStack(err)
+		}
+		value, err := it.Value()
+		if err != nil {
+			return WithStack(err)
+		}
+		child, found := node.children[key]
+		switch {
+		case !found:
+			if err := b.AddKeyValue(key, NewSliceValue(value)); err != nil {
+				return WithStack(err)
+			}
+		case child.leaf:
+			// Omit attribute
+		case value.IsObject():
+			if err := b.AddValue(NewStringValue(key)); err != nil {
+				return WithStack(err)
+			}
+			if err := b.addOmitted(value, child); err != nil {
+				return WithStack(err)
+			}
+		default:
+			if err := b.AddKeyValue(key, NewSliceValue(value)); err != nil {
+				return WithStack(err)
+			}
+		}
+		if err := it.Next(); err != nil {
+			return WithStack(err)
+		}
+	}
+	if err := b.Close(); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
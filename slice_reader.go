@@ -37,6 +37,11 @@ func SliceFromReader(r io.Reader) (Slice, error) {
 		// Buffered reader can use faster path.
 		return sliceFromBufReader(r)
 	}
+	return sliceFromReader(r)
+}
+
+// sliceFromReader reads a slice from the given reader, without using a faster buffered path.
+func sliceFromReader(r io.Reader) (Slice, error) {
 	hdr := make(Slice, 1, maxByteSizeBytes)
 	// Read first byte
 	if err := readBytes(hdr, r); err != nil {
@@ -108,25 +113,33 @@ func SliceFromReader(r io.Reader) (Slice, error) {
 		return readRemaining(append(hdr, bytes...), l)
 
 	case BCD:
-		if h <= 0xcf {
-			// positive BCD
-			vpackAssert(h >= 0xc8 && h < 0xcf)
-			x, bytes, err := readIntegerNonEmptyFromReader(r, uint(h)-0xc7)
-			if err != nil {
-				return nil, WithStack(err)
-			}
-			l := ValueLength(1 + ValueLength(h) - 0xc7 + ValueLength(x))
-			return readRemaining(append(hdr, bytes...), l)
+		lengthSize := bcdLengthSize(h)
+		x, bytes, err := readIntegerNonEmptyFromReader(r, lengthSize)
+		if err != nil {
+			return nil, WithStack(err)
 		}
+		// head, mantissa length, 4 byte exponent, mantissa
+		l := ValueLength(1 + ValueLength(lengthSize) + 4 + ValueLength(x))
+		return readRemaining(append(hdr, bytes...), l)
 
-		// negative BCD
-		vpackAssert(h >= 0xd0 && h < 0xd7)
-		x, bytes, err := readIntegerNonEmptyFromReader(r, uint(h)-0xcf)
+	case Tagged:
+		tagSize := uint(1)
+		if h == 0xef {
+			tagSize = 8
+		}
+		_, bytes, err := readIntegerFixedFromReader(r, tagSize)
 		if err != nil {
 			return nil, WithStack(err)
 		}
-		l := ValueLength(1 + ValueLength(h) - 0xcf + ValueLength(x))
-		return readRemaining(append(hdr, bytes...), l)
+		// Read the tagged value
+		value, err := sliceFromReader(r)
+		if err != nil {
+			return nil, WithStack(err)
+		}
+		if value == nil {
+			return nil, WithStack(io.ErrUnexpectedEOF)
+		}
+		return append(append(hdr, bytes...), value...), nil
 
 	case Custom:
 		vpackAssert(h >= 0xf4)
@@ -177,6 +190,10 @@ func sliceFromBufReader(r *bufio.Reader) (Slice, error) {
 		return nil, WithStack(err)
 	}
 	s := Slice(hdr)
+	if s.IsTagged() {
+		// Tags can be nested, so the size is not always found within the first 16 bytes.
+		return sliceFromReader(r)
+	}
 	size, err := s.ByteSize()
 	if err != nil {
 		return nil, WithStack(err)
@@ -122,6 +122,9 @@ func (s Slice) IsBCD() bool { return s.IsType(BCD) }
 // IsCustom returns true if slice is a Custom type
 func (s Slice) IsCustom() bool { return s.IsType(Custom) }
 
+// IsTagged returns true if slice is a value with one or more tags
+func (s Slice) IsTagged() bool { return s.IsType(Tagged) }
+
 // IsInteger returns true if a slice is any decimal number type
 func (s Slice) IsInteger() bool { return s.IsInt() || s.IsUInt() || s.IsSmallInt() }
 
@@ -0,0 +1,96 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// tagRegistry holds the mapping between tags and Go types.
+var tagRegistry struct {
+	sync.RWMutex
+	types map[uint64]reflect.Type
+	tags  map[reflect.Type]uint64
+}
+
+// RegisterTaggedType registers the type of the given value for the given tag.
+// Marshal encodes values of that type as a value tagged with the given tag.
+// Unmarshal decodes values tagged with the given tag into a value of that type
+// when decoding into an interface{}.
+// The tagged value itself is encoded & decoded as usual for the registered type.
+// Tag 0 means "no tag" and cannot be registered.
+// A tag and a type can only be registered once.
+func RegisterTaggedType(tag uint64, v interface{}) error {
+	if tag == 0 {
+		return WithStack(TagRegistrationError{"Tag 0 cannot be registered"})
+	}
+	if v == nil {
+		return WithStack(TagRegistrationError{"Cannot register nil"})
+	}
+	t := reflect.TypeOf(v)
+	tagRegistry.Lock()
+	defer tagRegistry.Unlock()
+	if existing, found := tagRegistry.types[tag]; found {
+		return WithStack(TagRegistrationError{fmt.Sprintf("Tag %d is already registered for type %s", tag, existing)})
+	}
+	if existing, found := tagRegistry.tags[t]; found {
+		return WithStack(TagRegistrationError{fmt.Sprintf("Type %s is already registered for tag %d", t, existing)})
+	}
+	if tagRegistry.types == nil {
+		tagRegistry.types = make(map[uint64]reflect.Type)
+		tagRegistry.tags = make(map[reflect.Type]uint64)
+	}
+	tagRegistry.types[tag] = t
+	tagRegistry.tags[t] = tag
+	clearEncoderCache()
+	return nil
+}
+
+// UnregisterTaggedType removes the registration of the given tag.
+func UnregisterTaggedType(tag uint64) {
+	tagRegistry.Lock()
+	defer tagRegistry.Unlock()
+	if t, found := tagRegistry.types[tag]; found {
+		delete(tagRegistry.types, tag)
+		delete(tagRegistry.tags, t)
+		clearEncoderCache()
+	}
+}
+
+// registeredTagForType returns the tag registered for the given type.
+func registeredTagForType(t reflect.Type) (uint64, bool) {
+	tagRegistry.RLock()
+	defer tagRegistry.RUnlock()
+	tag, found := tagRegistry.tags[t]
+	return tag, found
+}
+
+// registeredTypeForTag returns the type registered for the given tag.
+func registeredTypeForTag(tag uint64) (reflect.Type, bool) {
+	tagRegistry.RLock()
+	defer tagRegistry.RUnlock()
+	t, found := tagRegistry.types[tag]
+	return t, found
+}
@@ -23,6 +23,7 @@
 package test
 
 import (
+	"bytes"
 	"testing"
 
 	velocypack "github.com/arangodb/go-velocypack"
@@ -197,3 +198,93 @@ func TestAttributeTranslatorCompactObjectSmallInt(t *testing.T) {
 	to := mustSlice(slice.Get("_to"))
 	ASSERT_EQ(velocypack.Object, to.Type(), t)
 }
+
+var testAttributeTranslator = velocypack.NewAttributeTranslator(map[uint64]string{
+	1:  "name",
+	2:  "age",
+	20: "address",
+})
+
+func TestAttributeTranslatorBuilder(t *testing.T) {
+	b := velocypack.Builder{}
+	b.AttributeTranslator = testAttributeTranslator
+	must(b.OpenObject())
+	must(b.AddKeyValue("name", velocypack.NewStringValue("foo")))
+	must(b.AddKeyValue("other", velocypack.NewIntValue(1)))
+	must(b.AddValue(velocypack.NewStringValue("address")))
+	must(b.AddValue(velocypack.NewStringValue("age"))) // value, must not be translated
+	ASSERT_TRUE(mustBool(b.HasKey("address")), t)
+	ASSERT_FALSE(mustBool(b.HasKey("age")), t)
+	ASSERT_EQ("foo", mustString(mustSlice(b.GetKey("name")).GetString()), t)
+	must(b.Close())
+	s := mustSlice(b.Slice())
+
+	// Keys are sorted by their attribute names
+	ASSERT_EQ(uint64(20), mustUInt(mustSlice(s.KeyAt(0, false)).GetUInt()), t)
+	ASSERT_EQ(uint64(1), mustUInt(mustSlice(s.KeyAt(1, false)).GetUInt()), t)
+	ASSERT_EQ("other", mustString(mustSlice(s.KeyAt(2, false)).GetString()), t)
+
+	it := mustObjectIterator(velocypack.NewObjectIterator(s))
+	it.SetAttributeTranslator(testAttributeTranslator)
+	var keys []string
+	for ; it.IsValid(); must(it.Next()) {
+		keys = append(keys, mustString(mustSlice(it.Key(true)).GetString()))
+	}
+	ASSERT_EQ([]string{"address", "name", "other"}, keys, t)
+
+	ASSERT_EQ(`{"address":"age","name":"foo","other":1}`, mustString(s.JSONString(velocypack.DumperOptions{AttributeTranslator: testAttributeTranslator})), t)
+	// Default translator knows nothing about these keys
+	ASSERT_EQ(`{"20":"age","_key":"foo","other":1}`, mustString(s.JSONString()), t)
+
+	d := velocypack.NewDecoder(bytes.NewReader(s))
+	d.SetAttributeTranslator(testAttributeTranslator)
+	var m map[string]interface{}
+	must(d.Decode(&m))
+	ASSERT_EQ(map[string]interface{}{"address": "age", "name": "foo", "other": 1}, m, t)
+
+	d = velocypack.NewDecoder(bytes.NewReader(s))
+	d.SetAttributeTranslator(testAttributeTranslator)
+	var v struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	}
+	must(d.Decode(&v))
+	ASSERT_EQ("foo", v.Name, t)
+	ASSERT_EQ("age", v.Address, t)
+}
+
+func TestAttributeTranslatorBuilderUniqueness(t *testing.T) {
+	b := velocypack.Builder{}
+	b.AttributeTranslator = testAttributeTranslator
+	b.CheckAttributeUniqueness = true
+	must(b.OpenObject())
+	must(b.AddKeyValue("name", velocypack.NewIntValue(1)))
+	must(b.AddKeyValue("age", velocypack.NewIntValue(2)))
+	must(b.AddKeyValue("name", velocypack.NewIntValue(3)))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsDuplicateAttributeName, t)(b.Close())
+}
+
+func TestAttributeTranslatorParser(t *testing.T) {
+	b := velocypack.Builder{}
+	b.AttributeTranslator = testAttributeTranslator
+	p := velocypack.NewParser(bytes.NewReader([]byte(`{"name":"foo","x":{"age":1}}`)), &b)
+	must(p.Parse())
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(uint64(1), mustUInt(mustSlice(s.KeyAt(0, false)).GetUInt()), t)
+	ASSERT_EQ(uint64(2), mustUInt(mustSlice(mustSlice(s.Get("x")).KeyAt(0, false)).GetUInt()), t)
+	ASSERT_EQ(`{"name":"foo","x":{"age":1}}`, mustString(s.JSONString(velocypack.DumperOptions{AttributeTranslator: testAttributeTranslator})), t)
+}
+
+func TestAttributeTranslatorGlobal(t *testing.T) {
+	slice := velocypack.Slice{0x14, 0x05, 0x31, 0x1a, 0x01}
+	ASSERT_EQ(velocypack.ArangoAttributeTranslator, velocypack.GetAttributeTranslator(), t)
+
+	velocypack.SetAttributeTranslator(testAttributeTranslator)
+	defer velocypack.SetAttributeTranslator(velocypack.ArangoAttributeTranslator)
+	ASSERT_EQ(`{"name":true}`, mustString(slice.JSONString()), t)
+
+	velocypack.SetAttributeTranslator(nil)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsNeedAttributeTranslator, t)(slice.JSONString())
+	ASSERT_EQ(`{"name":true}`, mustString(slice.JSONString(velocypack.DumperOptions{AttributeTranslator: testAttributeTranslator})), t)
+}
@@ -0,0 +1,71 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func BenchmarkSliceHash64SmallObject(b *testing.B) {
+	s := mustSlice(velocypack.ParseJSONFromString(benchmarkParserSmallObject))
+	b.SetBytes(int64(len(s)))
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Hash64(velocypack.DefaultHashSeed); err != nil {
+			b.Errorf("Hash64 failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSliceHashFNV64SmallObject(b *testing.B) {
+	s := mustSlice(velocypack.ParseJSONFromString(benchmarkParserSmallObject))
+	b.SetBytes(int64(len(s)))
+	for i := 0; i < b.N; i++ {
+		if _, err := s.HashFNV64(velocypack.DefaultHashSeed); err != nil {
+			b.Errorf("HashFNV64 failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSliceHash64Documents(b *testing.B) {
+	s := mustSlice(velocypack.ParseJSONFromUTF8(benchmarkParserDocuments(1000)))
+	b.SetBytes(int64(len(s)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Hash64(velocypack.DefaultHashSeed); err != nil {
+			b.Errorf("Hash64 failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSliceHashFNV64Documents(b *testing.B) {
+	s := mustSlice(velocypack.ParseJSONFromUTF8(benchmarkParserDocuments(1000)))
+	b.SetBytes(int64(len(s)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.HashFNV64(velocypack.DefaultHashSeed); err != nil {
+			b.Errorf("HashFNV64 failed: %v", err)
+		}
+	}
+}
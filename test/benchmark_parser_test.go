@@ -0,0 +1,100 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+const benchmarkParserSmallObject = `{"Name":"John Doe","FirstName":"John","LastName":"Doe","Age":42,"Address":["Some street","Block  123","South"]}`
+
+// benchmarkParserDocuments creates a JSON array with the given number of documents.
+func benchmarkParserDocuments(count int) []byte {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(`{"_key":"` + strconv.Itoa(i) + `","name":"Some name with \"escapes\" and unicode é","age":` + strconv.Itoa(i%100) +
+			`,"score":` + strconv.FormatFloat(float64(i)*1.25, 'g', -1, 64) + `,"active":true,"tags":["a","b","c"],"parent":null}`)
+	}
+	sb.WriteString("]")
+	return []byte(sb.String())
+}
+
+func BenchmarkParserSmallObject(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkParserSmallObject)))
+	for i := 0; i < b.N; i++ {
+		if _, err := velocypack.ParseJSONFromString(benchmarkParserSmallObject); err != nil {
+			b.Errorf("ParseJSONFromString failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkParserDocumentsFromUTF8(b *testing.B) {
+	json := benchmarkParserDocuments(10000)
+	b.SetBytes(int64(len(json)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := velocypack.ParseJSONFromUTF8(json); err != nil {
+			b.Errorf("ParseJSONFromUTF8 failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkParserDocumentsFromReader(b *testing.B) {
+	json := benchmarkParserDocuments(10000)
+	b.SetBytes(int64(len(json)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := velocypack.ParseJSON(bytes.NewReader(json)); err != nil {
+			b.Errorf("ParseJSON failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkParserNumbers(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.Itoa(i*7919-1000000) + "," + strconv.FormatFloat(float64(i)/3, 'g', -1, 64))
+	}
+	sb.WriteString("]")
+	json := []byte(sb.String())
+	b.SetBytes(int64(len(json)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := velocypack.ParseJSONFromUTF8(json); err != nil {
+			b.Errorf("ParseJSONFromUTF8 failed: %v", err)
+		}
+	}
+}
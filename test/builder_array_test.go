@@ -26,6 +26,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"math"
+	"strings"
 	"testing"
 
 	velocypack "github.com/arangodb/go-velocypack"
@@ -452,3 +453,43 @@ func TestBuilderClear(t *testing.T) {
 	ASSERT_TRUE(b.IsClosed(), t)
 	ASSERT_EQ(0, len(mustBytes(b.Bytes())), t)
 }
+
+func TestBuilderArrayNoPadding(t *testing.T) {
+	build := func(noPadding bool) velocypack.Slice {
+		b := velocypack.Builder{}
+		b.NoPadding = noPadding
+		must(b.OpenArray())
+		for i := 0; i < 30; i++ {
+			must(b.AddValue(velocypack.NewStringValue(strings.Repeat("x", 10+i%5))))
+		}
+		must(b.Close())
+		return mustSlice(b.Slice())
+	}
+	padded := build(false)
+	unpadded := build(true)
+
+	ASSERT_EQ(byte(0x07), padded[0], t)
+	ASSERT_EQ(byte(0x07), unpadded[0], t)
+	ASSERT_EQ(byte(0x4a), padded[9], t)
+	ASSERT_EQ(byte(0x4a), unpadded[5], t)
+	ASSERT_EQ(len(padded)-4, len(unpadded), t)
+	ASSERT_EQ(mustLength(unpadded.ByteSize()), velocypack.ValueLength(len(unpadded)), t)
+	ASSERT_NIL(velocypack.Validate(unpadded, velocypack.ValidatorOptions{}), t)
+	for i := 0; i < 30; i++ {
+		ASSERT_EQ(strings.Repeat("x", 10+i%5), mustString(mustSlice(unpadded.At(velocypack.ValueLength(i))).GetString()), t)
+	}
+
+	// Array without index table
+	b := velocypack.Builder{}
+	b.NoPadding = true
+	must(b.OpenArray())
+	for i := 0; i < 30; i++ {
+		must(b.AddValue(velocypack.NewStringValue(strings.Repeat("x", 10))))
+	}
+	must(b.Close())
+	s := mustSlice(b.Slice())
+	ASSERT_EQ(byte(0x03), s[0], t)
+	ASSERT_EQ(byte(0x4a), s[3], t)
+	ASSERT_EQ(velocypack.ValueLength(30), mustLength(s.Length()), t)
+	ASSERT_EQ("xxxxxxxxxx", mustString(mustSlice(s.At(29)).GetString()), t)
+}
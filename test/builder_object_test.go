@@ -24,7 +24,9 @@ package test
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 
 	velocypack "github.com/arangodb/go-velocypack"
@@ -469,3 +471,22 @@ func TestBuilderAddKeysSeparately1(t *testing.T) {
 
 	ASSERT_EQ(`{"firstName":"Max","name":"Neunhoeffer"}`, mustString(mustSlice(b.Slice()).JSONString()), t)
 }
+
+func TestBuilderObjectNoPadding(t *testing.T) {
+	b := velocypack.Builder{}
+	b.NoPadding = true
+	must(b.OpenObject())
+	for i := 0; i < 30; i++ {
+		must(b.AddKeyValue(fmt.Sprintf("key%02d", 29-i), velocypack.NewStringValue(strings.Repeat("x", 10))))
+	}
+	must(b.Close())
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(byte(0x0c), s[0], t)
+	ASSERT_EQ(byte(0x45), s[5], t)
+	ASSERT_EQ(mustLength(s.ByteSize()), velocypack.ValueLength(len(s)), t)
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+	ASSERT_EQ(velocypack.ValueLength(30), mustLength(s.Length()), t)
+	ASSERT_EQ("key00", mustString(mustSlice(s.KeyAt(0)).GetString()), t)
+	ASSERT_EQ("xxxxxxxxxx", mustString(mustSlice(s.Get("key17")).GetString()), t)
+}
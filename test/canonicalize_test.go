@@ -0,0 +1,136 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func TestCanonicalizeEqualValues(t *testing.T) {
+	d, err := velocypack.ParseDecimal("2.50e1")
+	ASSERT_NIL(err, t)
+	var b velocypack.Builder
+	b.AttributeTranslator = velocypack.ArangoAttributeTranslator // writes "_key" as integer key
+	must(b.OpenObject(true))
+	must(b.AddKeyValue("c", velocypack.NewArrayValue(true)))
+	must(b.AddValue(velocypack.NewIntValue(1)))
+	must(b.AddValue(velocypack.NewDoubleValue(-0.5)))
+	must(b.AddValue(velocypack.NewStringValue("x")))
+	must(b.Close())
+	must(b.AddKeyValue("b", velocypack.NewBCDValue(d)))
+	must(b.AddKeyValue("_key", velocypack.NewStringValue("abc")))
+	must(b.AddKeyValue("a", velocypack.NewObjectValue(true)))
+	must(b.AddKeyValue("y", velocypack.NewNullValue()))
+	must(b.AddKeyValue("x", velocypack.NewDoubleValue(math.Copysign(0, -1))))
+	must(b.Close())
+	must(b.Close())
+	compact := mustSlice(b.Slice())
+	ASSERT_TRUE(mustSlice(compact.KeyAt(2, false)).IsSmallInt(), t)
+
+	indexed := mustSlice(velocypack.ParseJSONFromString(`{"a":{"x":0,"y":null},"b":25,"_key":"abc","c":[1.0,-0.5,"x"]}`))
+
+	ASSERT_TRUE(mustBool(compact.Equals(indexed)), t)
+	x := mustSlice(velocypack.Canonicalize(compact))
+	y := mustSlice(velocypack.Canonicalize(indexed))
+	ASSERT_EQ(x, y, t)
+	ASSERT_TRUE(mustBool(x.Equals(indexed)), t)
+	ASSERT_NIL(velocypack.Validate(x, velocypack.ValidatorOptions{}), t)
+	ASSERT_EQ(`{"_key":"abc","a":{"x":0,"y":null},"b":25,"c":[1,-0.5,"x"]}`, mustString(x.JSONString()), t)
+
+	// Canonicalization is idempotent
+	ASSERT_EQ(x, mustSlice(velocypack.Canonicalize(x)), t)
+}
+
+func TestCanonicalizeNumbers(t *testing.T) {
+	tests := []struct {
+		Input    velocypack.Slice
+		Expected velocypack.Slice
+	}{
+		{velocypack.Slice{0x20, 0x05}, velocypack.Slice{0x35}},
+		{velocypack.Slice{0x21, 0x05, 0x00}, velocypack.Slice{0x35}},
+		{velocypack.Slice{0x20, 0x20}, velocypack.Slice{0x28, 0x20}},
+		{velocypack.Slice{0x29, 0xff, 0x00}, velocypack.Slice{0x28, 0xff}},
+		{velocypack.Slice{0x21, 0x00, 0xff}, velocypack.Slice{0x21, 0x00, 0xff}},
+		{velocypack.Slice{0x3f}, velocypack.Slice{0x3f}},
+		{mustSlice(velocypack.Marshal(3.0)), velocypack.Slice{0x33}},
+		{mustSlice(velocypack.Marshal(-300.0)), velocypack.Slice{0x21, 0xd4, 0xfe}},
+		{mustSlice(velocypack.Marshal(math.Copysign(0, -1))), velocypack.Slice{0x30}},
+		{mustSlice(velocypack.Marshal(1.5)), mustSlice(velocypack.Marshal(1.5))},
+	}
+	for _, test := range tests {
+		ASSERT_EQ(test.Expected, mustSlice(velocypack.Canonicalize(test.Input)), t)
+	}
+
+	// BCD
+	for _, input := range []string{"1.50", "15e-1", "0.15e1"} {
+		d, err := velocypack.ParseDecimal(input)
+		ASSERT_NIL(err, t)
+		var b velocypack.Builder
+		must(b.AddValue(velocypack.NewBCDValue(d)))
+		s := mustSlice(b.Slice())
+		ASSERT_EQ(mustSlice(velocypack.Marshal(1.5)), mustSlice(velocypack.Canonicalize(s)), t)
+
+		c := mustSlice(velocypack.Canonicalize(s, velocypack.CanonicalizeOptions{KeepNumberTypes: true}))
+		ASSERT_EQ(velocypack.BCD, c.Type(), t)
+		d.Exponent = -1
+		d.Mantissa.SetInt64(15)
+		b.Clear()
+		must(b.AddValue(velocypack.NewBCDValue(d)))
+		ASSERT_EQ(mustSlice(b.Slice()), c, t)
+	}
+
+	// Keep number types
+	s := mustSlice(velocypack.Marshal(3.0))
+	ASSERT_EQ(s, mustSlice(velocypack.Canonicalize(s, velocypack.CanonicalizeOptions{KeepNumberTypes: true})), t)
+}
+
+func TestCanonicalizeArrays(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`[1,"a",[2,3]]`, velocypack.ParserOptions{BuildUnindexedArrays: true}))
+	ASSERT_EQ(byte(0x13), s[0], t)
+	ASSERT_EQ(byte(0x06), mustSlice(velocypack.Canonicalize(s))[0], t)
+	ASSERT_EQ(byte(0x13), mustSlice(velocypack.Canonicalize(s, velocypack.CanonicalizeOptions{UnindexedArrays: true}))[0], t)
+}
+
+func TestCanonicalizeNoPadding(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"a":"` + strings.Repeat("x", 300) + `","b":1}`))
+	ASSERT_EQ(byte(0x0c), s[0], t)
+	ASSERT_EQ(byte(0), s[5], t) // padding
+
+	c := mustSlice(velocypack.Canonicalize(s))
+	ASSERT_EQ(byte(0x0c), c[0], t)
+	ASSERT_EQ(byte(0x41), c[5], t)
+	ASSERT_EQ(len(s)-4, len(c), t)
+	ASSERT_TRUE(mustBool(c.Equals(s)), t)
+}
+
+func TestCanonicalizeTags(t *testing.T) {
+	var b velocypack.Builder
+	must(b.AddTagged(5, velocypack.NewTaggedValue(1000, velocypack.NewDoubleValue(2))))
+	c := mustSlice(velocypack.Canonicalize(mustSlice(b.Slice())))
+	ASSERT_EQ([]uint64{5, 1000}, c.GetTags(), t)
+	ASSERT_EQ(velocypack.Slice{0x32}, c.UnwrapTags(), t)
+}
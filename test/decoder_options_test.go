@@ -0,0 +1,136 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+type DecoderOptionsStruct struct {
+	Name string
+	Age  int
+}
+
+func TestDecoderOptionsDefault(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"name":"Max","age":"old","extra":1}`))
+
+	var v DecoderOptionsStruct
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(velocypack.UnmarshalWithOptions(s, &v, velocypack.DecoderOptions{}))
+	// Default options match case-insensitive and skip the mismatching field.
+	ASSERT_EQ(v, DecoderOptionsStruct{Name: "Max"}, t)
+}
+
+func TestDecoderOptionsDisallowUnknownFields(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"Name":"Max","Extra":1}`))
+
+	var v DecoderOptionsStruct
+	err := velocypack.UnmarshalWithOptions(s, &v, velocypack.DecoderOptions{DisallowUnknownFields: true})
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnknownField, t)(err)
+	ASSERT_EQ(err.Error(), `json: unknown field "Extra" in Go struct DecoderOptionsStruct`, t)
+
+	// Maps accept any key.
+	var m map[string]interface{}
+	ASSERT_NIL(velocypack.UnmarshalWithOptions(s, &m, velocypack.DecoderOptions{DisallowUnknownFields: true}), t)
+	ASSERT_EQ(len(m), 2, t)
+}
+
+func TestDecoderOptionsCaseSensitive(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"name":"Max","Age":7}`))
+
+	var v DecoderOptionsStruct
+	ASSERT_NIL(velocypack.UnmarshalWithOptions(s, &v, velocypack.DecoderOptions{CaseSensitive: true}), t)
+	ASSERT_EQ(v, DecoderOptionsStruct{Age: 7}, t)
+
+	v = DecoderOptionsStruct{}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnknownField, t)(velocypack.UnmarshalWithOptions(s, &v, velocypack.DecoderOptions{CaseSensitive: true, DisallowUnknownFields: true}))
+}
+
+func TestDecoderOptionsUseNumber(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"a":12,"b":-3,"c":1.5}`))
+
+	var m map[string]interface{}
+	ASSERT_NIL(velocypack.UnmarshalWithOptions(s, &m, velocypack.DecoderOptions{UseNumber: true}), t)
+	ASSERT_EQ(m, map[string]interface{}{"a": json.Number("12"), "b": json.Number("-3"), "c": json.Number("1.5")}, t)
+
+	var v interface{}
+	ASSERT_NIL(velocypack.UnmarshalWithOptions(velocypack.Slice{0x35}, &v, velocypack.DecoderOptions{UseNumber: true}), t)
+	ASSERT_EQ(v, json.Number("5"), t)
+}
+
+func TestDecoderOptionsStrict(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"Age":"old","Name":"Max"}`))
+
+	var v DecoderOptionsStruct
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(velocypack.UnmarshalWithOptions(s, &v, velocypack.DecoderOptions{Strict: true}))
+	// Decoding stops at the first mismatch.
+	ASSERT_EQ(v, DecoderOptionsStruct{}, t)
+}
+
+func TestDecoderOptionsStrictIgnoredValues(t *testing.T) {
+	strict := velocypack.DecoderOptions{Strict: true}
+
+	// Array elements that do not fit in a Go array
+	var a [2]int
+	ASSERT_NIL(velocypack.Unmarshal(mustSlice(velocypack.ParseJSONFromString(`[1,2,3]`)), &a), t)
+	ASSERT_EQ(a, [2]int{1, 2}, t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(velocypack.UnmarshalWithOptions(mustSlice(velocypack.ParseJSONFromString(`[1,2,3]`)), &a, strict))
+
+	// Custom values without a custom type handler
+	var str string
+	ASSERT_NIL(velocypack.Unmarshal(velocypack.Slice{0xf0, 0x2a}, &str), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(velocypack.UnmarshalWithOptions(velocypack.Slice{0xf0, 0x2a}, &str, strict))
+
+	// Values without a Go equivalent
+	b := velocypack.Builder{}
+	must(b.AddValue(velocypack.NewUTCDateValue(time.Unix(1, 0))))
+	date := mustSlice(b.Slice())
+	ASSERT_NIL(velocypack.Unmarshal(date, &str), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(velocypack.UnmarshalWithOptions(date, &str, strict))
+
+	// Null keeps having no effect
+	ASSERT_NIL(velocypack.UnmarshalWithOptions(velocypack.NullSlice(), &str, strict), t)
+}
+
+func TestDecoderOptionsDecoder(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"name":"Max","Age":7.5}`))
+
+	d := velocypack.NewDecoder(bytes.NewReader(s))
+	d.UseCaseSensitiveKeys()
+	d.DisallowUnknownFields()
+	var v DecoderOptionsStruct
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnknownField, t)(d.Decode(&v))
+
+	d = velocypack.NewDecoder(bytes.NewReader(append(s, s...)))
+	d.UseNumber()
+	d.UseStrictTypes()
+	var m map[string]interface{}
+	must(d.Decode(&m))
+	ASSERT_EQ(m["Age"], json.Number("7.5"), t)
+	v = DecoderOptionsStruct{}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(d.Decode(&v))
+}
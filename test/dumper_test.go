@@ -24,7 +24,10 @@ package test
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	velocypack "github.com/arangodb/go-velocypack"
 )
@@ -306,3 +309,146 @@ func TestDumperBinary(t *testing.T) {
 		ASSERT_EQ(test.Expected, buf.String(), t)
 	}
 }
+
+func TestDumperIndent(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"a":[1,2,{}],"b":{"c":"x","d":[]},"e":null}`))
+
+	buf := &bytes.Buffer{}
+	d := velocypack.NewDumper(buf, &velocypack.DumperOptions{Indent: "  "})
+	must(d.Append(s))
+	ASSERT_EQ("{\n  \"a\": [\n    1,\n    2,\n    {}\n  ],\n  \"b\": {\n    \"c\": \"x\",\n    \"d\": []\n  },\n  \"e\": null\n}", buf.String(), t)
+
+	// Must be equal to encoding/json output
+	var v interface{}
+	must(json.Unmarshal([]byte(mustString(s.JSONString())), &v))
+	expected, err := json.MarshalIndent(v, "> ", "\t")
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(string(expected), mustString(s.JSONString(velocypack.DumperOptions{Prefix: "> ", Indent: "\t"})), t)
+}
+
+func TestDumperNewline(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`[1,[2]]`))
+	ASSERT_EQ("[\r\n 1,\r\n [\r\n  2\r\n ]\r\n]", mustString(s.JSONString(velocypack.DumperOptions{Indent: " ", Newline: "\r\n"})), t)
+	// Compact output is not affected
+	ASSERT_EQ("[1,[2]]", mustString(s.JSONString(velocypack.DumperOptions{Newline: "\r\n"})), t)
+}
+
+func TestDumperKeyOrder(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenObject())
+	must(b.AddKeyValue("b", velocypack.NewIntValue(1)))
+	must(b.AddKeyValue("c", velocypack.NewIntValue(2)))
+	must(b.AddKeyValue("a", velocypack.NewIntValue(3)))
+	must(b.Close())
+	indexed := mustSlice(b.Slice())
+
+	b.Clear()
+	must(b.OpenObject(true))
+	must(b.AddKeyValue("b", velocypack.NewIntValue(1)))
+	must(b.AddKeyValue("c", velocypack.NewIntValue(2)))
+	must(b.AddKeyValue("a", velocypack.NewIntValue(3)))
+	must(b.Close())
+	compact := mustSlice(b.Slice())
+	ASSERT_EQ(byte(0x14), compact[0], t)
+
+	tests := []struct {
+		Slice    velocypack.Slice
+		Order    velocypack.ObjectKeyOrder
+		Expected string
+	}{
+		{indexed, velocypack.IndexTableKeyOrder, `{"a":3,"b":1,"c":2}`},
+		{indexed, velocypack.SortedKeyOrder, `{"a":3,"b":1,"c":2}`},
+		{indexed, velocypack.InsertionKeyOrder, `{"b":1,"c":2,"a":3}`},
+		{compact, velocypack.IndexTableKeyOrder, `{"b":1,"c":2,"a":3}`},
+		{compact, velocypack.SortedKeyOrder, `{"a":3,"b":1,"c":2}`},
+		{compact, velocypack.InsertionKeyOrder, `{"b":1,"c":2,"a":3}`},
+	}
+	for _, test := range tests {
+		ASSERT_EQ(test.Expected, mustString(test.Slice.JSONString(velocypack.DumperOptions{KeyOrder: test.Order})), t)
+	}
+
+	// Nested & pretty printed
+	s := mustSlice(velocypack.ParseJSONFromString(`{"z":{"y":1,"x":[{"b":1,"a":2}]},"a":{}}`, velocypack.ParserOptions{BuildUnindexedObjects: true}))
+	ASSERT_EQ("{\n \"a\": {},\n \"z\": {\n  \"x\": [\n   {\n    \"a\": 2,\n    \"b\": 1\n   }\n  ],\n  \"y\": 1\n }\n}",
+		mustString(s.JSONString(velocypack.DumperOptions{KeyOrder: velocypack.SortedKeyOrder, Indent: " "})), t)
+}
+
+func TestDumperBinaryBehavior(t *testing.T) {
+	b := velocypack.Builder{}
+	must(b.AddValue(velocypack.NewBinaryValue([]byte{0xde, 0xad, 0xbe, 0xef})))
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(`"3q2+7w=="`, mustString(s.JSONString(velocypack.DumperOptions{BinaryBehavior: velocypack.BinaryAsBase64})), t)
+	ASSERT_EQ(`"deadbeef"`, mustString(s.JSONString(velocypack.DumperOptions{BinaryBehavior: velocypack.BinaryAsHex})), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsNoJSONEquivalent, t)(s.JSONString(velocypack.DumperOptions{UnsupportedTypeBehavior: velocypack.FailOnUnsupportedType}))
+}
+
+func TestDumperUTCDate(t *testing.T) {
+	b := velocypack.Builder{}
+	must(b.AddValue(velocypack.NewUTCDateValue(time.Date(2017, 10, 25, 14, 30, 5, 123000000, time.UTC))))
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(`"2017-10-25T14:30:05.123Z"`, mustString(s.JSONString(velocypack.DumperOptions{UTCDateBehavior: velocypack.UTCDateAsISO8601})), t)
+	ASSERT_EQ(`1508941805123`, mustString(s.JSONString(velocypack.DumperOptions{UTCDateBehavior: velocypack.UTCDateAsEpochMillis})), t)
+	ASSERT_EQ(`null`, mustString(s.JSONString()), t)
+
+	b.Clear()
+	must(b.AddValue(velocypack.NewUTCDateValue(time.Unix(-1, 0))))
+	s = mustSlice(b.Slice())
+	ASSERT_EQ(`"1969-12-31T23:59:59.000Z"`, mustString(s.JSONString(velocypack.DumperOptions{UTCDateBehavior: velocypack.UTCDateAsISO8601})), t)
+	ASSERT_EQ(`-1000`, mustString(s.JSONString(velocypack.DumperOptions{UTCDateBehavior: velocypack.UTCDateAsEpochMillis})), t)
+}
+
+func TestDumperMinMaxKey(t *testing.T) {
+	b := velocypack.Builder{}
+	must(b.OpenArray())
+	must(b.AddValue(velocypack.NewMinKeyValue()))
+	must(b.AddValue(velocypack.NewMaxKeyValue()))
+	must(b.Close())
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(`["$minKey","$maxKey"]`, mustString(s.JSONString(velocypack.DumperOptions{MinKeyString: "$minKey", MaxKeyString: "$maxKey"})), t)
+	ASSERT_EQ(`[null,null]`, mustString(s.JSONString()), t)
+}
+
+func TestDumperBCD(t *testing.T) {
+	b := velocypack.Builder{}
+	d, err := velocypack.ParseDecimal("123456789012345678901234567890.5")
+	ASSERT_NIL(err, t)
+	must(b.AddValue(velocypack.NewBCDValue(d)))
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(`123456789012345678901234567890.5`, mustString(s.JSONString()), t)
+	ASSERT_EQ(`"123456789012345678901234567890.5"`, mustString(s.JSONString(velocypack.DumperOptions{BCDAsString: true})), t)
+}
+
+// hexCustomTypeHandler dumps custom values as a hex string of their payload,
+// prefixed with the length of the array or object that contains it.
+type hexCustomTypeHandler struct{}
+
+func (hexCustomTypeHandler) Dump(value velocypack.Slice, dumper *velocypack.Dumper, base velocypack.Slice) error {
+	prefix := "-"
+	if base != nil {
+		prefix = fmt.Sprintf("%d", mustLength(base.Length()))
+	}
+	size, err := value.ByteSize()
+	if err != nil {
+		return err
+	}
+	s, err := velocypack.Marshal(fmt.Sprintf("%s:%x", prefix, []byte(value[1:size])))
+	if err != nil {
+		return err
+	}
+	return dumper.Append(s)
+}
+
+func TestDumperCustom(t *testing.T) {
+	custom := velocypack.Slice{0xf0, 0x2a}
+	opts := velocypack.DumperOptions{CustomTypeHandler: hexCustomTypeHandler{}}
+	ASSERT_EQ(`"-:2a"`, mustString(custom.JSONString(opts)), t)
+	ASSERT_EQ(`null`, mustString(custom.JSONString()), t)
+
+	// {"a":custom,"b":[custom,1,2]} as compact object
+	s := velocypack.Slice{0x14, 0x10, 0x41, 'a', 0xf0, 0x2a, 0x41, 'b', 0x13, 0x07, 0xf0, 0x2a, 0x31, 0x32, 0x03, 0x02}
+	ASSERT_EQ(`{"a":"2:2a","b":["3:2a",1,2]}`, mustString(s.JSONString(opts)), t)
+}
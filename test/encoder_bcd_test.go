@@ -0,0 +1,148 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func TestEncoderBCDBigFloat(t *testing.T) {
+	f, _ := new(big.Float).SetString("1.5")
+	s := mustSlice(velocypack.Marshal(f))
+	ASSERT_TRUE(s.IsBCD(), t)
+	ASSERT_EQ("1.5", mustString(s.JSONString()), t)
+
+	var v *big.Float
+	must(velocypack.Unmarshal(s, &v))
+	ASSERT_EQ(0, v.Cmp(f), t)
+}
+
+func TestEncoderBCDBigRat(t *testing.T) {
+	r := big.NewRat(-1234567, 1000)
+	s := mustSlice(velocypack.Marshal(r))
+	ASSERT_TRUE(s.IsBCD(), t)
+	ASSERT_EQ("-1234.567", mustString(s.JSONString()), t)
+
+	var v big.Rat
+	must(velocypack.Unmarshal(s, &v))
+	ASSERT_EQ(0, v.Cmp(r), t)
+
+	// 1/3 has no finite decimal representation
+	_, err := velocypack.Marshal(big.NewRat(1, 3))
+	ASSERT_FALSE(err == nil, t)
+}
+
+func TestEncoderBCDStruct(t *testing.T) {
+	type Amount struct {
+		Value    big.Rat
+		Price    *big.Float
+		Number   json.Number
+		Decimal  velocypack.Decimal
+		Nothing  *big.Float
+		Quantity json.Number
+	}
+	price, _ := new(big.Float).SetString("99.75")
+	input := Amount{
+		Price:    price,
+		Number:   "0.000000000000000000000000001",
+		Decimal:  velocypack.NewDecimalFromInt64(1999, -2),
+		Quantity: "42",
+	}
+	input.Value.SetFrac64(5, 4)
+	s := mustSlice(velocypack.MarshalWithOptions(input, velocypack.EncoderOptions{NumbersAsNumeric: true}))
+	ASSERT_EQ(`{"Decimal":19.99,"Nothing":null,"Number":1E-27,"Price":99.75,"Quantity":42,"Value":1.25}`, mustString(s.JSONString()), t)
+	ASSERT_TRUE(mustSlice(s.Get("Quantity")).IsSmallInt() || mustSlice(s.Get("Quantity")).IsInt(), t)
+
+	var output Amount
+	must(velocypack.Unmarshal(s, &output))
+	ASSERT_EQ(0, output.Value.Cmp(&input.Value), t)
+	ASSERT_EQ(0, output.Price.Cmp(input.Price), t)
+	ASSERT_EQ(json.Number("1E-27"), output.Number, t)
+	ASSERT_EQ("19.99", output.Decimal.String(), t)
+	ASSERT_TRUE(output.Nothing == nil, t)
+	ASSERT_EQ(json.Number("42"), output.Quantity, t)
+}
+
+func TestEncoderNumberAsString(t *testing.T) {
+	type Amount struct {
+		Number   json.Number
+		Quantity json.Number `json:",string"`
+	}
+	input := Amount{Number: "0.000000000000000000000000001", Quantity: "42"}
+	s := mustSlice(velocypack.Marshal(input))
+	ASSERT_EQ(`{"Number":"0.000000000000000000000000001","Quantity":"\"42\""}`, mustString(s.JSONString()), t)
+	ASSERT_TRUE(mustSlice(s.Get("Number")).IsString(), t)
+
+	var output Amount
+	must(velocypack.Unmarshal(s, &output))
+	ASSERT_EQ(input, output, t)
+}
+
+func TestDecoderBCDConvert(t *testing.T) {
+	var b velocypack.Builder
+	must(b.AddValue(velocypack.NewBCDValue(velocypack.NewDecimalFromInt64(12500, -2))))
+	s := mustSlice(b.Slice())
+
+	var i int
+	must(velocypack.Unmarshal(s, &i))
+	ASSERT_EQ(125, i, t)
+
+	var u uint8
+	must(velocypack.Unmarshal(s, &u))
+	ASSERT_EQ(uint8(125), u, t)
+
+	var f float64
+	must(velocypack.Unmarshal(s, &f))
+	ASSERT_DOUBLE_EQ(125.0, f, t)
+
+	var n json.Number
+	must(velocypack.Unmarshal(s, &n))
+	ASSERT_EQ(json.Number("125.00"), n, t)
+
+	var x interface{}
+	must(velocypack.Unmarshal(s, &x))
+	d, ok := x.(velocypack.Decimal)
+	ASSERT_TRUE(ok, t)
+	ASSERT_EQ("125.00", d.String(), t)
+
+	// Fractional value cannot be stored in an int
+	b.Clear()
+	must(b.AddValue(velocypack.NewBCDValue(velocypack.NewDecimalFromInt64(125, -1))))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(velocypack.Unmarshal(mustSlice(b.Slice()), &i))
+}
+
+func TestDecoderBigFloatFromDouble(t *testing.T) {
+	s := mustSlice(velocypack.Marshal(2.5))
+	var v big.Float
+	must(velocypack.Unmarshal(s, &v))
+	f, _ := v.Float64()
+	ASSERT_DOUBLE_EQ(2.5, f, t)
+
+	var r big.Rat
+	must(velocypack.Unmarshal(mustSlice(velocypack.Marshal(7)), &r))
+	ASSERT_EQ("7/1", r.String(), t)
+}
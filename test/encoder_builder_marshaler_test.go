@@ -0,0 +1,139 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"errors"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+type BuilderMarshalerPoint struct {
+	X, Y int
+}
+
+func (p BuilderMarshalerPoint) MarshalVPackTo(b *velocypack.Builder) error {
+	if err := b.OpenArray(); err != nil {
+		return err
+	}
+	if err := b.AddValue(velocypack.NewIntValue(int64(p.X))); err != nil {
+		return err
+	}
+	if err := b.AddValue(velocypack.NewIntValue(int64(p.Y))); err != nil {
+		return err
+	}
+	return b.Close()
+}
+
+// MarshalVPack must not be used, since MarshalVPackTo is preferred.
+func (p BuilderMarshalerPoint) MarshalVPack() (velocypack.Slice, error) {
+	return nil, errors.New("MarshalVPack called")
+}
+
+type BuilderMarshalerName struct {
+	Name string
+}
+
+func (n *BuilderMarshalerName) MarshalVPackTo(b *velocypack.Builder) error {
+	return b.AddValue(velocypack.NewStringValue("name:" + n.Name))
+}
+
+type BuilderMarshalerInvalid int
+
+func (i BuilderMarshalerInvalid) MarshalVPackTo(b *velocypack.Builder) error {
+	switch i {
+	case 1:
+		return errors.New("failed")
+	case 2:
+		// Leaves the array open
+		return b.OpenArray()
+	}
+	// Adds nothing
+	return nil
+}
+
+func TestEncoderBuilderMarshaler(t *testing.T) {
+	s := mustSlice(velocypack.Marshal(BuilderMarshalerPoint{1, 2}))
+	ASSERT_EQ(mustString(s.JSONString()), `[1,2]`, t)
+
+	s = mustSlice(velocypack.Marshal([]BuilderMarshalerPoint{{1, 2}, {3, 4}}))
+	ASSERT_EQ(mustString(s.JSONString()), `[[1,2],[3,4]]`, t)
+
+	s = mustSlice(velocypack.Marshal(map[string]interface{}{"p": BuilderMarshalerPoint{5, 6}, "q": &BuilderMarshalerName{"q"}}))
+	ASSERT_EQ(mustString(s.JSONString()), `{"p":[5,6],"q":"name:q"}`, t)
+}
+
+func TestEncoderBuilderMarshalerAddr(t *testing.T) {
+	v := struct {
+		A BuilderMarshalerName
+		B *BuilderMarshalerName
+		C *BuilderMarshalerName
+	}{
+		A: BuilderMarshalerName{"a"},
+		B: &BuilderMarshalerName{"b"},
+	}
+	s := mustSlice(velocypack.Marshal(&v))
+	ASSERT_EQ(mustString(s.JSONString()), `{"A":"name:a","B":"name:b","C":null}`, t)
+
+	// Not addressable, so encoded as a regular struct
+	s = mustSlice(velocypack.Marshal(BuilderMarshalerName{"x"}))
+	ASSERT_EQ(mustString(s.JSONString()), `{"Name":"x"}`, t)
+}
+
+func TestEncoderBuilderMarshalerInvalid(t *testing.T) {
+	for _, v := range []BuilderMarshalerInvalid{0, 1, 2} {
+		_, err := velocypack.Marshal([]interface{}{v})
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsMarshaler, t)(err)
+	}
+}
+
+type SliceUnmarshalerRaw struct {
+	Data velocypack.Slice
+}
+
+func (r *SliceUnmarshalerRaw) UnmarshalVPackSlice(data velocypack.Slice) error {
+	r.Data = data
+	return nil
+}
+
+// UnmarshalVPack must not be used, since UnmarshalVPackSlice is preferred.
+func (r *SliceUnmarshalerRaw) UnmarshalVPack(data velocypack.Slice) error {
+	return errors.New("UnmarshalVPack called")
+}
+
+func TestDecoderSliceUnmarshaler(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"a":[1,2,3],"b":"foo"}`))
+
+	var v struct {
+		A SliceUnmarshalerRaw
+		B *SliceUnmarshalerRaw
+	}
+	ASSERT_NIL(velocypack.Unmarshal(s, &v), t)
+	ASSERT_EQ(mustString(v.A.Data.JSONString()), `[1,2,3]`, t)
+	ASSERT_EQ(mustString(v.B.Data.JSONString()), `"foo"`, t)
+
+	// The given slices share memory with the input.
+	a := mustSlice(s.Get("a"))
+	ASSERT_TRUE(&v.A.Data[0] == &a[0], t)
+}
@@ -0,0 +1,206 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+type CycleNode struct {
+	Name string
+	Next *CycleNode `json:"next,omitempty"`
+}
+
+// nestedArrays returns a slice with the given number of nested arrays.
+func nestedArrays(depth int) velocypack.Slice {
+	return mustSlice(velocypack.ParseJSONFromString(strings.Repeat("[", depth) + "1" + strings.Repeat("]", depth)))
+}
+
+// assertUnsupportedValue asserts that err is an UnsupportedValueError and returns it.
+func assertUnsupportedValue(err error, t *testing.T) *velocypack.UnsupportedValueError {
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnsupportedValue, t)(err)
+	e := velocypack.Cause(err).(*velocypack.UnsupportedValueError)
+	// The path must be usable to query the value.
+	if _, err := velocypack.ParsePath(e.Path); err != nil {
+		t.Errorf("Invalid path '%s': %v", e.Path, err)
+	}
+	return e
+}
+
+func TestEncoderCyclePointer(t *testing.T) {
+	n := &CycleNode{Name: "a"}
+	n.Next = &CycleNode{Name: "b", Next: n}
+
+	_, err := velocypack.Marshal(n)
+	e := assertUnsupportedValue(err, t)
+	// Like encoding/json, cycle errors have no path.
+	ASSERT_EQ(e.Path, "", t)
+	ASSERT_EQ(e.Error(), "unsupported value: encountered a cycle via *test.CycleNode", t)
+}
+
+func TestEncoderCycleMap(t *testing.T) {
+	m := map[string]interface{}{}
+	m["a.b"] = map[string]interface{}{"self": m}
+
+	_, err := velocypack.Marshal(m)
+	e := assertUnsupportedValue(err, t)
+	ASSERT_EQ(e.Error(), "unsupported value: encountered a cycle via map[string]interface {}", t)
+}
+
+func TestEncoderCycleSlice(t *testing.T) {
+	s := []interface{}{"x", nil}
+	s[1] = s
+
+	_, err := velocypack.Marshal(s)
+	e := assertUnsupportedValue(err, t)
+	ASSERT_EQ(e.Error(), "unsupported value: encountered a cycle via []interface {}", t)
+}
+
+// CycleMarshalerNode encodes itself with MarshalVPackTo, passing its fields on to MarshalTo.
+type CycleMarshalerNode struct {
+	Name string
+	Next *CycleMarshalerNode
+}
+
+func (n CycleMarshalerNode) MarshalVPackTo(b *velocypack.Builder) error {
+	if err := b.OpenObject(); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("name", velocypack.NewStringValue(n.Name)); err != nil {
+		return err
+	}
+	if err := b.AddValue(velocypack.NewStringValue("next")); err != nil {
+		return err
+	}
+	if err := velocypack.MarshalTo(b, n.Next); err != nil {
+		return err
+	}
+	return b.Close()
+}
+
+func TestEncoderCycleBuilderMarshaler(t *testing.T) {
+	n := &CycleMarshalerNode{Name: "a"}
+	n.Next = &CycleMarshalerNode{Name: "b", Next: n}
+
+	_, err := velocypack.Marshal(n)
+	e := assertUnsupportedValue(err, t)
+	ASSERT_EQ(e.Error(), "unsupported value: encountered a cycle via *test.CycleMarshalerNode", t)
+
+	// Also when nested in other values.
+	_, err = velocypack.Marshal(map[string]interface{}{"list": []interface{}{n}})
+	assertUnsupportedValue(err, t)
+
+	var b velocypack.Builder
+	assertUnsupportedValue(velocypack.MarshalTo(&b, n), t)
+}
+
+func TestEncoderMaxDepthBuilderMarshaler(t *testing.T) {
+	var n *CycleMarshalerNode
+	for i := 0; i < 5; i++ {
+		n = &CycleMarshalerNode{Name: "n", Next: n}
+	}
+	_, err := velocypack.MarshalWithOptions(n, velocypack.EncoderOptions{MaxDepth: 5})
+	ASSERT_NIL(err, t)
+	_, err = velocypack.MarshalWithOptions([]interface{}{n}, velocypack.EncoderOptions{MaxDepth: 5})
+	e := assertUnsupportedValue(err, t)
+	ASSERT_EQ(e.Error(), "unsupported value: exceeds maximum depth of 5", t)
+
+	// Nested MarshalTo calls use the options of the encoding in progress.
+	var buf bytes.Buffer
+	enc := velocypack.NewEncoder(&buf, velocypack.EncoderOptions{MaxDepth: 4})
+	assertUnsupportedValue(enc.Encode(n), t)
+}
+
+func TestEncoderDeepNoCycle(t *testing.T) {
+	// A long list passes the cycle detection threshold without being cyclic.
+	var n *CycleNode
+	for i := 0; i < 2000; i++ {
+		n = &CycleNode{Name: "n", Next: n}
+	}
+	s, err := velocypack.Marshal(n)
+	ASSERT_NIL(err, t)
+	var out CycleNode
+	ASSERT_NIL(velocypack.Unmarshal(s, &out), t)
+	ASSERT_EQ(out.Next.Name, "n", t)
+
+	// The same value may occur multiple times, as long as it does not contain itself.
+	shared := []int{1, 2}
+	s, err = velocypack.Marshal([][]int{shared, shared})
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(mustString(s.JSONString()), "[[1,2],[1,2]]", t)
+}
+
+func TestEncoderMaxDepth(t *testing.T) {
+	v := map[string]interface{}{
+		"a": []interface{}{1, []interface{}{[]interface{}{2}}},
+	}
+	var buf bytes.Buffer
+	enc := velocypack.NewEncoder(&buf)
+	enc.SetMaxDepth(4)
+	ASSERT_NIL(enc.Encode(v), t)
+
+	enc.SetMaxDepth(3)
+	e := assertUnsupportedValue(enc.Encode(v), t)
+	ASSERT_EQ(e.Path, "a[1][0]", t)
+	ASSERT_EQ(e.Error(), "unsupported value at a[1][0]: exceeds maximum depth of 3", t)
+
+	// Marshal uses the default maximum depth.
+	var deep interface{} = 1
+	for i := 0; i < velocypack.DefaultMaxDepth; i++ {
+		deep = []interface{}{deep}
+	}
+	_, err := velocypack.Marshal(deep)
+	ASSERT_NIL(err, t)
+	_, err = velocypack.Marshal([]interface{}{deep})
+	e = assertUnsupportedValue(err, t)
+	ASSERT_EQ(e.Path, strings.Repeat("[0]", velocypack.DefaultMaxDepth), t)
+}
+
+func TestDecoderMaxDepth(t *testing.T) {
+	s := nestedArrays(5)
+
+	var v interface{}
+	ASSERT_NIL(velocypack.UnmarshalWithOptions(s, &v, velocypack.DecoderOptions{MaxDepth: 5}), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsMaxDepthExceeded, t)(velocypack.UnmarshalWithOptions(s, &v, velocypack.DecoderOptions{MaxDepth: 4}))
+
+	var typed [][][][][]int
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsMaxDepthExceeded, t)(velocypack.UnmarshalWithOptions(s, &typed, velocypack.DecoderOptions{MaxDepth: 4}))
+
+	obj := mustSlice(velocypack.ParseJSONFromString(`{"a":{"b":{"c":1}}}`))
+	var m map[string]interface{}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsMaxDepthExceeded, t)(velocypack.UnmarshalWithOptions(obj, &m, velocypack.DecoderOptions{MaxDepth: 2}))
+
+	d := velocypack.NewDecoder(bytes.NewReader(s))
+	d.SetMaxDepth(4)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsMaxDepthExceeded, t)(d.Decode(&v))
+}
+
+func TestDecoderDefaultMaxDepth(t *testing.T) {
+	var v interface{}
+	ASSERT_NIL(velocypack.Unmarshal(nestedArrays(velocypack.DefaultMaxDepth), &v), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsMaxDepthExceeded, t)(velocypack.Unmarshal(nestedArrays(velocypack.DefaultMaxDepth+1), &v))
+}
@@ -0,0 +1,169 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+type EncoderOptionsStruct struct {
+	Slice  []int
+	Map    map[string]int
+	Bytes  []byte
+	Ptr    *int
+	Quoted string `json:",string"`
+}
+
+// duplicateKey marshals every value to the same text, so a map with more than one
+// duplicateKey results in an object with duplicate attributes.
+type duplicateKey int
+
+func (duplicateKey) MarshalText() ([]byte, error) {
+	return []byte("key"), nil
+}
+
+func TestEncoderOptionsUnindexed(t *testing.T) {
+	v := map[string]interface{}{
+		"a": []interface{}{1, "abc", 1000},
+		"b": "text",
+	}
+	indexed := mustSlice(velocypack.Marshal(v))
+	ASSERT_EQ(byte(0x0b), indexed[0], t)
+	ASSERT_EQ(byte(0x06), mustSlice(indexed.Get("a"))[0], t)
+
+	s := mustSlice(velocypack.MarshalWithOptions(v, velocypack.EncoderOptions{BuildUnindexedArrays: true, BuildUnindexedObjects: true}))
+	ASSERT_EQ(byte(0x14), s[0], t)
+	ASSERT_EQ(byte(0x13), mustSlice(s.Get("a"))[0], t)
+	ASSERT_TRUE(len(s) < len(indexed), t)
+	ASSERT_EQ(mustString(s.JSONString()), mustString(indexed.JSONString()), t)
+
+	var buf bytes.Buffer
+	enc := velocypack.NewEncoder(&buf, velocypack.EncoderOptions{BuildUnindexedArrays: true})
+	ASSERT_NIL(enc.Encode([]interface{}{1, "abc", 1000}), t)
+	ASSERT_EQ(byte(0x13), buf.Bytes()[0], t)
+}
+
+func TestEncoderOptionsUnsortedMapKeys(t *testing.T) {
+	v := map[string]int{}
+	for _, k := range []string{"d", "a", "c", "b", "e", "f"} {
+		v[k] = len(v)
+	}
+	s := mustSlice(velocypack.MarshalWithOptions(v, velocypack.EncoderOptions{UnsortedMapKeys: true, BuildUnindexedObjects: true}))
+	var out map[string]int
+	ASSERT_NIL(velocypack.Unmarshal(s, &out), t)
+	ASSERT_EQ(out, v, t)
+}
+
+func TestEncoderOptionsNilAsEmpty(t *testing.T) {
+	var v EncoderOptionsStruct
+	s := mustSlice(velocypack.Marshal(v))
+	ASSERT_TRUE(mustSlice(s.Get("Slice")).IsNull(), t)
+	ASSERT_TRUE(mustSlice(s.Get("Map")).IsNull(), t)
+	ASSERT_TRUE(mustSlice(s.Get("Bytes")).IsNull(), t)
+
+	s = mustSlice(velocypack.MarshalWithOptions(v, velocypack.EncoderOptions{NilAsEmpty: true}))
+	ASSERT_TRUE(mustSlice(s.Get("Slice")).IsEmptyArray(), t)
+	ASSERT_TRUE(mustSlice(s.Get("Map")).IsEmptyObject(), t)
+	ASSERT_TRUE(mustSlice(s.Get("Bytes")).IsBinary(), t)
+	ASSERT_EQ(mustLength(mustSlice(s.Get("Bytes")).GetBinaryLength()), velocypack.ValueLength(0), t)
+	// Nil pointers are not affected.
+	ASSERT_TRUE(mustSlice(s.Get("Ptr")).IsNull(), t)
+
+	var out EncoderOptionsStruct
+	ASSERT_NIL(velocypack.Unmarshal(s, &out), t)
+	ASSERT_EQ(out.Slice, []int{}, t)
+	ASSERT_EQ(out.Map, map[string]int{}, t)
+}
+
+func TestEncoderOptionsCheckAttributeUniqueness(t *testing.T) {
+	v := map[duplicateKey]int{1: 1, 2: 2}
+	s := mustSlice(velocypack.Marshal(v))
+	// Both keys are "key", so their order is not defined.
+	json := mustString(s.JSONString())
+	ASSERT_TRUE(json == `{"key":1,"key":2}` || json == `{"key":2,"key":1}`, t)
+
+	_, err := velocypack.MarshalWithOptions(v, velocypack.EncoderOptions{CheckAttributeUniqueness: true})
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsDuplicateAttributeName, t)(err)
+
+	var buf bytes.Buffer
+	enc := velocypack.NewEncoder(&buf, velocypack.EncoderOptions{CheckAttributeUniqueness: true})
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsDuplicateAttributeName, t)(enc.Encode(v))
+}
+
+type EncoderOptionsTagged struct {
+	List []interface{}
+	Keys map[duplicateKey]int
+	Nil  []int
+}
+
+func TestEncoderOptionsTagged(t *testing.T) {
+	must(velocypack.RegisterTaggedType(1001, EncoderOptionsTagged{}))
+	defer velocypack.UnregisterTaggedType(1001)
+
+	v := EncoderOptionsTagged{List: []interface{}{1, "abc", 1000}}
+	s := mustSlice(velocypack.MarshalWithOptions(v, velocypack.EncoderOptions{BuildUnindexedArrays: true, BuildUnindexedObjects: true, NilAsEmpty: true}))
+	ASSERT_EQ([]uint64{1001}, s.GetTags(), t)
+	s = s.UnwrapTags()
+	ASSERT_EQ(byte(0x14), s[0], t)
+	ASSERT_EQ(byte(0x13), mustSlice(s.Get("List"))[0], t)
+	ASSERT_TRUE(mustSlice(s.Get("Nil")).IsEmptyArray(), t)
+
+	v.Keys = map[duplicateKey]int{1: 1, 2: 2}
+	_, err := velocypack.MarshalWithOptions([]interface{}{v}, velocypack.EncoderOptions{CheckAttributeUniqueness: true})
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsDuplicateAttributeName, t)(err)
+}
+
+func TestMarshalToWithOptions(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenArray())
+	must(velocypack.MarshalToWithOptions(&b, EncoderOptionsStruct{}, velocypack.EncoderOptions{BuildUnindexedObjects: true, NilAsEmpty: true}))
+	must(velocypack.MarshalTo(&b, EncoderOptionsStruct{}))
+	must(b.Close())
+	// The options only apply to the given value.
+	ASSERT_FALSE(b.BuildUnindexedObjects, t)
+	s := mustSlice(b.Slice())
+	first, second := mustSlice(s.At(0)), mustSlice(s.At(1))
+	ASSERT_EQ(byte(0x14), first[0], t)
+	ASSERT_TRUE(mustSlice(first.Get("Slice")).IsEmptyArray(), t)
+	ASSERT_EQ(byte(0x0b), second[0], t)
+	ASSERT_TRUE(mustSlice(second.Get("Slice")).IsNull(), t)
+
+	b.Clear()
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsDuplicateAttributeName, t)(velocypack.MarshalToWithOptions(&b, map[duplicateKey]int{1: 1, 2: 2}, velocypack.EncoderOptions{CheckAttributeUniqueness: true}))
+}
+
+func TestEncoderOptionsNoHTMLEscaping(t *testing.T) {
+	v := EncoderOptionsStruct{Quoted: "<a & b>"}
+	s := mustSlice(velocypack.Marshal(v))
+	ASSERT_EQ(mustString(mustSlice(s.Get("Quoted")).GetString()), `"\u003ca \u0026 b\u003e"`, t)
+
+	s = mustSlice(velocypack.MarshalWithOptions(v, velocypack.EncoderOptions{NoHTMLEscaping: true}))
+	ASSERT_EQ(mustString(mustSlice(s.Get("Quoted")).GetString()), `"<a & b>"`, t)
+
+	var out EncoderOptionsStruct
+	ASSERT_NIL(velocypack.Unmarshal(s, &out), t)
+	ASSERT_EQ(out.Quoted, v.Quoted, t)
+}
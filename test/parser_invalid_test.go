@@ -23,7 +23,9 @@
 package test
 
 import (
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	velocypack "github.com/arangodb/go-velocypack"
 )
@@ -46,3 +48,40 @@ func TestParserGarbage(t *testing.T) {
 		ASSERT_VELOCYPACK_EXCEPTION(errFunc, t)(velocypack.ParseJSONFromString(test))
 	}
 }
+
+func TestParserErrorOffset(t *testing.T) {
+	tests := map[string]int64{
+		`x`:                 0,
+		`  x`:               2,
+		`[1,2,x]`:           5,
+		`[1 2]`:             3,
+		`{"a" 1}`:           5,
+		`{"a":1,}`:          7,
+		`{1:2}`:             1,
+		`[1,]`:              3,
+		`tru`:               3,
+		`trUe`:              2,
+		`"abc`:              4,
+		"\"a\x01\"":         2,
+		`"\x"`:              2,
+		`"\u12g4"`:          5,
+		`-`:                 1,
+		`-x`:                1,
+		`1.x`:               2,
+		`1e+`:               3,
+		`5.6.7`:             3,
+		`[[}`:               2,
+		`{"a":[1,{"b":]}]}`: 13,
+	}
+	for test, offset := range tests {
+		_, err := velocypack.ParseJSONFromString(test)
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsParse, t)(err)
+		ASSERT_EQ(offset, velocypack.Cause(err).(*velocypack.ParseError).Offset, t)
+
+		// Same offset when reading byte by byte
+		var b velocypack.Builder
+		err = velocypack.NewParser(iotest.OneByteReader(strings.NewReader(test)), &b).Parse()
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsParse, t)(err)
+		ASSERT_EQ(offset, velocypack.Cause(err).(*velocypack.ParseError).Offset, t)
+	}
+}
@@ -0,0 +1,174 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"math"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func TestParserOptionsCheckAttributeUniqueness(t *testing.T) {
+	opts := velocypack.ParserOptions{CheckAttributeUniqueness: true}
+	valid := []string{
+		`{"a":1,"b":2}`,
+		`{"a":{"a":1},"b":{"a":2}}`,
+		`[{"a":1},{"a":2}]`,
+	}
+	for _, test := range valid {
+		mustSlice(velocypack.ParseJSONFromString(test, opts))
+	}
+	invalid := []string{
+		`{"a":1,"a":2}`,
+		`{"a":1,"b":2,"a":3}`,
+		`{"x":{"a":1,"b":{},"a":2}}`,
+		`[{"a":1},{"b":1,"b":2}]`,
+	}
+	for _, test := range invalid {
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsDuplicateAttributeName, t)(velocypack.ParseJSONFromString(test, opts))
+		// Without the option duplicates are accepted
+		mustSlice(velocypack.ParseJSONFromString(test))
+	}
+	// Also for unindexed objects
+	opts.BuildUnindexedObjects = true
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsDuplicateAttributeName, t)(velocypack.ParseJSONFromString(`{"a":1,"a":2}`, opts))
+}
+
+func TestParserOptionsMaxDepth(t *testing.T) {
+	opts := velocypack.ParserOptions{MaxDepth: 3}
+	mustSlice(velocypack.ParseJSONFromString(`[{"a":[1]}]`, opts))
+	mustSlice(velocypack.ParseJSONFromString(`[[[]],[[]]]`, opts))
+	_, err := velocypack.ParseJSONFromString(`[{"a":[[1]]}]`, opts)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsParse, t)(err)
+	ASSERT_EQ(int64(7), velocypack.Cause(err).(*velocypack.ParseError).Offset, t)
+}
+
+func TestParserOptionsClearAttributesAtDepth(t *testing.T) {
+	tests := []struct {
+		JSON     string
+		Depth    int
+		Expected string
+	}{
+		{`{"a":1,"b":{"c":2}}`, 1, `{}`},
+		{`{"a":{"b":1,"c":[1,2]},"d":{}}`, 2, `{"a":{},"d":{}}`},
+		{`[{"a":1},{"b":{"c":{"d":1}}}]`, 3, `[{"a":1},{"b":{}}]`},
+		{`{"a":{"b":{"c":1}},"x":[{"y":1}]}`, 2, `{"a":{},"x":[{"y":1}]}`},
+		{`{"a":{"b":{"c":1}},"x":[{"y":1}]}`, 3, `{"a":{"b":{}},"x":[{}]}`},
+	}
+	for _, test := range tests {
+		s := mustSlice(velocypack.ParseJSONFromString(test.JSON, velocypack.ParserOptions{ClearAttributesAtDepth: test.Depth}))
+		ASSERT_EQ(test.Expected, mustString(s.JSONString()), t)
+		ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+	}
+}
+
+func TestParserOptionsTopLevelAttributes(t *testing.T) {
+	json := `{"_key":"1","name":"foo","secret":{"password":"x"},"nested":{"name":"bar","secret":1}}`
+
+	s := mustSlice(velocypack.ParseJSONFromString(json, velocypack.ParserOptions{
+		KeepTopLevelAttributes: []string{"_key", "nested", "unknown"},
+	}))
+	ASSERT_EQ(`{"_key":"1","nested":{"name":"bar","secret":1}}`, mustString(s.JSONString()), t)
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+
+	s = mustSlice(velocypack.ParseJSONFromString(json, velocypack.ParserOptions{
+		ExcludeTopLevelAttributes: []string{"secret", "name"},
+	}))
+	ASSERT_EQ(`{"_key":"1","nested":{"name":"bar","secret":1}}`, mustString(s.JSONString()), t)
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+
+	s = mustSlice(velocypack.ParseJSONFromString(json, velocypack.ParserOptions{
+		KeepTopLevelAttributes:    []string{"_key", "name", "secret"},
+		ExcludeTopLevelAttributes: []string{"secret"},
+		BuildUnindexedObjects:     true,
+	}))
+	ASSERT_EQ(`{"_key":"1","name":"foo"}`, mustString(s.JSONString()), t)
+	ASSERT_EQ("foo", mustString(mustSlice(s.Get("name")).GetString()), t)
+
+	// Only applies to top level objects
+	s = mustSlice(velocypack.ParseJSONFromString(`[{"secret":1}]`, velocypack.ParserOptions{
+		ExcludeTopLevelAttributes: []string{"secret"},
+	}))
+	ASSERT_EQ(`[{"secret":1}]`, mustString(s.JSONString()), t)
+
+	// Excluded attributes are not checked for uniqueness
+	s = mustSlice(velocypack.ParseJSONFromString(`{"a":1,"secret":1,"secret":2}`, velocypack.ParserOptions{
+		ExcludeTopLevelAttributes: []string{"secret"},
+		CheckAttributeUniqueness:  true,
+	}))
+	ASSERT_EQ(`{"a":1}`, mustString(s.JSONString()), t)
+}
+
+func TestParserOptionsValidateUTF8Strings(t *testing.T) {
+	opts := velocypack.ParserOptions{ValidateUTF8Strings: true}
+	s := mustSlice(velocypack.ParseJSONFromString(`["é\u00e9","😀"]`, opts))
+	ASSERT_EQ(`["éé","😀"]`, mustString(s.JSONString()), t)
+
+	tests := map[string]int64{
+		"\"\xff\"":                        1,
+		"[\"abc\", \"\\n\xc3\"]":          11,
+		"{\"a\xe2\x82\":1}":               3,
+		"\"\\u00e9\xc3\xa9\xed\xa0\x80\"": 9,
+	}
+	for test, offset := range tests {
+		_, err := velocypack.ParseJSONFromString(test, opts)
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsParse, t)(err)
+		ASSERT_EQ(offset, velocypack.Cause(err).(*velocypack.ParseError).Offset, t)
+
+		// Without the option invalid bytes are replaced
+		mustSlice(velocypack.ParseJSONFromString(test))
+	}
+}
+
+func TestParserOptionsLargeNumbers(t *testing.T) {
+	// Default
+	s := mustSlice(velocypack.ParseJSONFromString(`123456789012345678901234567890`))
+	ASSERT_TRUE(s.IsDouble(), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsParse, t)(velocypack.ParseJSONFromString(`1e400`))
+
+	// Double
+	opts := velocypack.ParserOptions{LargeNumbers: velocypack.LargeNumbersAsDouble}
+	s = mustSlice(velocypack.ParseJSONFromString(`[1e400,-1e400,123456789012345678901234567890,1.5]`, opts))
+	ASSERT_DOUBLE_EQ(math.MaxFloat64, mustDouble(mustSlice(s.At(0)).GetDouble()), t)
+	ASSERT_DOUBLE_EQ(-math.MaxFloat64, mustDouble(mustSlice(s.At(1)).GetDouble()), t)
+	ASSERT_DOUBLE_EQ(1.2345678901234568e29, mustDouble(mustSlice(s.At(2)).GetDouble()), t)
+	ASSERT_DOUBLE_EQ(1.5, mustDouble(mustSlice(s.At(3)).GetDouble()), t)
+	// Clamped numbers can be dumped as JSON and parsed again
+	json := mustString(s.JSONString())
+	ASSERT_EQ(json, mustString(mustSlice(velocypack.ParseJSONFromString(json)).JSONString()), t)
+
+	// BCD
+	opts = velocypack.ParserOptions{LargeNumbers: velocypack.LargeNumbersAsBCD}
+	s = mustSlice(velocypack.ParseJSONFromString(`[1e400,-1.5e-400,123456789012345678901234567890,-9223372036854775809,18446744073709551615,1.5]`, opts))
+	expected := []string{"1E+400", "-1.5E-400", "123456789012345678901234567890", "-9223372036854775809"}
+	for i, e := range expected {
+		v := mustSlice(s.At(velocypack.ValueLength(i)))
+		ASSERT_TRUE(v.IsBCD(), t)
+		d, err := v.GetBCD()
+		ASSERT_NIL(err, t)
+		ASSERT_EQ(e, d.String(), t)
+	}
+	ASSERT_EQ(uint64(math.MaxUint64), mustUInt(mustSlice(s.At(4)).GetUInt()), t)
+	ASSERT_DOUBLE_EQ(1.5, mustDouble(mustSlice(s.At(5)).GetDouble()), t)
+}
@@ -26,7 +26,9 @@ import (
 	"encoding/json"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	velocypack "github.com/arangodb/go-velocypack"
 )
@@ -103,6 +105,9 @@ func TestParserString(t *testing.T) {
 		`foo`,
 		`'quoted "foo"'`,
 		``,
+		"\\ \" \b \f \n \r \t \x01 /",
+		"你好，世界 😀",
+		strings.Repeat("long string ", 20),
 	}
 	for _, test := range tests {
 		j, err := json.Marshal(test)
@@ -113,3 +118,51 @@ func TestParserString(t *testing.T) {
 		ASSERT_EQ(test, mustString(s.GetString()), t)
 	}
 }
+
+func TestParserStringEscapes(t *testing.T) {
+	tests := map[string]string{
+		`"\u0041\u00e9\u4f60"`: "Aé你",
+		`"\/"`:                 "/",
+		`"\ud83d\ude00"`:       "😀",
+		`"\ud83d"`:             "\uFFFD",
+		`"\ud83dx"`:            "\uFFFDx",
+		`"\ude00\ud83d\ude00"`: "\uFFFD😀",
+		"\"a\xffb\xe2\x82\"":   "a\uFFFDb\uFFFD\uFFFD",
+	}
+	for test, expected := range tests {
+		s := mustSlice(velocypack.ParseJSONFromString(test))
+		ASSERT_EQ(expected, mustString(s.GetString()), t)
+	}
+}
+
+func TestParserReader(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 20000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(`{"name":"` + strings.Repeat("n\\u00e9", i%50) + `","value":` + strconv.Itoa(i*i-5000) + `,"ratio":1.5e-3,"ok":true,"none":null}`)
+	}
+	sb.WriteString("]")
+	json := sb.String()
+	expected := mustSlice(velocypack.ParseJSONFromString(json))
+
+	// Large input (more than the read buffer)
+	ASSERT_EQ(expected, mustSlice(velocypack.ParseJSON(strings.NewReader(json))), t)
+	// Input read byte by byte
+	ASSERT_EQ(expected, mustSlice(velocypack.ParseJSON(iotest.OneByteReader(strings.NewReader(json)))), t)
+
+	ASSERT_EQ(velocypack.ValueLength(20000), mustLength(expected.Length()), t)
+	last := mustSlice(expected.At(19999))
+	ASSERT_EQ(int64(19999*19999-5000), mustInt(mustSlice(last.Get("value")).GetInt()), t)
+}
+
+func TestParserMultipleValues(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenArray())
+	must(velocypack.NewParser(strings.NewReader("1 \"a\"\n[2]\t{\"b\":null}"), &b).Parse())
+	must(b.Close())
+	s := mustSlice(b.Slice())
+	ASSERT_EQ(`[1,"a",[2],{"b":null}]`, mustString(s.JSONString()), t)
+}
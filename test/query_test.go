@@ -0,0 +1,168 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+	"github.com/arangodb/go-velocypack/query"
+)
+
+const jsonPathTestJSON = `{"orders":[` +
+	`{"id":1,"total":50,"customer":{"name":"ann","vip":true},"items":[{"sku":"a","qty":1}]},` +
+	`{"id":2,"total":150.5,"customer":{"name":"bob"},"items":[{"sku":"b","qty":2},{"sku":"c","qty":5}]},` +
+	`{"id":3,"total":250,"customer":{"name":"cid","vip":false},"items":[]},` +
+	`{"id":4,"total":"999","status":null}` +
+	`],"limit":200,"tags":["x","y","z","w"],"a b":{"c":1}}`
+
+// TestQueryEvaluate checks evaluating JSONPath expressions.
+func TestQueryEvaluate(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(jsonPathTestJSON))
+	tests := []struct {
+		Expr     string
+		Expected []string
+	}{
+		{`$.limit`, []string{`200`}},
+		{`$['limit']`, []string{`200`}},
+		{`$["a b"].c`, []string{`1`}},
+		{`$.orders[0].id`, []string{`1`}},
+		{`$.orders[-1].id`, []string{`4`}},
+		{`$.orders[9].id`, nil},
+		{`$.orders[*].id`, []string{`1`, `2`, `3`, `4`}},
+		{`$.orders.*.id`, []string{`1`, `2`, `3`, `4`}},
+		{`$.orders[0,2].id`, []string{`1`, `3`}},
+		{`$.orders[0]['id','total']`, []string{`1`, `50`}},
+		// Slicing
+		{`$.tags[1:3]`, []string{`"y"`, `"z"`}},
+		{`$.tags[:2]`, []string{`"x"`, `"y"`}},
+		{`$.tags[2:]`, []string{`"z"`, `"w"`}},
+		{`$.tags[-2:]`, []string{`"z"`, `"w"`}},
+		{`$.tags[::2]`, []string{`"x"`, `"z"`}},
+		{`$.tags[::-1]`, []string{`"w"`, `"z"`, `"y"`, `"x"`}},
+		{`$.tags[3:0:-2]`, []string{`"w"`, `"y"`}},
+		{`$.tags[0:10:0]`, nil},
+		{`$.tags[5:9]`, nil},
+		// Recursive descent
+		{`$..sku`, []string{`"a"`, `"b"`, `"c"`}},
+		{`$..customer.name`, []string{`"ann"`, `"bob"`, `"cid"`}},
+		{`$..items[-1].qty`, []string{`1`, `5`}},
+		{`$.orders[1]..qty`, []string{`2`, `5`}},
+		{`$.orders[0].customer..*`, []string{`"ann"`, `true`}},
+		// Filters
+		{`$.orders[?(@.total > 100)].id`, []string{`2`, `3`}},
+		{`$.orders[?@.total >= 150.5].id`, []string{`2`, `3`}},
+		{`$.orders[?(@.total < 100)].id`, []string{`1`}},
+		{`$.orders[?(@.total <= $.limit)].id`, []string{`1`, `2`}},
+		{`$.orders[?(@.total == 250)].id`, []string{`3`}},
+		{`$.orders[?(@.total == 250.0)].id`, []string{`3`}},
+		{`$.orders[?(@.total != 250)].id`, []string{`1`, `2`, `4`}},
+		{`$.orders[?(@.total == '999')].id`, []string{`4`}},
+		{`$.orders[?(@.customer.vip)].id`, []string{`1`, `3`}},
+		{`$.orders[?(!@.customer.vip)].id`, []string{`2`, `4`}},
+		{`$.orders[?(@.customer.vip == true)].id`, []string{`1`}},
+		{`$.orders[?(@.status == null)].id`, []string{`4`}},
+		{`$.orders[?(@.missing == @.other)].id`, []string{`1`, `2`, `3`, `4`}},
+		{`$.orders[?(@.total > 100 && @.customer.name == "bob")].id`, []string{`2`}},
+		{`$.orders[?(@.total < 100 || @.id == 3)].id`, []string{`1`, `3`}},
+		{`$.orders[?(!(@.total > 100) && @.id != 4)].id`, []string{`1`}},
+		{`$.orders[?(@.items[?(@.qty > 4)])].id`, []string{`2`}},
+		{`$.orders[?(@..qty == 2)].id`, nil},
+		{`$..items[?(@.qty >= 2)].sku`, []string{`"b"`, `"c"`}},
+		{`$.tags[?(@ > 'x')]`, []string{`"y"`, `"z"`}},
+		{`$.tags[?(@ == 'x' || @ == 'w')]`, []string{`"x"`, `"w"`}},
+		{`$.orders[?(@.id > '1')].id`, nil},
+		{`$..[?(@.c == 1)]`, []string{`{"c":1}`}},
+	}
+	for _, test := range tests {
+		result, err := query.Evaluate(s, test.Expr)
+		if err != nil {
+			t.Errorf("Evaluate '%s' failed: %v", test.Expr, err)
+			continue
+		}
+		var got []string
+		for _, r := range result {
+			got = append(got, mustString(r.JSONString()))
+		}
+		if strings.Join(got, "|") != strings.Join(test.Expected, "|") || len(got) != len(test.Expected) {
+			t.Errorf("Evaluate '%s' returned unexpected result\nExpected: %v\nGot: %v", test.Expr, test.Expected, got)
+		}
+	}
+}
+
+// TestQueryCompiled checks reuse of a compiled query.
+func TestQueryCompiled(t *testing.T) {
+	q := query.MustCompile(`$[?(@.v > 1)].v`)
+	ASSERT_EQ(`$[?(@.v > 1)].v`, q.String(), t)
+	for i := 0; i < 3; i++ {
+		s := mustSlice(velocypack.ParseJSONFromString(`[{"v":1},{"v":2},{"v":3}]`))
+		result, err := q.Evaluate(s)
+		ASSERT_NIL(err, t)
+		ASSERT_EQ(2, len(result), t)
+		ASSERT_EQ(uint64(3), mustUInt(result[1].GetUInt()), t)
+		// Results are sub-slices of the input
+		result[1][0] = 0x35
+		ASSERT_EQ(`[{"v":1},{"v":2},{"v":5}]`, mustString(s.JSONString()), t)
+	}
+}
+
+// TestQueryCompileInvalid checks that invalid expressions are rejected.
+func TestQueryCompileInvalid(t *testing.T) {
+	tests := []string{
+		``,
+		`a`,
+		`$.`,
+		`$..`,
+		`$.1a`,
+		`$[`,
+		`$[]`,
+		`$[a]`,
+		`$[1`,
+		`$[1,]`,
+		`$['a`,
+		`$['\q']`,
+		`$[?()]`,
+		`$[?(@.a ==)]`,
+		`$[?(@.a == 1]`,
+		`$[?(1)]`,
+		`$[?(@.a > 1e)]`,
+		`$[?(@.a === 1)]`,
+		`$.a b`,
+	}
+	for _, test := range tests {
+		if _, err := query.Compile(test); !query.IsSyntax(err) {
+			t.Errorf("Expected SyntaxError for '%s', got %v", test, err)
+		}
+	}
+}
+
+// TestQueryEvaluateInvalidData checks that malformed values in a filter comparison result in an error.
+func TestQueryEvaluateInvalidData(t *testing.T) {
+	// An array containing a BCD value with an invalid digit
+	s := velocypack.Slice{0x02, 0x09, 0xc8, 0x01, 0x00, 0x00, 0x00, 0x00, 0xff}
+	for _, expr := range []string{`$[?(@ > 1)]`, `$[?(@ == 1)]`, `$[?(@ != 1)]`} {
+		_, err := query.Evaluate(s, expr)
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsInvalidBCD, t)(err)
+	}
+}
@@ -0,0 +1,224 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	velocypack "github.com/arangodb/go-velocypack"
+	"github.com/arangodb/go-velocypack/schema"
+)
+
+// TestSchemaValidate checks validating slices against a variety of schemas.
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		Schema  string
+		Valid   []string
+		Invalid []string
+	}{
+		{`true`, []string{`1`, `{}`}, nil},
+		{`false`, nil, []string{`1`, `{}`}},
+		{`{}`, []string{`1`, `null`, `[]`}, nil},
+		{`{"type":"string"}`, []string{`"a"`}, []string{`1`, `null`}},
+		{`{"type":["integer","null"]}`, []string{`1`, `-5`, `2.0`, `null`}, []string{`1.5`, `"1"`}},
+		{`{"type":"number"}`, []string{`1`, `1.5`}, []string{`true`}},
+		{`{"type":"boolean"}`, []string{`true`, `false`}, []string{`0`}},
+		{`{"enum":[1,"a",{"x":[1]}]}`, []string{`1`, `1.0`, `"a"`, `{"x":[1]}`}, []string{`2`, `"b"`, `{"x":[2]}`}},
+		{`{"const":{"a":1,"b":2}}`, []string{`{"b":2,"a":1}`}, []string{`{"a":1}`}},
+		{`{"minimum":1,"maximum":10}`, []string{`1`, `10`, `5.5`, `"x"`}, []string{`0`, `10.1`, `-1`}},
+		{`{"exclusiveMinimum":1,"exclusiveMaximum":10}`, []string{`2`, `9.9`}, []string{`1`, `10`}},
+		{`{"multipleOf":0.1}`, []string{`0.3`, `1`, `-0.7`}, []string{`0.35`}},
+		{`{"multipleOf":3}`, []string{`9`, `0`}, []string{`10`}},
+		{`{"minLength":2,"maxLength":3}`, []string{`"ab"`, `"äöü"`, `1`}, []string{`"a"`, `"abcd"`}},
+		{`{"pattern":"^[a-z]+$"}`, []string{`"abc"`, `1`}, []string{`"aBc"`}},
+		{`{"minItems":1,"maxItems":2,"uniqueItems":true}`, []string{`[1]`, `[1,"1"]`}, []string{`[]`, `[1,2,3]`, `[1,1.0]`}},
+		{`{"items":{"type":"integer"}}`, []string{`[]`, `[1,2]`}, []string{`[1,"a"]`}},
+		{`{"prefixItems":[{"type":"string"}],"items":false}`, []string{`["a"]`}, []string{`[1]`, `["a",1]`}},
+		{`{"items":[{"type":"string"}],"additionalItems":{"type":"integer"}}`, []string{`["a",1,2]`}, []string{`["a","b"]`}},
+		{`{"contains":{"type":"string"}}`, []string{`[1,"a"]`}, []string{`[1,2]`, `[]`}},
+		{`{"contains":{"type":"string"},"minContains":2,"maxContains":3}`, []string{`["a","b"]`}, []string{`["a"]`, `["a","b","c","d"]`}},
+		{`{"required":["a","b"]}`, []string{`{"a":1,"b":2}`, `[]`}, []string{`{"a":1}`}},
+		{`{"minProperties":1,"maxProperties":2}`, []string{`{"a":1}`}, []string{`{}`, `{"a":1,"b":2,"c":3}`}},
+		{`{"properties":{"a":{"type":"string"}},"patternProperties":{"^x-":{"type":"integer"}},"additionalProperties":false}`,
+			[]string{`{"a":"s","x-1":1}`, `{}`}, []string{`{"a":1}`, `{"x-1":"s"}`, `{"b":1}`}},
+		{`{"propertyNames":{"maxLength":3}}`, []string{`{"abc":1}`}, []string{`{"abcd":1}`}},
+		{`{"dependentRequired":{"a":["b"]}}`, []string{`{"a":1,"b":1}`, `{"b":1}`}, []string{`{"a":1}`}},
+		{`{"dependentSchemas":{"a":{"required":["c"]}}}`, []string{`{"a":1,"c":1}`, `{}`}, []string{`{"a":1}`}},
+		{`{"dependencies":{"a":["b"],"c":{"required":["d"]}}}`, []string{`{"a":1,"b":1,"c":1,"d":1}`}, []string{`{"a":1}`, `{"c":1}`}},
+		{`{"allOf":[{"minimum":1},{"maximum":2}]}`, []string{`1`, `2`}, []string{`0`, `3`}},
+		{`{"anyOf":[{"type":"string"},{"type":"integer"}]}`, []string{`"a"`, `1`}, []string{`1.5`}},
+		{`{"oneOf":[{"type":"integer"},{"minimum":2}]}`, []string{`1`, `2.5`}, []string{`3`, `1.5`}},
+		{`{"not":{"type":"string"}}`, []string{`1`}, []string{`"a"`}},
+		{`{"if":{"type":"integer"},"then":{"minimum":10},"else":{"type":"string"}}`, []string{`10`, `"a"`}, []string{`5`, `true`}},
+		{`{"$defs":{"pos":{"type":"integer","minimum":0}},"properties":{"a":{"$ref":"#/$defs/pos"}}}`, []string{`{"a":1}`}, []string{`{"a":-1}`}},
+		{`{"definitions":{"a~/b":{"type":"string"}},"items":{"$ref":"#/definitions/a~0~1b"}}`, []string{`["x"]`}, []string{`[1]`}},
+		{`{"$defs":{"s":{"$anchor":"str","type":"string"}},"items":{"$ref":"#str"}}`, []string{`["x"]`}, []string{`[1]`}},
+		// Recursive schema
+		{`{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#"}}},"required":["name"]}`,
+			[]string{`{"name":"a","children":[{"name":"b","children":[]}]}`}, []string{`{"name":"a","children":[{"children":[]}]}`}},
+	}
+	for i, test := range tests {
+		s, err := schema.CompileJSON(test.Schema)
+		if err != nil {
+			t.Errorf("Compile of test %d failed: %v", i, err)
+			continue
+		}
+		for _, v := range test.Valid {
+			if err := s.Validate(mustSlice(velocypack.ParseJSONFromString(v))); err != nil {
+				t.Errorf("Expected %s to be valid in test %d, got %v", v, i, err)
+			}
+			ASSERT_TRUE(mustBool(s.IsValid(mustSlice(velocypack.ParseJSONFromString(v)))), t)
+		}
+		for _, v := range test.Invalid {
+			if err := s.Validate(mustSlice(velocypack.ParseJSONFromString(v))); !schema.IsValidation(err) {
+				t.Errorf("Expected %s to be invalid in test %d, got %v", v, i, err)
+			}
+			ASSERT_FALSE(mustBool(s.IsValid(mustSlice(velocypack.ParseJSONFromString(v)))), t)
+		}
+	}
+}
+
+// TestSchemaViolations checks that all violations are reported with their paths.
+func TestSchemaViolations(t *testing.T) {
+	s := schema.MustCompileJSON(`{
+		"type": "object",
+		"required": ["name", "age"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"address": {"$ref": "#/$defs/address"}
+		},
+		"$defs": {
+			"address": {"type": "object", "properties": {"zip": {"pattern": "^[0-9]{5}$"}}}
+		}
+	}`)
+	err := s.Validate(mustSlice(velocypack.ParseJSONFromString(`{"name":"","tags":["a",1,"b",true],"address":{"zip":"abc"}}`)))
+	ASSERT_TRUE(schema.IsValidation(err), t)
+	violations := velocypack.Cause(err).(schema.ValidationError).Violations
+
+	expected := []struct{ Path, SchemaPath string }{
+		{"", "/required"},
+		{"/name", "/properties/name/minLength"},
+		{"/tags/1", "/properties/tags/items/type"},
+		{"/tags/3", "/properties/tags/items/type"},
+		{"/address/zip", "/$defs/address/properties/zip/pattern"},
+	}
+	ASSERT_EQ(len(expected), len(violations), t)
+	for i, x := range expected {
+		ASSERT_EQ(x.Path, violations[i].Path, t)
+		ASSERT_EQ(x.SchemaPath, violations[i].SchemaPath, t)
+	}
+	ASSERT_EQ("missing required property 'age'", violations[0].Message, t)
+}
+
+// TestSchemaContainsViolations checks the keywords reported for contains violations.
+func TestSchemaContainsViolations(t *testing.T) {
+	tests := []struct {
+		Schema, Value, SchemaPath string
+	}{
+		{`{"contains":{"type":"string"}}`, `[1]`, "/contains"},
+		{`{"contains":{"type":"string"},"minContains":2}`, `["a"]`, "/minContains"},
+		{`{"contains":{"type":"string"},"minContains":1}`, `[]`, "/minContains"},
+		{`{"contains":{"type":"string"},"maxContains":1}`, `["a","b"]`, "/maxContains"},
+	}
+	for _, test := range tests {
+		err := schema.MustCompileJSON(test.Schema).Validate(mustSlice(velocypack.ParseJSONFromString(test.Value)))
+		ASSERT_TRUE(schema.IsValidation(err), t)
+		violations := velocypack.Cause(err).(schema.ValidationError).Violations
+		ASSERT_EQ(1, len(violations), t)
+		ASSERT_EQ(test.SchemaPath, violations[0].SchemaPath, t)
+	}
+}
+
+// TestSchemaVelocyPackTypes checks the VelocyPack specific extension keywords.
+func TestSchemaVelocyPackTypes(t *testing.T) {
+	s := schema.MustCompileJSON(`{
+		"properties": {
+			"created": {"vpackType": "UTCDate", "vpackMinDate": "2020-01-01T00:00:00Z", "vpackMaxDate": "2030-01-01T00:00:00Z"},
+			"data": {"vpackType": ["binary", "null"], "vpackMinBinaryLength": 1, "vpackMaxBinaryLength": 4}
+		}
+	}`)
+	build := func(created, data velocypack.Value) velocypack.Slice {
+		var b velocypack.Builder
+		must(b.OpenObject())
+		must(b.AddKeyValue("created", created))
+		must(b.AddKeyValue("data", data))
+		must(b.Close())
+		return mustSlice(b.Slice())
+	}
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	ASSERT_NIL(s.Validate(build(velocypack.NewUTCDateValue(date), velocypack.NewBinaryValue([]byte{1, 2}))), t)
+	ASSERT_NIL(s.Validate(build(velocypack.NewUTCDateValue(date), velocypack.NewNullValue())), t)
+
+	invalid := []velocypack.Slice{
+		build(velocypack.NewStringValue("2025-06-01"), velocypack.NewNullValue()),
+		build(velocypack.NewUTCDateValue(date.AddDate(-10, 0, 0)), velocypack.NewNullValue()),
+		build(velocypack.NewUTCDateValue(date.AddDate(10, 0, 0)), velocypack.NewNullValue()),
+		build(velocypack.NewUTCDateValue(date), velocypack.NewStringValue("x")),
+		build(velocypack.NewUTCDateValue(date), velocypack.NewBinaryValue(nil)),
+		build(velocypack.NewUTCDateValue(date), velocypack.NewBinaryValue([]byte{1, 2, 3, 4, 5})),
+	}
+	for i, v := range invalid {
+		if err := s.Validate(v); !schema.IsValidation(err) {
+			t.Errorf("Expected validation error in test %d, got %v", i, err)
+		}
+	}
+
+	// UTCDate & Binary do not match JSON types
+	ASSERT_FALSE(mustBool(schema.MustCompileJSON(`{"type":["string","number"]}`).IsValid(build(velocypack.NewUTCDateValue(date), velocypack.NewNullValue()))), t)
+}
+
+// TestSchemaCompileInvalid checks that invalid schemas are rejected.
+func TestSchemaCompileInvalid(t *testing.T) {
+	tests := []string{
+		`1`,
+		`{"type":"foo"}`,
+		`{"type":1}`,
+		`{"vpackType":"Foo"}`,
+		`{"minLength":-1}`,
+		`{"maxItems":1.5}`,
+		`{"multipleOf":0}`,
+		`{"minimum":"1"}`,
+		`{"pattern":"("}`,
+		`{"required":[1]}`,
+		`{"properties":{"a":1}}`,
+		`{"allOf":[]}`,
+		`{"$ref":"#/$defs/missing"}`,
+		`{"$ref":"#missing"}`,
+		`{"$ref":"http://example.com/schema"}`,
+		`{"vpackMinDate":"yesterday"}`,
+		`{"$defs":{"a":{"type":"foo"}}}`,
+		`{"$ref":"#"}`,
+		`{"$defs":{"x":{"$ref":"#/$defs/y"},"y":{"$ref":"#/$defs/x"}},"$ref":"#/$defs/x"}`,
+		`{"$defs":{"x":{"$ref":"#/$defs/y"},"y":{"$ref":"#/$defs/x"}}}`,
+		`{"$defs":{"a":{"allOf":[{"$ref":"#/$defs/a"}]}}}`,
+		`{"not":{"anyOf":[{"$ref":"#"}]}}`,
+	}
+	for _, test := range tests {
+		if _, err := schema.CompileJSON(test); !schema.IsCompile(err) {
+			t.Errorf("Expected CompileError for %s, got %v", test, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func TestSliceBCDPositive(t *testing.T) {
+	// 12345 * 10^-2, mantissa length 3
+	slice := velocypack.Slice{0xc8, 0x03, 0xfe, 0xff, 0xff, 0xff, 0x01, 0x23, 0x45}
+	assertEqualFromReader(t, slice)
+
+	ASSERT_EQ(velocypack.BCD, slice.Type(), t)
+	ASSERT_TRUE(slice.IsBCD(), t)
+	ASSERT_EQ(velocypack.ValueLength(len(slice)), mustLength(slice.ByteSize()), t)
+	d, err := slice.GetBCD()
+	ASSERT_NIL(err, t)
+	ASSERT_EQ("123.45", d.String(), t)
+	ASSERT_EQ("123.45", mustString(slice.JSONString()), t)
+	ASSERT_NIL(velocypack.Validate(slice, velocypack.ValidatorOptions{}), t)
+}
+
+func TestSliceBCDNegative(t *testing.T) {
+	// -7 * 10^3, mantissa length 1, 2 byte length
+	slice := velocypack.Slice{0xd1, 0x01, 0x00, 0x03, 0x00, 0x00, 0x00, 0x07}
+	assertEqualFromReader(t, slice)
+
+	ASSERT_EQ(velocypack.BCD, slice.Type(), t)
+	ASSERT_EQ(velocypack.ValueLength(len(slice)), mustLength(slice.ByteSize()), t)
+	d, err := slice.GetBCD()
+	ASSERT_NIL(err, t)
+	ASSERT_EQ("-7E+3", d.String(), t)
+	ASSERT_NIL(velocypack.Validate(slice, velocypack.ValidatorOptions{}), t)
+}
+
+func TestSliceBCDInvalidDigit(t *testing.T) {
+	slice := velocypack.Slice{0xc8, 0x01, 0x00, 0x00, 0x00, 0x00, 0x1a}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsInvalidBCD, t)(slice.GetBCD())
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(slice, velocypack.ValidatorOptions{}))
+}
+
+func TestBuilderBCD(t *testing.T) {
+	tests := []string{
+		"0", "1", "-1", "123.45", "-0.001", "1E+10", "1.5E-10", "98765432109876543210.0123456789",
+		"-9.8765432109876543210987654321E+128",
+	}
+	for _, test := range tests {
+		d, err := velocypack.ParseDecimal(test)
+		ASSERT_NIL(err, t)
+		var b velocypack.Builder
+		must(b.AddValue(velocypack.NewBCDValue(d)))
+		s := mustSlice(b.Slice())
+		assertEqualFromReader(t, s)
+		ASSERT_TRUE(s.IsBCD(), t)
+		ASSERT_EQ(velocypack.ValueLength(len(s)), mustLength(s.ByteSize()), t)
+		ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+		d2, err := s.GetBCD()
+		ASSERT_NIL(err, t)
+		ASSERT_EQ(test, d2.String(), t)
+		ASSERT_EQ(test, mustString(s.JSONString()), t)
+	}
+}
+
+func TestBuilderBCDInArray(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenArray())
+	must(b.AddValue(velocypack.NewBCDValue(velocypack.NewDecimalFromInt64(314, -2))))
+	must(b.AddValue(velocypack.NewBCDValue(velocypack.NewDecimalFromInt64(-5, 0))))
+	must(b.Close())
+	s := mustSlice(b.Slice())
+	ASSERT_EQ("[3.14,-5]", mustString(s.JSONString()), t)
+}
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		Mantissa int64
+		Exponent int32
+		Expected string
+	}{
+		{0, 0, "0"},
+		{123, 0, "123"},
+		{-123, 0, "-123"},
+		{123, -2, "1.23"},
+		{123, -3, "0.123"},
+		{123, -5, "0.00123"},
+		{123, -10, "1.23E-8"},
+		{123, 1, "1.23E+3"},
+		{-1, 2, "-1E+2"},
+		{0, -2, "0.00"},
+	}
+	for _, test := range tests {
+		d := velocypack.NewDecimalFromInt64(test.Mantissa, test.Exponent)
+		ASSERT_EQ(test.Expected, d.String(), t)
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]string{
+		"12":        "12",
+		"-12.50":    "-12.50",
+		"+0.5":      "0.5",
+		"1e3":       "1E+3",
+		"1.25E-2":   "0.0125",
+		"123456e-3": "123.456",
+	}
+	for input, expected := range tests {
+		d, err := velocypack.ParseDecimal(input)
+		ASSERT_NIL(err, t)
+		ASSERT_EQ(expected, d.String(), t)
+	}
+	for _, input := range []string{"", "-", "abc", "1.2.3", "1e", "0x10", "."} {
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsParse, t)(velocypack.ParseDecimal(input))
+	}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsNumberOutOfRange, t)(velocypack.ParseDecimal("1e99999999999"))
+}
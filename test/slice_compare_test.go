@@ -0,0 +1,153 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func TestSliceEqualsNumbers(t *testing.T) {
+	d, err := velocypack.ParseDecimal("1")
+	ASSERT_NIL(err, t)
+	var b velocypack.Builder
+	must(b.AddValue(velocypack.NewBCDValue(d)))
+	bcd := mustSlice(b.Slice())
+
+	ones := []velocypack.Slice{
+		{0x31},                             // SmallInt
+		{0x20, 0x01},                       // Int
+		{0x28, 0x01},                       // UInt
+		{0x29, 0x01, 0x00},                 // UInt (2 bytes)
+		mustSlice(velocypack.Marshal(1.0)), // Double
+		bcd,
+	}
+	for _, x := range ones {
+		for _, y := range ones {
+			ASSERT_TRUE(mustBool(x.Equals(y)), t)
+			ASSERT_EQ(0, velocypack.Compare(x, y), t)
+		}
+	}
+	ASSERT_FALSE(mustBool(ones[0].Equals(ones[4], velocypack.EqualsOptions{StrictNumberTypes: true})), t)
+	ASSERT_FALSE(mustBool(ones[4].Equals(ones[5], velocypack.EqualsOptions{StrictNumberTypes: true})), t)
+	ASSERT_TRUE(mustBool(ones[0].Equals(ones[3], velocypack.EqualsOptions{StrictNumberTypes: true})), t)
+
+	ASSERT_FALSE(mustBool(velocypack.Slice{0x32}.Equals(ones[4])), t)
+	ASSERT_FALSE(mustBool(velocypack.Slice{0x31}.Equals(velocypack.StringSlice("1"))), t)
+}
+
+func TestSliceEqualsObjects(t *testing.T) {
+	indexed := mustSlice(velocypack.ParseJSONFromString(`{"b":1,"a":[1,{"x":"y"}],"c":null}`))
+	compact := mustSlice(velocypack.ParseJSONFromString(`{"c":null,"a":[1,{"x":"y"}],"b":1.0}`, velocypack.ParserOptions{BuildUnindexedObjects: true, BuildUnindexedArrays: true}))
+	other := mustSlice(velocypack.ParseJSONFromString(`{"b":1,"a":[1,{"x":"z"}],"c":null}`))
+	fewer := mustSlice(velocypack.ParseJSONFromString(`{"b":1,"a":[1,{"x":"y"}]}`))
+
+	ASSERT_TRUE(mustBool(indexed.Equals(compact)), t)
+	ASSERT_TRUE(mustBool(compact.Equals(indexed)), t)
+	ASSERT_FALSE(mustBool(indexed.Equals(other)), t)
+	ASSERT_FALSE(mustBool(indexed.Equals(fewer)), t)
+	ASSERT_FALSE(mustBool(fewer.Equals(indexed)), t)
+	ASSERT_FALSE(mustBool(indexed.Equals(compact, velocypack.EqualsOptions{StrictKeyOrder: true})), t)
+
+	same := mustSlice(velocypack.ParseJSONFromString(`{"b":1,"a":[1,{"x":"y"}],"c":null}`, velocypack.ParserOptions{BuildUnindexedObjects: true}))
+	ASSERT_TRUE(mustBool(indexed.Equals(same, velocypack.EqualsOptions{StrictKeyOrder: true})), t)
+}
+
+func TestSliceEqualsTags(t *testing.T) {
+	var b velocypack.Builder
+	must(b.AddTagged(42, velocypack.NewStringValue("foo")))
+	tagged := mustSlice(b.Slice())
+	plain := velocypack.StringSlice("foo")
+
+	ASSERT_TRUE(mustBool(tagged.Equals(tagged)), t)
+	ASSERT_FALSE(mustBool(tagged.Equals(plain)), t)
+	ASSERT_TRUE(mustBool(tagged.Equals(plain, velocypack.EqualsOptions{IgnoreTags: true})), t)
+	ASSERT_EQ(0, velocypack.Compare(tagged, plain), t)
+}
+
+func TestCompareOrder(t *testing.T) {
+	inputs := []string{
+		`null`, `false`, `true`, `-1.5`, `0`, `1`, `2.5`, `100`, `""`, `"a"`, `"abc"`, `"b"`,
+		`[]`, `[false,1]`, `[false,""]`, `[0]`, `[1]`, `[1,2]`, `[2]`,
+		`{}`, `{"b":1}`, `{"a":0}`, `{"a":1}`, `{"a":1,"b":1}`,
+	}
+	var sorted []velocypack.Slice
+	var b velocypack.Builder
+	must(b.AddValue(velocypack.NewMaxKeyValue()))
+	sorted = append(sorted, mustSlice(b.Slice()))
+	for i := len(inputs) - 1; i >= 0; i-- {
+		sorted = append(sorted, mustSlice(velocypack.ParseJSONFromString(inputs[i])))
+	}
+	b.Clear()
+	must(b.AddValue(velocypack.NewMinKeyValue()))
+	sorted = append(sorted, mustSlice(b.Slice()))
+
+	sort.SliceStable(sorted, func(i, j int) bool { return velocypack.Compare(sorted[i], sorted[j]) < 0 })
+
+	ASSERT_EQ(velocypack.MinKey, sorted[0].Type(), t)
+	ASSERT_EQ(velocypack.MaxKey, sorted[len(sorted)-1].Type(), t)
+	for i, input := range inputs {
+		ASSERT_EQ(input, mustString(sorted[i+1].JSONString()), t)
+	}
+	// Missing members & attributes are treated as null
+	ASSERT_EQ(0, velocypack.Compare(mustSlice(velocypack.ParseJSONFromString(`[]`)), mustSlice(velocypack.ParseJSONFromString(`[null]`))), t)
+	ASSERT_EQ(0, velocypack.Compare(mustSlice(velocypack.ParseJSONFromString(`{"a":null}`)), mustSlice(velocypack.ParseJSONFromString(`{}`))), t)
+}
+
+func TestCompareNumbers(t *testing.T) {
+	tests := []struct {
+		A, B     interface{}
+		Expected int
+	}{
+		{uint64(math.MaxUint64), int64(math.MaxInt64), 1},
+		{int64(math.MinInt64), uint64(math.MaxUint64), -1},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64 - 1), 1},
+		{int64(-5), -4.5, -1},
+		{uint64(1 << 60), float64(1 << 60), 0},
+		{math.Inf(1), uint64(math.MaxUint64), 1},
+		{math.Inf(-1), int64(math.MinInt64), -1},
+		{math.NaN(), math.Inf(-1), -1},
+		{math.NaN(), math.NaN(), 0},
+	}
+	for _, test := range tests {
+		a := mustSlice(velocypack.Marshal(test.A))
+		b := mustSlice(velocypack.Marshal(test.B))
+		ASSERT_EQ(test.Expected, velocypack.Compare(a, b), t)
+		ASSERT_EQ(-test.Expected, velocypack.Compare(b, a), t)
+	}
+}
+
+func TestSliceCompareInvalid(t *testing.T) {
+	one := mustSlice(velocypack.Marshal(1))
+	c, err := one.Compare(mustSlice(velocypack.Marshal(2)))
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(-1, c, t)
+
+	// BCD value with an invalid digit
+	invalid := velocypack.Slice{0xc8, 0x01, 0x00, 0x00, 0x00, 0x00, 0xff}
+	_, err = one.Compare(invalid)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsInvalidBCD, t)(err)
+}
@@ -23,6 +23,10 @@
 package test
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
 	"testing"
 
 	velocypack "github.com/arangodb/go-velocypack"
@@ -56,3 +60,163 @@ func TestSliceCustomTypeByteSize(t *testing.T) {
 		}
 	}
 }
+
+func TestSliceCustom(t *testing.T) {
+	tests := []struct {
+		Head     byte
+		Payload  []byte
+		Expected velocypack.Slice
+	}{
+		{0xf0, []byte{0x01}, velocypack.Slice{0xf0, 0x01}},
+		{0xf1, []byte{0x01, 0x02}, velocypack.Slice{0xf1, 0x01, 0x02}},
+		{0xf2, []byte{0x01, 0x02, 0x03, 0x04}, velocypack.Slice{0xf2, 0x01, 0x02, 0x03, 0x04}},
+		{0xf3, []byte{1, 2, 3, 4, 5, 6, 7, 8}, velocypack.Slice{0xf3, 1, 2, 3, 4, 5, 6, 7, 8}},
+		{0xf4, []byte{0xaa}, velocypack.Slice{0xf4, 0x01, 0xaa}},
+		{0xf5, []byte{}, velocypack.Slice{0xf5, 0x00}},
+		{0xf8, []byte{0xaa, 0xbb}, velocypack.Slice{0xf8, 0x02, 0x00, 0xaa, 0xbb}},
+		{0xfb, []byte{0xaa}, velocypack.Slice{0xfb, 0x01, 0x00, 0x00, 0x00, 0xaa}},
+		{0xff, []byte{0xaa}, velocypack.Slice{0xff, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xaa}},
+	}
+	for _, test := range tests {
+		var b velocypack.Builder
+		must(b.AddCustom(test.Head, test.Payload))
+		s := mustSlice(b.Slice())
+		ASSERT_EQ(test.Expected, s, t)
+		ASSERT_EQ(velocypack.Custom, s.Type(), t)
+		ASSERT_TRUE(s.IsCustom(), t)
+		ASSERT_EQ(velocypack.ValueLength(len(test.Expected)), mustLength(s.ByteSize()), t)
+		ASSERT_EQ(test.Payload, mustBytes(s.GetCustomPayload()), t)
+
+		// Read back from reader
+		r, err := velocypack.SliceFromReader(bytes.NewReader(s))
+		ASSERT_NIL(err, t)
+		ASSERT_EQ(s, r, t)
+		r, err = velocypack.SliceFromReader(bufio.NewReader(bytes.NewReader(s)))
+		ASSERT_NIL(err, t)
+		ASSERT_EQ(s, r, t)
+	}
+}
+
+func TestSliceCustomInArray(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenArray())
+	must(b.AddCustom(0xf4, []byte("abc")))
+	must(b.AddValue(velocypack.NewIntValue(7)))
+	must(b.Close())
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(velocypack.ValueLength(2), mustLength(s.Length()), t)
+	ASSERT_EQ([]byte("abc"), mustBytes(mustSlice(s.At(0)).GetCustomPayload()), t)
+	ASSERT_EQ(int64(7), mustInt(mustSlice(s.At(1)).GetInt()), t)
+}
+
+func TestSliceCustomInvalid(t *testing.T) {
+	var b velocypack.Builder
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsBuilderUnexpectedType, t)(b.AddCustom(0xef, []byte{0x01}))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsBuilderUnexpectedType, t)(b.AddCustom(0xf0, []byte{0x01, 0x02}))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsBuilderUnexpectedType, t)(b.AddCustom(0xf3, nil))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsBuilderUnexpectedType, t)(b.AddCustom(0xf4, make([]byte, 256)))
+	ASSERT_TRUE(b.IsEmpty(), t)
+
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsInvalidType, t)(velocypack.NullSlice().GetCustomPayload())
+}
+
+// idDocument builds an ArangoDB style document with an `_id` custom value.
+func idDocument(collectionID uint64, key string) velocypack.Slice {
+	id := make([]byte, 8)
+	binary.LittleEndian.PutUint64(id, collectionID)
+	var b velocypack.Builder
+	must(b.OpenObject())
+	must(b.AddValue(velocypack.NewStringValue("_id")))
+	must(b.AddCustom(0xf3, id))
+	must(b.AddKeyValue("_key", velocypack.NewStringValue(key)))
+	must(b.AddKeyValue("name", velocypack.NewStringValue("foo")))
+	must(b.Close())
+	return mustSlice(b.Slice())
+}
+
+func collectionName(id uint64) (string, error) {
+	if id == 42 {
+		return "users", nil
+	}
+	return "", fmt.Errorf("unknown collection %d", id)
+}
+
+// hexCustomTypeConverter converts custom values into a hex string of their payload,
+// prefixed with the length of the array or object that contains it.
+type hexCustomTypeConverter struct{}
+
+func (hexCustomTypeConverter) ToString(value velocypack.Slice, base velocypack.Slice) (string, error) {
+	prefix := "-"
+	if base != nil {
+		prefix = fmt.Sprintf("%d", mustLength(base.Length()))
+	}
+	payload, err := value.GetCustomPayload()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%x", prefix, payload), nil
+}
+
+func TestSliceCustomDecode(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenArray())
+	must(b.AddCustom(0xf4, []byte{0xab, 0xcd}))
+	must(b.AddCustom(0xf0, []byte{0x01}))
+	must(b.AddValue(velocypack.NewIntValue(7)))
+	must(b.Close())
+	s := mustSlice(b.Slice())
+
+	d := velocypack.NewDecoder(bytes.NewReader(s))
+	d.SetCustomTypeHandler(hexCustomTypeConverter{})
+	var v []interface{}
+	must(d.Decode(&v))
+	ASSERT_EQ([]interface{}{"3:abcd", "3:01", 7}, v, t)
+
+	d = velocypack.NewDecoder(bytes.NewReader(mustSlice(s.At(0))))
+	d.SetCustomTypeHandler(hexCustomTypeConverter{})
+	var str string
+	must(d.Decode(&str))
+	ASSERT_EQ("-:abcd", str, t)
+}
+
+func TestIDCustomTypeHandlerDump(t *testing.T) {
+	s := idDocument(42, "abc")
+	h := velocypack.IDCustomTypeHandler{CollectionName: collectionName}
+
+	ASSERT_EQ(`{"_id":"users/abc","_key":"abc","name":"foo"}`, mustString(s.JSONString(velocypack.DumperOptions{CustomTypeHandler: h})), t)
+	ASSERT_EQ(`{"_id":null,"_key":"abc","name":"foo"}`, mustString(s.JSONString()), t)
+
+	_, err := idDocument(7, "abc").JSONString(velocypack.DumperOptions{CustomTypeHandler: h})
+	ASSERT_FALSE(err == nil, t)
+
+	// _id outside of an object
+	id := mustSlice(s.Get("_id"))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsInvalidType, t)(id.JSONString(velocypack.DumperOptions{CustomTypeHandler: h}))
+}
+
+func TestIDCustomTypeHandlerDecode(t *testing.T) {
+	s := idDocument(42, "abc")
+
+	type document struct {
+		ID   string `json:"_id"`
+		Key  string `json:"_key"`
+		Name string `json:"name"`
+	}
+	d := velocypack.NewDecoder(bytes.NewReader(s))
+	d.SetCustomTypeHandler(velocypack.IDCustomTypeHandler{CollectionName: collectionName})
+	var v document
+	must(d.Decode(&v))
+	ASSERT_EQ(document{ID: "users/abc", Key: "abc", Name: "foo"}, v, t)
+
+	d = velocypack.NewDecoder(bytes.NewReader(s))
+	d.SetCustomTypeHandler(velocypack.IDCustomTypeHandler{CollectionName: collectionName})
+	var m interface{}
+	must(d.Decode(&m))
+	ASSERT_EQ(map[string]interface{}{"_id": "users/abc", "_key": "abc", "name": "foo"}, m, t)
+
+	// Without handler, custom values are ignored
+	v = document{}
+	must(velocypack.Unmarshal(s, &v))
+	ASSERT_EQ(document{Key: "abc", Name: "foo"}, v, t)
+}
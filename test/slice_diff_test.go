@@ -0,0 +1,167 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// TestDiff checks the changes reported by Diff.
+func TestDiff(t *testing.T) {
+	a := mustSlice(velocypack.ParseJSONFromString(`{"a":1,"b":{"c":[1,2,3],"d":"x"},"e":true,"f":[1]}`))
+	b := mustSlice(velocypack.ParseJSONFromString(`{"a":1.0,"b":{"c":[1,5],"d":"x","n":null},"f":[1,{"g":2},3],"h":"new"}`))
+	changes, err := velocypack.Diff(a, b)
+	ASSERT_NIL(err, t)
+
+	expected := []struct {
+		Type     velocypack.ChangeType
+		Pointer  string
+		OldValue string
+		NewValue string
+	}{
+		{velocypack.ChangeModified, "/b/c/1", `2`, `5`},
+		{velocypack.ChangeRemoved, "/b/c/2", `3`, ``},
+		{velocypack.ChangeAdded, "/b/n", ``, `null`},
+		{velocypack.ChangeRemoved, "/e", `true`, ``},
+		{velocypack.ChangeAdded, "/f/1", ``, `{"g":2}`},
+		{velocypack.ChangeAdded, "/f/2", ``, `3`},
+		{velocypack.ChangeAdded, "/h", ``, `"new"`},
+	}
+	ASSERT_EQ(len(expected), len(changes), t)
+	for i, x := range expected {
+		c := changes[i]
+		ASSERT_EQ(x.Type, c.Type, t)
+		ASSERT_EQ(x.Pointer, c.Pointer(), t)
+		if x.OldValue == "" {
+			ASSERT_TRUE(c.OldValue.IsNone(), t)
+		} else {
+			ASSERT_EQ(x.OldValue, mustString(c.OldValue.JSONString()), t)
+		}
+		if x.NewValue == "" {
+			ASSERT_TRUE(c.NewValue.IsNone(), t)
+		} else {
+			ASSERT_EQ(x.NewValue, mustString(c.NewValue.JSONString()), t)
+		}
+	}
+	ASSERT_EQ("modified", velocypack.ChangeModified.String(), t)
+	ASSERT_EQ([]string{"b", "c", "1"}, changes[0].Path, t)
+}
+
+// TestDiffEqual checks Diff on equal values stored in different ways.
+func TestDiffEqual(t *testing.T) {
+	indexed := mustSlice(velocypack.ParseJSONFromString(`{"x":{"b":2,"a":1},"y":[1,2]}`))
+
+	var b velocypack.Builder
+	must(b.OpenObject(true))
+	must(b.AddValue(velocypack.NewStringValue("y")))
+	must(b.OpenArray(true))
+	must(b.AddValue(velocypack.NewDoubleValue(1)))
+	must(b.AddValue(velocypack.NewIntValue(2)))
+	must(b.Close())
+	must(b.AddValue(velocypack.NewStringValue("x")))
+	must(b.OpenObject(true))
+	must(b.AddKeyValue("a", velocypack.NewIntValue(1)))
+	must(b.AddKeyValue("b", velocypack.NewIntValue(2)))
+	must(b.Close())
+	must(b.Close())
+	compact := mustSlice(b.Slice())
+	ASSERT_EQ(byte(0x14), compact[0], t)
+
+	changes, err := velocypack.Diff(indexed, compact)
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(0, len(changes), t)
+
+	changes, err = velocypack.Diff(velocypack.NullSlice(), velocypack.NullSlice())
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(0, len(changes), t)
+
+	changes, err = velocypack.Diff(velocypack.NullSlice(), indexed)
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(1, len(changes), t)
+	ASSERT_EQ("", changes[0].Pointer(), t)
+	ASSERT_EQ(velocypack.ChangeModified, changes[0].Type, t)
+}
+
+// TestDiffPatch checks that the JSON Patch created by DiffPatch turns a into b.
+func TestDiffPatch(t *testing.T) {
+	tests := []struct {
+		A, B     string
+		Expected string
+	}{
+		{`{"a":1}`, `{"a":1}`, `[]`},
+		{`{"a":1}`, `{"a":2}`, `[{"op":"replace","path":"/a","value":2}]`},
+		{`{"a/b":[1,2,3]}`, `{"a/b":[1]}`, `[{"op":"remove","path":"/a~1b/2"},{"op":"remove","path":"/a~1b/1"}]`},
+		{`{"a":1,"~":{}}`, `{"~":{"x":[]}}`, `[{"op":"remove","path":"/a"},{"op":"add","path":"/~0/x","value":[]}]`},
+		{`[1,{"a":1}]`, `[2,{"a":1},3]`, `[{"op":"replace","path":"/0","value":2},{"op":"add","path":"/2","value":3}]`},
+		{`1`, `"x"`, `[{"op":"replace","path":"","value":"x"}]`},
+	}
+	for i, test := range tests {
+		a := mustSlice(velocypack.ParseJSONFromString(test.A))
+		b := mustSlice(velocypack.ParseJSONFromString(test.B))
+		patch, err := velocypack.DiffPatch(a, b)
+		if err != nil {
+			t.Errorf("DiffPatch %d failed: %v", i, err)
+			continue
+		}
+		ASSERT_EQ(test.Expected, mustString(patch.JSONString()), t)
+		result, err := velocypack.ApplyPatch(a, patch)
+		if err != nil {
+			t.Errorf("ApplyPatch %d failed: %v", i, err)
+			continue
+		}
+		ASSERT_TRUE(mustBool(result.Equals(b)), t)
+	}
+}
+
+// TestDiffMergePatch checks that the JSON Merge Patch created by DiffMergePatch turns a into b.
+func TestDiffMergePatch(t *testing.T) {
+	tests := []struct {
+		A, B     string
+		Expected string
+	}{
+		{`{"a":1}`, `{"a":1}`, `{}`},
+		{`{"a":1,"b":2}`, `{"a":3,"c":4}`, `{"a":3,"b":null,"c":4}`},
+		{`{"a":{"b":{"c":1,"d":2}},"e":[1,2]}`, `{"a":{"b":{"c":1}},"e":[1,3]}`, `{"a":{"b":{"d":null}},"e":[1,3]}`},
+		{`{"a":{"b":1}}`, `{"a":"x"}`, `{"a":"x"}`},
+		{`[1]`, `{"a":1}`, `{"a":1}`},
+		{`{"a":1}`, `[1]`, `[1]`},
+	}
+	for i, test := range tests {
+		a := mustSlice(velocypack.ParseJSONFromString(test.A))
+		b := mustSlice(velocypack.ParseJSONFromString(test.B))
+		patch, err := velocypack.DiffMergePatch(a, b)
+		if err != nil {
+			t.Errorf("DiffMergePatch %d failed: %v", i, err)
+			continue
+		}
+		ASSERT_EQ(test.Expected, mustString(patch.JSONString()), t)
+		result, err := velocypack.DeepMerge(a, patch)
+		if err != nil {
+			t.Errorf("DeepMerge %d failed: %v", i, err)
+			continue
+		}
+		ASSERT_TRUE(mustBool(result.Equals(b)), t)
+	}
+}
@@ -0,0 +1,95 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"math"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func TestSliceHashEqualValues(t *testing.T) {
+	d, err := velocypack.ParseDecimal("1")
+	ASSERT_NIL(err, t)
+	var b velocypack.Builder
+	must(b.AddValue(velocypack.NewBCDValue(d)))
+	bcd := mustSlice(b.Slice())
+
+	groups := [][]velocypack.Slice{
+		{velocypack.Slice{0x31}, velocypack.Slice{0x20, 0x01}, velocypack.Slice{0x29, 0x01, 0x00}, mustSlice(velocypack.Marshal(1.0)), bcd},
+		{mustSlice(velocypack.Marshal(0.0)), mustSlice(velocypack.Marshal(math.Copysign(0, -1))), velocypack.Slice{0x30}},
+		{
+			mustSlice(velocypack.ParseJSONFromString(`{"a":1,"b":[1,2,{"c":"x"}],"d":null}`)),
+			mustSlice(velocypack.ParseJSONFromString(`{"d":null,"b":[1.0,2,{"c":"x"}],"a":1}`, velocypack.ParserOptions{BuildUnindexedObjects: true})),
+			mustSlice(velocypack.ParseJSONFromString(`{"b":[1,2,{"c":"x"}],"a":1,"d":null}`, velocypack.ParserOptions{BuildUnindexedArrays: true})),
+		},
+	}
+	for _, group := range groups {
+		for _, x := range group {
+			for _, y := range group {
+				ASSERT_TRUE(mustBool(x.Equals(y)), t)
+				ASSERT_EQ(mustUInt(x.NormalizedHash()), mustUInt(y.NormalizedHash()), t)
+				ASSERT_EQ(mustUInt(x.Hash64(17)), mustUInt(y.Hash64(17)), t)
+				ASSERT_EQ(mustUInt(x.HashFNV64(17)), mustUInt(y.HashFNV64(17)), t)
+			}
+		}
+	}
+}
+
+func TestSliceHashDifferentValues(t *testing.T) {
+	inputs := []string{
+		`null`, `false`, `true`, `0`, `1`, `1.5`, `""`, `"1"`, `"a"`, `"b"`,
+		`[]`, `[1]`, `[1,2]`, `[2,1]`, `[[1],2]`, `[1,[2]]`,
+		`{}`, `{"a":1}`, `{"a":2}`, `{"b":1}`, `{"a":1,"b":2}`, `{"a":2,"b":1}`, `{"a":{"b":1}}`,
+	}
+	seen := make(map[uint64]string)
+	seenFNV := make(map[uint64]string)
+	for _, input := range inputs {
+		s := mustSlice(velocypack.ParseJSONFromString(input))
+		h := mustUInt(s.NormalizedHash())
+		if other, found := seen[h]; found {
+			t.Errorf("Hash of %s equals hash of %s", input, other)
+		}
+		seen[h] = input
+		h = mustUInt(s.HashFNV64(velocypack.DefaultHashSeed))
+		if other, found := seenFNV[h]; found {
+			t.Errorf("FNV hash of %s equals hash of %s", input, other)
+		}
+		seenFNV[h] = input
+	}
+}
+
+func TestSliceHashSeed(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"a":[1,2,3]}`))
+	ASSERT_EQ(mustUInt(s.Hash64(velocypack.DefaultHashSeed)), mustUInt(s.NormalizedHash()), t)
+	ASSERT_FALSE(mustUInt(s.Hash64(1)) == mustUInt(s.Hash64(2)), t)
+	ASSERT_FALSE(mustUInt(s.HashFNV64(1)) == mustUInt(s.HashFNV64(2)), t)
+}
+
+func TestSliceHashTags(t *testing.T) {
+	var b velocypack.Builder
+	must(b.AddTagged(42, velocypack.NewStringValue("foo")))
+	tagged := mustSlice(b.Slice())
+	ASSERT_FALSE(mustUInt(tagged.NormalizedHash()) == mustUInt(velocypack.StringSlice("foo").NormalizedHash()), t)
+}
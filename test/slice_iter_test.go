@@ -0,0 +1,229 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// iterTestArrays returns the same array stored indexed, unindexed and compact.
+func iterTestArrays(t *testing.T) map[string]velocypack.Slice {
+	result := map[string]velocypack.Slice{
+		"indexed": mustSlice(velocypack.ParseJSONFromString(`[1,"a",[true],{"x":null}]`)),
+	}
+	for name, unindexed := range map[string]bool{"unindexed": true, "compact": false} {
+		var b velocypack.Builder
+		b.BuildUnindexedArrays = unindexed
+		must(b.OpenArray(!unindexed))
+		must(b.AddValue(velocypack.NewIntValue(1)))
+		must(b.AddValue(velocypack.NewStringValue("a")))
+		must(b.OpenArray())
+		must(b.AddValue(velocypack.NewBoolValue(true)))
+		must(b.Close())
+		must(b.OpenObject())
+		must(b.AddKeyValue("x", velocypack.NewNullValue()))
+		must(b.Close())
+		must(b.Close())
+		result[name] = mustSlice(b.Slice())
+	}
+	return result
+}
+
+// TestSliceValuesArray checks Slice.Values on arrays.
+func TestSliceValuesArray(t *testing.T) {
+	for name, s := range iterTestArrays(t) {
+		var values []string
+		for v, err := range s.Values() {
+			ASSERT_NIL(err, t)
+			values = append(values, mustString(v.JSONString()))
+			// Values are trimmed to their own size
+			ASSERT_EQ(mustLength(v.ByteSize()), velocypack.ValueLength(len(v)), t)
+		}
+		if strings.Join(values, ",") != `1,"a",[true],{"x":null}` {
+			t.Errorf("Unexpected values for %s array: %v", name, values)
+		}
+	}
+
+	// Stop early
+	count := 0
+	for range mustSlice(velocypack.ParseJSONFromString(`[1,2,3]`)).Values() {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	ASSERT_EQ(2, count, t)
+
+	// Empty
+	for range velocypack.EmptyArraySlice().Values() {
+		t.Error("Expected no values")
+	}
+}
+
+// TestSliceValuesObject checks Slice.Values & Slice.Entries on objects.
+func TestSliceValuesObject(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenObject(true))
+	must(b.AddKeyValue("b", velocypack.NewIntValue(1)))
+	must(b.AddKeyValue("a", velocypack.NewStringValue("x")))
+	must(b.Close())
+	compact := mustSlice(b.Slice())
+
+	for _, s := range []velocypack.Slice{
+		mustSlice(velocypack.ParseJSONFromString(`{"b":1,"a":"x"}`)),
+		compact,
+	} {
+		var values []string
+		for v, err := range s.Values() {
+			ASSERT_NIL(err, t)
+			values = append(values, mustString(v.JSONString()))
+		}
+		ASSERT_EQ([]string{`1`, `"x"`}, values, t)
+
+		var entries []string
+		for k, v := range s.Entries() {
+			entries = append(entries, mustString(k.GetString())+"="+mustString(v.JSONString()))
+		}
+		ASSERT_EQ([]string{`b=1`, `a="x"`}, entries, t)
+	}
+
+	// Integer keys are translated
+	var tb velocypack.Builder
+	tb.AttributeTranslator = velocypack.ArangoAttributeTranslator
+	must(tb.OpenObject())
+	must(tb.AddKeyValue("_key", velocypack.NewStringValue("k")))
+	must(tb.AddKeyValue("name", velocypack.NewStringValue("n")))
+	must(tb.Close())
+	var keys []string
+	for k := range mustSlice(tb.Slice()).Entries() {
+		keys = append(keys, mustString(k.GetString()))
+	}
+	ASSERT_EQ([]string{"_key", "name"}, keys, t)
+
+	// Entries of a non-object yield nothing
+	for range velocypack.NullSlice().Entries() {
+		t.Error("Expected no entries")
+	}
+}
+
+// TestSliceValuesInvalid checks Slice.Values on non-containers.
+func TestSliceValuesInvalid(t *testing.T) {
+	count := 0
+	for v, err := range velocypack.NullSlice().Values() {
+		ASSERT_TRUE(velocypack.IsInvalidType(err), t)
+		ASSERT_TRUE(v == nil, t)
+		count++
+	}
+	ASSERT_EQ(1, count, t)
+}
+
+// TestSliceIteratorsAllocations checks that Values & Entries do not allocate.
+func TestSliceIteratorsAllocations(t *testing.T) {
+	arrays := iterTestArrays(t)
+	object := mustSlice(velocypack.ParseJSONFromString(`{"a":1,"b":[2],"c":"x"}`))
+	for name, s := range map[string]velocypack.Slice{"indexed": arrays["indexed"], "compact": arrays["compact"], "object": object} {
+		allocs := testing.AllocsPerRun(100, func() {
+			for v, err := range s.Values() {
+				if err != nil || v.IsNone() {
+					t.Fatal("unexpected value")
+				}
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("Expected no allocations for %s, got %v", name, allocs)
+		}
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		for k, v := range object.Entries() {
+			if k.IsNone() || v.IsNone() {
+				t.Fatal("unexpected entry")
+			}
+		}
+	})
+	ASSERT_EQ(float64(0), allocs, t)
+}
+
+// TestWalk checks Walk.
+func TestWalk(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"a":[1,{"b":2}],"c":{"d":[]},"e":"x"}`))
+	var visited []string
+	err := velocypack.Walk(s, func(path []velocypack.PathElement, value velocypack.Slice) error {
+		var parts []string
+		for _, p := range path {
+			if p.Key != "" {
+				parts = append(parts, p.Key)
+			} else {
+				parts = append(parts, fmt.Sprintf("[%d]", p.Index))
+			}
+		}
+		visited = append(visited, strings.Join(parts, ".")+"="+value.Type().String())
+		return nil
+	})
+	ASSERT_NIL(err, t)
+	ASSERT_EQ([]string{
+		"=Object",
+		"a=Array",
+		"a.[0]=SmallInt",
+		"a.[1]=Object",
+		"a.[1].b=SmallInt",
+		"c=Object",
+		"c.d=Array",
+		"e=String",
+	}, visited, t)
+}
+
+// TestWalkSkipChildren checks Walk with SkipChildren and errors.
+func TestWalkSkipChildren(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`{"a":[1,2],"b":{"c":3},"d":4}`))
+	count := 0
+	err := velocypack.Walk(s, func(path []velocypack.PathElement, value velocypack.Slice) error {
+		count++
+		if len(path) == 1 && path[0].Key == "a" {
+			return velocypack.SkipChildren
+		}
+		return nil
+	})
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(5, count, t) // root, a, b, b.c, d
+
+	ASSERT_NIL(velocypack.Walk(s, func(path []velocypack.PathElement, value velocypack.Slice) error {
+		return velocypack.SkipChildren
+	}), t)
+
+	stop := errors.New("stop")
+	count = 0
+	err = velocypack.Walk(s, func(path []velocypack.PathElement, value velocypack.Slice) error {
+		count++
+		if len(path) == 2 {
+			return stop
+		}
+		return nil
+	})
+	ASSERT_EQ(stop, err, t)
+	ASSERT_EQ(3, count, t) // root, a, a[0]
+}
@@ -93,3 +93,74 @@ func TestSliceMergeNonObject(t *testing.T) {
 		t.Errorf("Expected InvalidTypeError, got %#v", err)
 	}
 }
+
+// TestDeepMerge checks DeepMerge with the examples of RFC 7396.
+func TestDeepMerge(t *testing.T) {
+	tests := []struct {
+		Base     string
+		Patch    string
+		Expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// Nested objects are merged recursively
+		{`{"a":{"b":{"c":1,"d":2}},"x":1}`, `{"a":{"b":{"d":3,"e":4}}}`, `{"a":{"b":{"c":1,"d":3,"e":4}},"x":1}`},
+	}
+	for i, test := range tests {
+		base := mustSlice(velocypack.ParseJSONFromString(test.Base))
+		patch := mustSlice(velocypack.ParseJSONFromString(test.Patch))
+		result, err := velocypack.DeepMerge(base, patch)
+		if err != nil {
+			t.Errorf("DeepMerge %d failed: %v", i, err)
+			continue
+		}
+		if output := mustString(result.JSONString()); output != test.Expected {
+			t.Errorf("Unexpected result in test %d\nExpected: %s\nGot: %s", i, test.Expected, output)
+		}
+	}
+}
+
+// TestDeepMergeOptions checks DeepMerge with various options.
+func TestDeepMergeOptions(t *testing.T) {
+	tests := []struct {
+		Base     string
+		Patch    string
+		Options  velocypack.MergeOptions
+		Expected string
+	}{
+		{`{"a":1,"b":{"c":1}}`, `{"a":null,"b":{"c":null}}`, velocypack.MergeOptions{KeepNull: true}, `{"a":null,"b":{"c":null}}`},
+		{`{"a":1}`, `{"b":{"c":null}}`, velocypack.MergeOptions{KeepNull: true}, `{"a":1,"b":{"c":null}}`},
+		{`{"a":1,"b":{"c":1}}`, `{"a":2,"b":{"c":2,"d":2},"e":2}`, velocypack.MergeOptions{FirstWins: true}, `{"a":1,"b":{"c":1,"d":2},"e":2}`},
+		{`{"a":1}`, `{"a":null,"b":null}`, velocypack.MergeOptions{FirstWins: true}, `{"a":1}`},
+		{`{"a":[1,2]}`, `{"a":[3]}`, velocypack.MergeOptions{FirstWins: true}, `{"a":[1,2]}`},
+		{`{"a":[1,2]}`, `{"a":[2,3]}`, velocypack.MergeOptions{ArrayStrategy: velocypack.ArrayMergeConcat}, `{"a":[1,2,2,3]}`},
+		{`{"a":[1,2]}`, `{"a":[2.0,3,3,{"x":1}]}`, velocypack.MergeOptions{ArrayStrategy: velocypack.ArrayMergeUnion}, `{"a":[1,2,3,{"x":1}]}`},
+		{`{"a":[1,2]}`, `{"a":"x"}`, velocypack.MergeOptions{ArrayStrategy: velocypack.ArrayMergeConcat}, `{"a":"x"}`},
+		{`[1]`, `[2]`, velocypack.MergeOptions{ArrayStrategy: velocypack.ArrayMergeConcat}, `[1,2]`},
+	}
+	for i, test := range tests {
+		base := mustSlice(velocypack.ParseJSONFromString(test.Base))
+		patch := mustSlice(velocypack.ParseJSONFromString(test.Patch))
+		result, err := velocypack.DeepMerge(base, patch, test.Options)
+		if err != nil {
+			t.Errorf("DeepMerge %d failed: %v", i, err)
+			continue
+		}
+		if output := mustString(result.JSONString()); output != test.Expected {
+			t.Errorf("Unexpected result in test %d\nExpected: %s\nGot: %s", i, test.Expected, output)
+		}
+	}
+}
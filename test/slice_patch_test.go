@@ -0,0 +1,141 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// TestApplyPatch checks ApplyPatch with the examples of RFC 6902.
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		Doc      string
+		Patch    string
+		Expected string
+	}{
+		// A.1 Adding an Object Member
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		// A.2 Adding an Array Element
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		// A.3 Removing an Object Member
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		// A.4 Removing an Array Element
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		// A.5 Replacing a Value
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		// A.6 Moving a Value
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		// A.7 Moving an Array Element
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		// A.8 Testing a Value: Success
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		// A.10 Adding a Nested Member Object
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`},
+		// A.11 Ignoring Unrecognized Elements
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"baz":"qux","foo":"bar"}`},
+		// A.14 ~ Escape Ordering
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		// A.16 Adding an Array Value
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		// Others
+		{`{"a":{"b":[1,{"c":2}]}}`, `[{"op":"replace","path":"/a/b/1/c","value":null}]`, `{"a":{"b":[1,{"c":null}]}}`},
+		{`{"a":1}`, `[{"op":"add","path":"/a","value":2}]`, `{"a":2}`},
+		{`{"a":1}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`},
+		{`{"a":1}`, `[{"op":"replace","path":"","value":true}]`, `true`},
+		{`[1,2]`, `[{"op":"add","path":"/2","value":3},{"op":"add","path":"/0","value":0}]`, `[0,1,2,3]`},
+		{`{"a":{"x":1},"b":{}}`, `[{"op":"copy","from":"/a","path":"/b/c"},{"op":"replace","path":"/a/x","value":2}]`, `{"a":{"x":2},"b":{"c":{"x":1}}}`},
+		{`{"a":{"x":1}}`, `[{"op":"move","from":"/a","path":"/a"}]`, `{"a":{"x":1}}`},
+		{`{"a":1.0}`, `[{"op":"test","path":"/a","value":1}]`, `{"a":1}`},
+		{`{"a":[{"b":1,"c":2}]}`, `[{"op":"test","path":"/a","value":[{"c":2,"b":1}]}]`, `{"a":[{"b":1,"c":2}]}`},
+		{`{"a":1}`, `[]`, `{"a":1}`},
+	}
+	for i, test := range tests {
+		doc := mustSlice(velocypack.ParseJSONFromString(test.Doc))
+		patch := mustSlice(velocypack.ParseJSONFromString(test.Patch))
+		result, err := velocypack.ApplyPatch(doc, patch)
+		if err != nil {
+			t.Errorf("ApplyPatch %d failed: %v", i, err)
+			continue
+		}
+		ASSERT_EQ(test.Expected, mustString(result.JSONString()), t)
+	}
+}
+
+// TestApplyPatchErrors checks the errors returned by ApplyPatch.
+func TestApplyPatchErrors(t *testing.T) {
+	tests := []struct {
+		Doc   string
+		Patch string
+		Is    func(error) bool
+		Index int
+		Op    string
+	}{
+		// A.9 Testing a Value: Error
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, velocypack.IsPatchTestFailed, 0, "test"},
+		// A.12 Adding to a Nonexistent Target
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, velocypack.IsPatchPathNotFound, 0, "add"},
+		// A.15 Comparing Strings and Numbers
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, velocypack.IsPatchTestFailed, 0, "test"},
+		{`{"a":1}`, `[{"op":"test","path":"/a","value":1},{"op":"remove","path":"/b"}]`, velocypack.IsPatchPathNotFound, 1, "remove"},
+		{`{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, velocypack.IsPatchPathNotFound, 0, "replace"},
+		{`[1,2]`, `[{"op":"add","path":"/3","value":1}]`, velocypack.IsPatchPathNotFound, 0, "add"},
+		{`[1,2]`, `[{"op":"remove","path":"/-"}]`, velocypack.IsPatchPathNotFound, 0, "remove"},
+		{`[1,2]`, `[{"op":"remove","path":"/01"}]`, velocypack.IsPatchPathNotFound, 0, "remove"},
+		{`[1,2]`, `[{"op":"replace","path":"/2","value":1}]`, velocypack.IsPatchPathNotFound, 0, "replace"},
+		{`{"a":1}`, `[{"op":"copy","from":"/b","path":"/c"}]`, velocypack.IsPatchPathNotFound, 0, "copy"},
+		{`{"a":1}`, `[{"op":"add","path":"/a/b","value":1}]`, velocypack.IsPatchPathNotFound, 0, "add"},
+		{`{"a":1}`, `[{"op":"add","path":"a","value":1}]`, velocypack.IsInvalidJSONPointer, 0, "add"},
+		{`{"a":1}`, `[{"op":"add","path":"/~2","value":1}]`, velocypack.IsInvalidJSONPointer, 0, "add"},
+		// A.13 Invalid JSON Patch Document (missing value)
+		{`{"a":1}`, `[{"op":"add","path":"/b"}]`, velocypack.IsInvalidPatch, 0, "add"},
+		{`{"a":1}`, `[{"op":"frobnicate","path":"/a"}]`, velocypack.IsInvalidPatch, 0, "frobnicate"},
+		{`{"a":1}`, `[{"path":"/a"}]`, velocypack.IsInvalidPatch, 0, ""},
+		{`{"a":1}`, `[{"op":"move","path":"/a"}]`, velocypack.IsInvalidPatch, 0, "move"},
+		{`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, velocypack.IsInvalidPatch, 0, "move"},
+		{`{"a":1}`, `[{"op":"remove","path":""}]`, velocypack.IsInvalidPatch, 0, "remove"},
+		{`{"a":1}`, `[1]`, velocypack.IsInvalidPatch, 0, ""},
+	}
+	for i, test := range tests {
+		doc := mustSlice(velocypack.ParseJSONFromString(test.Doc))
+		patch := mustSlice(velocypack.ParseJSONFromString(test.Patch))
+		_, err := velocypack.ApplyPatch(doc, patch)
+		if !test.Is(err) {
+			t.Errorf("ApplyPatch %d returned unexpected error: %v", i, err)
+			continue
+		}
+		pe, ok := velocypack.Cause(err).(velocypack.PatchError)
+		if !ok || !velocypack.IsPatch(err) {
+			t.Errorf("ApplyPatch %d expected PatchError, got %v", i, err)
+			continue
+		}
+		ASSERT_EQ(test.Index, pe.Index, t)
+		ASSERT_EQ(test.Op, pe.Op, t)
+	}
+
+	// Patch must be an array
+	_, err := velocypack.ApplyPatch(velocypack.NullSlice(), velocypack.EmptyObjectSlice())
+	ASSERT_TRUE(velocypack.IsInvalidType(err), t)
+}
@@ -0,0 +1,104 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+const projectTestJSON = `{"_key":"k1","name":"ann","address":{"city":"Cologne","zip":"50667","geo":{"lat":50.9,"lon":6.9}},"tags":["a","b"],"a.b":1}`
+
+// TestProject checks Project.
+func TestProject(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(projectTestJSON))
+	tests := []struct {
+		Paths    []string
+		Expected string
+	}{
+		{nil, `{}`},
+		{[]string{"name"}, `{"name":"ann"}`},
+		{[]string{"name", "_key"}, `{"_key":"k1","name":"ann"}`},
+		{[]string{"address.city", "address.geo.lat"}, `{"address":{"city":"Cologne","geo":{"lat":50.9}}}`},
+		{[]string{"address.geo.lat", "address"}, `{"address":{"city":"Cologne","geo":{"lat":50.9,"lon":6.9},"zip":"50667"}}`},
+		{[]string{"address", "address.geo.lat"}, `{"address":{"city":"Cologne","geo":{"lat":50.9,"lon":6.9},"zip":"50667"}}`},
+		{[]string{"missing", "address.missing", "name.x", "tags.x"}, `{}`},
+		{[]string{"tags"}, `{"tags":["a","b"]}`},
+		{[]string{`["a.b"]`}, `{"a.b":1}`},
+	}
+	for i, test := range tests {
+		result, err := velocypack.Project(s, test.Paths...)
+		if err != nil {
+			t.Errorf("Project %d failed: %v", i, err)
+			continue
+		}
+		ASSERT_EQ(test.Expected, mustString(result.JSONString()), t)
+	}
+}
+
+// TestProjectOrder checks that Project adds attributes in the order of the given paths.
+func TestProjectOrder(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(projectTestJSON))
+	result := mustSlice(velocypack.Project(s, "tags", "name", "_key"))
+	it := mustObjectIterator(velocypack.NewObjectIterator(result, true))
+	var keys []string
+	for it.IsValid() {
+		keys = append(keys, mustString(mustSlice(it.Key(true)).GetString()))
+		must(it.Next())
+	}
+	ASSERT_EQ([]string{"tags", "name", "_key"}, keys, t)
+}
+
+// TestOmit checks Omit.
+func TestOmit(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(projectTestJSON))
+	tests := []struct {
+		Paths    []string
+		Expected string
+	}{
+		{nil, `{"_key":"k1","a.b":1,"address":{"city":"Cologne","geo":{"lat":50.9,"lon":6.9},"zip":"50667"},"name":"ann","tags":["a","b"]}`},
+		{[]string{"address", "tags", `["a.b"]`}, `{"_key":"k1","name":"ann"}`},
+		{[]string{"address.zip", "address.geo.lon", "_key"}, `{"a.b":1,"address":{"city":"Cologne","geo":{"lat":50.9}},"name":"ann","tags":["a","b"]}`},
+		{[]string{"missing", "name.x", "address.missing"}, `{"_key":"k1","a.b":1,"address":{"city":"Cologne","geo":{"lat":50.9,"lon":6.9},"zip":"50667"},"name":"ann","tags":["a","b"]}`},
+	}
+	for i, test := range tests {
+		result, err := velocypack.Omit(s, test.Paths...)
+		if err != nil {
+			t.Errorf("Omit %d failed: %v", i, err)
+			continue
+		}
+		ASSERT_EQ(test.Expected, mustString(result.JSONString()), t)
+	}
+}
+
+// TestProjectInvalid checks Project & Omit with invalid input.
+func TestProjectInvalid(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(projectTestJSON))
+	for _, path := range []string{"", "tags[0]", "address.*", "a..b"} {
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsPathSyntax, t)(velocypack.Project(s, path))
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsPathSyntax, t)(velocypack.Omit(s, path))
+	}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsInvalidType, t)(velocypack.Project(velocypack.NullSlice(), "a"))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsInvalidType, t)(velocypack.Omit(mustSlice(velocypack.ParseJSONFromString(`[1]`)), "a"))
+}
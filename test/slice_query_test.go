@@ -0,0 +1,159 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+const queryTestJSON = `{"users":[{"name":"a","address":{"city":"Cologne"}},{"name":"b"},{"name":"c","address":{"city":"Berlin"}},{"name":"d","address":{"city":"Paris"}}],` +
+	`"items":[{"price":1},{"price":2.5},{"id":3},{"price":"4"}],"tags":["x","y","z"],"a.b":{"c]":true},"empty":[],"num":7}`
+
+// TestSliceQuery checks Slice.Query with a variety of paths.
+func TestSliceQuery(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(queryTestJSON))
+	tests := []struct {
+		Path     string
+		Expected []string
+	}{
+		{"num", []string{`7`}},
+		{"users[3].address.city", []string{`"Paris"`}},
+		{"users[1].address.city", nil},
+		{"users[*].address.city", []string{`"Cologne"`, `"Berlin"`, `"Paris"`}},
+		{"users[*].name", []string{`"a"`, `"b"`, `"c"`, `"d"`}},
+		{"items[*].price", []string{`1`, `2.5`, `"4"`}},
+		{"tags[-1]", []string{`"z"`}},
+		{"tags[-3]", []string{`"x"`}},
+		{"tags[-4]", nil},
+		{"tags[3]", nil},
+		{"tags[0]", []string{`"x"`}},
+		{"tags.*", []string{`"x"`, `"y"`, `"z"`}},
+		{"users[0].*", []string{`"a"`, `{"city":"Cologne"}`}},
+		{`["a.b"]["c]"]`, []string{`true`}},
+		{`['a.b'].*`, []string{`true`}},
+		{"empty[*]", nil},
+		{"empty[0]", nil},
+		{"missing.x", nil},
+		{"num.x", nil},
+		{"num[0]", nil},
+		{"users.name", nil},
+		{"[0]", nil},
+	}
+	root, err := s.Query("")
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(1, len(root), t)
+	ASSERT_EQ(s, root[0], t)
+
+	for _, test := range tests {
+		result, err := s.Query(test.Path)
+		if err != nil {
+			t.Errorf("Query '%s' failed: %v", test.Path, err)
+			continue
+		}
+		var got []string
+		for _, r := range result {
+			got = append(got, mustString(r.JSONString()))
+		}
+		if strings.Join(got, ",") != strings.Join(test.Expected, ",") || len(got) != len(test.Expected) {
+			t.Errorf("Query '%s' returned unexpected result\nExpected: %v\nGot: %v", test.Path, test.Expected, got)
+		}
+	}
+}
+
+// TestSliceQueryArrayRoot checks Slice.Query on an array.
+func TestSliceQueryArrayRoot(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`[[1,2],[3,4,5]]`))
+	result, err := s.Query("[*][-1]")
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(2, len(result), t)
+	ASSERT_EQ(int64(2), mustInt(result[0].GetInt()), t)
+	ASSERT_EQ(int64(5), mustInt(result[1].GetInt()), t)
+}
+
+// TestSliceQueryZeroCopy checks that Slice.Query returns slices into the queried data.
+func TestSliceQueryZeroCopy(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(queryTestJSON))
+	result, err := s.Query("users[2].address.city")
+	ASSERT_NIL(err, t)
+	ASSERT_EQ(1, len(result), t)
+	result[0][1] = 'b'
+	user := mustSlice(mustSlice(s.Get("users")).At(2))
+	ASSERT_EQ("berlin", mustString(mustSlice(user.Get("address", "city")).GetString()), t)
+}
+
+// TestPath checks reuse of a precompiled Path.
+func TestPath(t *testing.T) {
+	p := velocypack.MustParsePath("items[*].price")
+	ASSERT_EQ("items[*].price", p.String(), t)
+
+	var result []velocypack.Slice
+	for i := 0; i < 3; i++ {
+		s := mustSlice(velocypack.ParseJSONFromString(`{"items":[{"price":1},{"price":2}]}`))
+		var err error
+		result, err = p.AppendQuery(result[:0], s)
+		ASSERT_NIL(err, t)
+		ASSERT_EQ(2, len(result), t)
+		ASSERT_EQ(uint64(2), mustUInt(result[1].GetUInt()), t)
+	}
+
+	s := mustSlice(velocypack.ParseJSONFromString(queryTestJSON))
+	first := mustSlice(p.QueryFirst(s))
+	ASSERT_EQ(uint64(1), mustUInt(first.GetUInt()), t)
+	none := mustSlice(velocypack.MustParsePath("items[9]").QueryFirst(s))
+	ASSERT_TRUE(none.IsNone(), t)
+	ASSERT_EQ(velocypack.NoneSlice(), none, t)
+	none = mustSlice(velocypack.MustParsePath("missing.key").QueryFirst(s))
+	ASSERT_EQ(velocypack.NoneSlice(), none, t)
+}
+
+// TestParsePathInvalid checks that invalid paths are rejected.
+func TestParsePathInvalid(t *testing.T) {
+	tests := []string{
+		".a",
+		"a.",
+		"a..b",
+		"a[",
+		"a[1",
+		"a[]",
+		"a[x]",
+		"a[1.5]",
+		"a[*",
+		`a["b]`,
+		`a["b"`,
+		`a["b\`,
+		"a]",
+		"a[0]b",
+	}
+	for _, test := range tests {
+		if _, err := velocypack.ParsePath(test); !velocypack.IsPathSyntax(err) {
+			t.Errorf("Expected PathSyntaxError for '%s', got %v", test, err)
+		}
+		s := mustSlice(velocypack.ParseJSONFromString(`{}`))
+		if _, err := s.Query(test); !velocypack.IsPathSyntax(err) {
+			t.Errorf("Expected PathSyntaxError from Query for '%s', got %v", test, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+func TestSliceTagged1ByteTag(t *testing.T) {
+	slice := velocypack.Slice{0xee, 0x05, 0x31}
+	assertEqualFromReader(t, slice)
+
+	ASSERT_EQ(velocypack.Tagged, slice.Type(), t)
+	ASSERT_TRUE(slice.IsTagged(), t)
+	ASSERT_EQ(velocypack.ValueLength(3), mustLength(slice.ByteSize()), t)
+	ASSERT_EQ(uint64(5), slice.GetFirstTag(), t)
+	ASSERT_EQ([]uint64{5}, slice.GetTags(), t)
+	ASSERT_TRUE(slice.HasTag(5), t)
+	ASSERT_FALSE(slice.HasTag(6), t)
+	ASSERT_EQ(velocypack.Slice{0x31}, slice.UnwrapTags(), t)
+	ASSERT_EQ(int64(1), mustInt(slice.UnwrapTags().GetInt()), t)
+}
+
+func TestSliceTagged8ByteTag(t *testing.T) {
+	slice := velocypack.Slice{0xef, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x43, 'f', 'o', 'o'}
+	assertEqualFromReader(t, slice)
+
+	ASSERT_EQ(velocypack.Tagged, slice.Type(), t)
+	ASSERT_EQ(velocypack.ValueLength(13), mustLength(slice.ByteSize()), t)
+	ASSERT_EQ(uint64(0x0807060504030201), slice.GetFirstTag(), t)
+	ASSERT_EQ("foo", mustString(slice.UnwrapTags().GetString()), t)
+}
+
+func TestSliceTaggedNested(t *testing.T) {
+	slice := velocypack.Slice{0xee, 0x01, 0xef, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xee, 0x03, 0x02, 0x04, 0x31, 0x32}
+	assertEqualFromReader(t, slice)
+
+	ASSERT_EQ(velocypack.ValueLength(17), mustLength(slice.ByteSize()), t)
+	ASSERT_EQ([]uint64{1, 256, 3}, slice.GetTags(), t)
+	ASSERT_TRUE(slice.HasTag(256), t)
+	ASSERT_EQ(velocypack.Array, slice.UnwrapTags().Type(), t)
+	ASSERT_EQ(velocypack.ValueLength(2), mustLength(slice.UnwrapTags().Length()), t)
+}
+
+func TestSliceTaggedNotTagged(t *testing.T) {
+	slice := velocypack.Slice{0x31}
+	ASSERT_FALSE(slice.IsTagged(), t)
+	ASSERT_EQ(uint64(0), slice.GetFirstTag(), t)
+	ASSERT_EQ(0, len(slice.GetTags()), t)
+	ASSERT_EQ(slice, slice.UnwrapTags(), t)
+}
+
+func TestBuilderTagged(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenArray())
+	must(b.AddTagged(5, velocypack.NewIntValue(1)))
+	must(b.AddTagged(1000, velocypack.NewStringValue("foo")))
+	must(b.AddTagged(0, velocypack.NewIntValue(2)))
+	must(b.AddValue(velocypack.NewTaggedValue(7, velocypack.NewTaggedValue(8, velocypack.NewNullValue()))))
+	must(b.AddTagged(9, velocypack.NewObjectValue()))
+	must(b.AddKeyValue("a", velocypack.NewTaggedValue(10, velocypack.NewBoolValue(true))))
+	must(b.Close())
+	must(b.Close())
+	s := mustSlice(b.Slice())
+
+	ASSERT_EQ(velocypack.ValueLength(5), mustLength(s.Length()), t)
+	v0 := mustSlice(s.At(0))
+	ASSERT_EQ(velocypack.ValueLength(3), mustLength(v0.ByteSize()), t)
+	ASSERT_EQ([]uint64{5}, v0.GetTags(), t)
+	v1 := mustSlice(s.At(1))
+	ASSERT_EQ([]uint64{1000}, v1.GetTags(), t)
+	ASSERT_EQ(velocypack.ValueLength(13), mustLength(v1.ByteSize()), t)
+	ASSERT_EQ("foo", mustString(v1.UnwrapTags().GetString()), t)
+	v2 := mustSlice(s.At(2))
+	ASSERT_FALSE(v2.IsTagged(), t)
+	v3 := mustSlice(s.At(3))
+	ASSERT_EQ([]uint64{7, 8}, v3.GetTags(), t)
+	ASSERT_TRUE(v3.UnwrapTags().IsNull(), t)
+	v4 := mustSlice(s.At(4))
+	ASSERT_EQ([]uint64{9}, v4.GetTags(), t)
+	a := mustSlice(v4.UnwrapTags().Get("a"))
+	ASSERT_EQ([]uint64{10}, a.GetTags(), t)
+	ASSERT_TRUE(a.UnwrapTags().IsTrue(), t)
+
+	ASSERT_EQ(`[1,"foo",2,null,{"a":true}]`, mustString(s.JSONString()), t)
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+}
+
+func TestBuilderTaggedKey(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenObject())
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsBuilderKeyMustBeString, t)(b.AddTagged(5, velocypack.NewStringValue("a")))
+}
+
+func TestBuilderTaggedInvalidValue(t *testing.T) {
+	var b velocypack.Builder
+	must(b.OpenArray())
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsBuilderUnexpectedType, t)(b.AddTagged(5, velocypack.Value{}))
+	must(b.AddValue(velocypack.NewIntValue(1)))
+	must(b.Close())
+	s := mustSlice(b.Slice())
+	ASSERT_EQ(velocypack.ValueLength(1), mustLength(s.Length()), t)
+	ASSERT_EQ(int64(1), mustInt(mustSlice(s.At(0)).GetInt()), t)
+}
+
+func TestValidateTagged(t *testing.T) {
+	valid := velocypack.Slice{0xee, 0x01, 0xef, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x31}
+	ASSERT_NIL(velocypack.Validate(valid, velocypack.ValidatorOptions{}), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(valid, velocypack.ValidatorOptions{DisallowTags: true}))
+
+	invalid := []velocypack.Slice{
+		velocypack.Slice{0xee},
+		velocypack.Slice{0xee, 0x01},
+		velocypack.Slice{0xef, 0x01, 0x00, 0x00},
+		velocypack.Slice{0xee, 0x01, 0x00},
+		velocypack.Slice{0xee, 0x01, 0x41},
+	}
+	for _, s := range invalid {
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(s, velocypack.ValidatorOptions{}))
+	}
+
+	// Tags count towards nesting depth
+	nested := velocypack.Slice{0xee, 0x01, 0xee, 0x02, 0xee, 0x03, 0x31}
+	ASSERT_NIL(velocypack.Validate(nested, velocypack.ValidatorOptions{MaxDepth: 3}), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(nested, velocypack.ValidatorOptions{MaxDepth: 2}))
+}
+
+type taggedTestID string
+
+func TestTaggedTypeRegistry(t *testing.T) {
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsTagRegistration, t)(velocypack.RegisterTaggedType(0, time.Duration(0)))
+	must(velocypack.RegisterTaggedType(200, time.Duration(0)))
+	defer velocypack.UnregisterTaggedType(200)
+	must(velocypack.RegisterTaggedType(1000, taggedTestID("")))
+	defer velocypack.UnregisterTaggedType(1000)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsTagRegistration, t)(velocypack.RegisterTaggedType(200, ""))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsTagRegistration, t)(velocypack.RegisterTaggedType(201, time.Duration(0)))
+
+	// Marshal adds tags
+	s := velocypack.Slice(mustBytes(velocypack.Marshal(time.Second)))
+	ASSERT_EQ([]uint64{200}, s.GetTags(), t)
+	ASSERT_EQ(int64(time.Second), mustInt(s.UnwrapTags().GetInt()), t)
+
+	type Struct struct {
+		D  time.Duration
+		P  *time.Duration
+		ID taggedTestID
+		I  int64
+	}
+	d := time.Minute
+	input := Struct{D: time.Hour, P: &d, ID: "abc", I: 5}
+	s = velocypack.Slice(mustBytes(velocypack.Marshal(input)))
+	ASSERT_EQ([]uint64{200}, mustSlice(s.Get("D")).GetTags(), t)
+	ASSERT_EQ([]uint64{200}, mustSlice(s.Get("P")).GetTags(), t)
+	ASSERT_EQ([]uint64{1000}, mustSlice(s.Get("ID")).GetTags(), t)
+	ASSERT_FALSE(mustSlice(s.Get("I")).IsTagged(), t)
+
+	// Unmarshal into typed fields ignores tags
+	var output Struct
+	must(velocypack.Unmarshal(s, &output))
+	ASSERT_EQ(input.D, output.D, t)
+	ASSERT_EQ(*input.P, *output.P, t)
+	ASSERT_EQ(input.ID, output.ID, t)
+	ASSERT_EQ(input.I, output.I, t)
+
+	// Unmarshal into interfaces uses the registered types
+	var m map[string]interface{}
+	must(velocypack.Unmarshal(s, &m))
+	ASSERT_EQ(time.Hour, m["D"], t)
+	ASSERT_EQ(time.Minute, m["P"], t)
+	ASSERT_EQ(taggedTestID("abc"), m["ID"], t)
+	ASSERT_EQ(5, m["I"], t)
+
+	var v interface{}
+	must(velocypack.Unmarshal(velocypack.Slice(mustBytes(velocypack.Marshal(time.Second))), &v))
+	ASSERT_EQ(time.Second, v, t)
+
+	// Unregistered tags are ignored
+	var x interface{}
+	must(velocypack.Unmarshal(velocypack.Slice{0xee, 0x05, 0x31}, &x))
+	ASSERT_EQ(1, x, t)
+
+	// Unregistering stops adding tags
+	velocypack.UnregisterTaggedType(200)
+	s = velocypack.Slice(mustBytes(velocypack.Marshal(time.Second)))
+	ASSERT_FALSE(s.IsTagged(), t)
+}
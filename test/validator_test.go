@@ -0,0 +1,187 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// validatorTestSlices returns a set of valid slices, build in various formats.
+func validatorTestSlices(t *testing.T) []velocypack.Slice {
+	jsons := []string{
+		`null`,
+		`true`,
+		`12345`,
+		`-12345`,
+		`1.5`,
+		`"foo"`,
+		`"` + strings.Repeat("x", 300) + `"`,
+		`[]`,
+		`{}`,
+		`[1,2,3]`,
+		`[1,"foo",3.5,[true,false]]`,
+		`{"a":1}`,
+		`{"b":1,"a":"foo","c":[1,2,{"d":null}]}`,
+		`{"z":{"y":{"x":[1,2,3,{"w":"v"}]}}}`,
+		`[` + strings.Repeat(`"some longer string value",`, 20) + `1]`,
+		`{"key` + strings.Repeat(`":1,"key`, 100) + `":2}`,
+	}
+	var result []velocypack.Slice
+	for _, opts := range []velocypack.ParserOptions{
+		{},
+		{BuildUnindexedArrays: true},
+		{BuildUnindexedObjects: true},
+		{BuildUnindexedArrays: true, BuildUnindexedObjects: true},
+	} {
+		for _, j := range jsons {
+			if strings.Contains(j, `"key":1,"key`) {
+				continue // duplicate keys
+			}
+			s, err := velocypack.ParseJSONFromString(j, opts)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", j, err)
+			}
+			result = append(result, s)
+		}
+	}
+	var b velocypack.Builder
+	must(b.OpenArray())
+	must(b.AddValue(velocypack.NewBinaryValue([]byte{1, 2, 3})))
+	must(b.AddValue(velocypack.NewMinKeyValue()))
+	must(b.AddValue(velocypack.NewMaxKeyValue()))
+	must(b.Close())
+	result = append(result, mustSlice(b.Slice()))
+	result = append(result, velocypack.Slice{0xf0, 0x01}, velocypack.Slice{0xf4, 0x02, 0x01, 0x02})
+	return result
+}
+
+func TestValidateValid(t *testing.T) {
+	for _, s := range validatorTestSlices(t) {
+		if err := velocypack.Validate(s, velocypack.ValidatorOptions{}); err != nil {
+			t.Errorf("Expected '%s' to be valid, got %v", s, err)
+		}
+	}
+}
+
+func TestValidateTruncated(t *testing.T) {
+	for _, s := range validatorTestSlices(t) {
+		for l := 0; l < len(s); l++ {
+			if err := velocypack.Validate(s[:l], velocypack.ValidatorOptions{}); !velocypack.IsValidation(err) {
+				t.Errorf("Expected ValidationError for '%s' truncated to %d bytes, got %v", s, l, err)
+			}
+		}
+	}
+}
+
+func TestValidateCorruptedDoesNotPanic(t *testing.T) {
+	for _, s := range validatorTestSlices(t) {
+		if len(s) > 64 {
+			continue
+		}
+		for i := range s {
+			for x := 0; x < 256; x++ {
+				c := append(velocypack.Slice{}, s...)
+				c[i] = byte(x)
+				if err := velocypack.Validate(c, velocypack.ValidatorOptions{}); err != nil && !velocypack.IsValidation(err) {
+					t.Errorf("Expected nil or ValidationError for '%s', got %v", c, err)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	tests := []struct {
+		Slice  velocypack.Slice
+		Offset velocypack.ValueLength
+	}{
+		{velocypack.Slice{0x00}, 0},                                     // None
+		{velocypack.Slice{0x15}, 0},                                     // reserved
+		{velocypack.Slice{0xd8}, 0},                                     // reserved
+		{velocypack.Slice{0x1d, 0, 0, 0, 0, 0, 0, 0, 0}, 0},             // External
+		{velocypack.Slice{0x1b, 0, 0}, 0},                               // truncated double
+		{velocypack.Slice{0x43, 'a', 'b'}, 0},                           // truncated string
+		{velocypack.Slice{0x42, 0xff, 0xfe}, 0},                         // invalid UTF-8
+		{velocypack.Slice{0x02, 0x05, 0x31}, 0},                         // byte length too large
+		{velocypack.Slice{0x02, 0x05, 0x31, 0x28, 0x10}, 3},             // members with different sizes
+		{velocypack.Slice{0x02, 0x04, 0x31, 0x00}, 3},                   // None member
+		{velocypack.Slice{0x03, 0x06, 0x00, 0x00, 0x31, 0x32}, 4},       // invalid padding
+		{velocypack.Slice{0x06, 0x06, 0x02, 0x31, 0x32, 0x00}, 0},       // index table overlaps members
+		{velocypack.Slice{0x06, 0x07, 0x02, 0x31, 0x32, 0x03, 0x03}, 6}, // invalid index table entry
+		{velocypack.Slice{0x06, 0x07, 0x00, 0x31, 0x32, 0x03, 0x04}, 0}, // zero members
+		{velocypack.Slice{0x13, 0x05, 0x31, 0x32, 0x01}, 3},             // compact with too many members
+		{velocypack.Slice{0x13, 0x04, 0x31, 0x02}, 0},                   // compact with too few members
+		{velocypack.Slice{0x14, 0x05, 0x1a, 0x31, 0x01}, 2},             // invalid key type
+		{velocypack.Slice{0xc1, 0x05, 0x00, 0x01}, 0},                   // binary length too large
+		{velocypack.Slice{0xf4, 0x05, 0x00}, 0},                         // custom length too large
+	}
+	for _, test := range tests {
+		err := velocypack.Validate(test.Slice, velocypack.ValidatorOptions{})
+		if !velocypack.IsValidation(err) {
+			t.Errorf("Expected ValidationError for '%s', got %v", test.Slice, err)
+		} else if verr := velocypack.Cause(err).(velocypack.ValidationError); verr.Offset != test.Offset {
+			t.Errorf("Expected offset %d for '%s', got %d (%s)", test.Offset, test.Slice, verr.Offset, verr.Message)
+		}
+	}
+}
+
+func TestValidateDuplicateKeys(t *testing.T) {
+	for _, opts := range []velocypack.ParserOptions{{}, {BuildUnindexedObjects: true}} {
+		s := mustSlice(velocypack.ParseJSONFromString(`{"a":1,"b":2,"a":3}`, opts))
+		ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(s, velocypack.ValidatorOptions{}))
+		ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{AllowDuplicateKeys: true}), t)
+	}
+}
+
+func TestValidateUnsortedObject(t *testing.T) {
+	// Sorted object (0x0b) with an index table that is not sorted.
+	s := velocypack.Slice{0x0b, 0x0b, 0x02, 0x41, 'a', 0x31, 0x41, 'b', 0x32, 0x06, 0x03}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(s, velocypack.ValidatorOptions{}))
+	// Same object, marked as unsorted (0x0f)
+	s[0] = 0x0f
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+}
+
+func TestValidateUTF8(t *testing.T) {
+	s := velocypack.Slice{0x42, 0xff, 0xfe}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(s, velocypack.ValidatorOptions{}))
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{SkipUTF8Check: true}), t)
+}
+
+func TestValidateMaxDepth(t *testing.T) {
+	s := mustSlice(velocypack.ParseJSONFromString(`[[[[1]]]]`))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(s, velocypack.ValidatorOptions{MaxDepth: 3}))
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{MaxDepth: 4}), t)
+
+	deep := mustSlice(velocypack.ParseJSONFromString(strings.Repeat("[", velocypack.DefaultValidatorMaxDepth+1) + strings.Repeat("]", velocypack.DefaultValidatorMaxDepth+1)))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(deep, velocypack.ValidatorOptions{}))
+}
+
+func TestValidateCustom(t *testing.T) {
+	s := velocypack.Slice{0xf5, 0x01, 0x01}
+	ASSERT_NIL(velocypack.Validate(s, velocypack.ValidatorOptions{}), t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsValidation, t)(velocypack.Validate(s, velocypack.ValidatorOptions{DisallowCustom: true}))
+}
@@ -0,0 +1,312 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// plainGenDocument has the fields of GenDocument, but none of its generated methods,
+// so it is encoded and decoded using reflection.
+type plainGenDocument GenDocument
+
+// plainGenAddress has the fields of GenAddress, but none of its generated methods.
+type plainGenAddress GenAddress
+
+func genTestDocument() GenDocument {
+	age := 42
+	ptrQuoted := uint(7)
+	return GenDocument{
+		Key:       "doc1",
+		Name:      "Jan <&>  ",
+		Age:       age,
+		Small:     -8,
+		Rune:      'x',
+		Count:     65535,
+		Byte:      255,
+		Big:       1 << 63,
+		Ratio:     0.25,
+		Score:     -1.5e100,
+		Active:    true,
+		Hidden:    true,
+		Dash:      "dash",
+		Quoted:    -12,
+		QuotedStr: "a \"quoted\" <string>",
+		QuotedF:   3.125,
+		QuotedB:   true,
+		Ptr:       &age,
+		PtrQuoted: &ptrQuoted,
+		NoTag:     "no tag",
+		A:         1,
+		B:         2,
+		Color:     "red",
+		Tags:      []string{"a", "b"},
+		Attrs:     map[string]interface{}{"x": "y", "n": 1.5},
+		Any:       []interface{}{true, "z"},
+		Address:   GenAddress{Street: "Main", Number: 12},
+		AddrPtr:   &GenAddress{Street: "Side"},
+		Created:   time.Date(2017, 10, 25, 14, 30, 5, 0, time.UTC),
+		Raw:       velocypack.RawSlice(velocypack.TrueSlice()),
+		Binary:    []byte{1, 2, 3},
+		GenEmbedded: GenEmbedded{
+			Inner: "inner",
+		},
+		Dup1:   "dup1",
+		Dup2:   "dup2",
+		Dup3:   "dup3",
+		Tagged: "tagged",
+	}
+}
+
+func TestVPackGenMarshal(t *testing.T) {
+	for _, doc := range []GenDocument{genTestDocument(), {}} {
+		generated := mustSlice(velocypack.Marshal(doc))
+		reflected := mustSlice(velocypack.Marshal(plainGenDocument(doc)))
+		ASSERT_EQ(generated, reflected, t)
+
+		generated = mustSlice(velocypack.Marshal(&doc))
+		ASSERT_EQ(generated, reflected, t)
+	}
+	for _, addr := range []GenAddress{{Street: "Main", Number: 1}, {}} {
+		ASSERT_EQ(mustSlice(velocypack.Marshal(addr)), mustSlice(velocypack.Marshal(plainGenAddress(addr))), t)
+	}
+	ASSERT_EQ(mustString(mustSlice(velocypack.Marshal(GenEmpty{})).JSONString()), `{}`, t)
+}
+
+func TestVPackGenMarshalOptions(t *testing.T) {
+	for _, options := range []velocypack.EncoderOptions{
+		{NoHTMLEscaping: true},
+		{NilAsEmpty: true},
+		{BuildUnindexedArrays: true, BuildUnindexedObjects: true},
+	} {
+		for _, doc := range []GenDocument{genTestDocument(), {}} {
+			generated := mustSlice(velocypack.MarshalWithOptions(doc, options))
+			reflected := mustSlice(velocypack.MarshalWithOptions(plainGenDocument(doc), options))
+			ASSERT_EQ(generated, reflected, t)
+		}
+	}
+
+	// Nested values count towards the maximum depth.
+	doc := GenDocument{Any: []interface{}{[]interface{}{}}}
+	_, err := velocypack.MarshalWithOptions(doc, velocypack.EncoderOptions{MaxDepth: 3})
+	ASSERT_NIL(err, t)
+	_, err = velocypack.MarshalWithOptions(doc, velocypack.EncoderOptions{MaxDepth: 2})
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnsupportedValue, t)(err)
+}
+
+func TestVPackGenMarshalNested(t *testing.T) {
+	docs := map[string]*GenDocument{"a": {Name: "a"}, "nil": nil}
+	s := mustSlice(velocypack.Marshal(docs))
+	ASSERT_TRUE(mustSlice(s.Get("nil")).IsNull(), t)
+	ASSERT_EQ(mustString(mustSlice(s.Get("a", "name")).GetString()), "a", t)
+}
+
+func TestVPackGenRoundTrip(t *testing.T) {
+	doc := genTestDocument()
+	s := mustSlice(velocypack.Marshal(doc))
+
+	var generated GenDocument
+	ASSERT_NIL(velocypack.Unmarshal(s, &generated), t)
+	var reflected plainGenDocument
+	ASSERT_NIL(velocypack.Unmarshal(s, &reflected), t)
+	ASSERT_EQ(generated, GenDocument(reflected), t)
+
+	// Fields that are not encoded are not decoded.
+	ASSERT_FALSE(generated.Hidden, t)
+	ASSERT_EQ(generated.Dup1, "", t)
+	ASSERT_EQ(generated.Name, doc.Name, t)
+	ASSERT_EQ(generated.QuotedStr, doc.QuotedStr, t)
+	ASSERT_EQ(*generated.PtrQuoted, *doc.PtrQuoted, t)
+	ASSERT_EQ(generated.Address, doc.Address, t)
+}
+
+// assertVPackGenUnmarshal decodes the given JSON into a GenDocument using the
+// generated code and using reflection and checks that the results are equal.
+func assertVPackGenUnmarshal(json string, t *testing.T) error {
+	return assertVPackGenUnmarshalWithOptions(json, velocypack.DecoderOptions{}, t)
+}
+
+// assertVPackGenUnmarshalWithOptions is like assertVPackGenUnmarshal, using the given options.
+func assertVPackGenUnmarshalWithOptions(json string, options velocypack.DecoderOptions, t *testing.T) error {
+	s := mustSlice(velocypack.ParseJSONFromString(json))
+	initial := genTestDocument()
+
+	generated := initial
+	generatedErr := velocypack.UnmarshalWithOptions(s, &generated, options)
+	direct := initial
+	directErr := direct.UnmarshalVPackWithOptions(s, options)
+	reflected := plainGenDocument(initial)
+	reflectedErr := velocypack.UnmarshalWithOptions(s, &reflected, options)
+
+	ASSERT_EQ(generated, GenDocument(reflected), t)
+	if s.IsObject() {
+		ASSERT_EQ(direct, generated, t)
+	}
+	if reflectedErr == nil {
+		ASSERT_NIL(generatedErr, t)
+		ASSERT_NIL(directErr, t)
+		return nil
+	}
+	ASSERT_TRUE(generatedErr != nil, t)
+	ASSERT_TRUE(directErr != nil, t)
+	expected := strings.Replace(reflectedErr.Error(), "plainGenDocument", "GenDocument", -1)
+	ASSERT_EQ(directErr.Error(), expected, t)
+	return directErr
+}
+
+func TestVPackGenUnmarshal(t *testing.T) {
+	ASSERT_NIL(assertVPackGenUnmarshal(`{}`, t), t)
+	ASSERT_NIL(assertVPackGenUnmarshal(`null`, t), t)
+	ASSERT_NIL(assertVPackGenUnmarshal(`{"unknown":1,"name":null,"ptr":null,"tags":null}`, t), t)
+	// Case-insensitive matching, exact matches win
+	ASSERT_NIL(assertVPackGenUnmarshal(`{"NAME":"upper","Age":3,"tagged":"lower","TAGGED":"upper","notag":"x"}`, t), t)
+	// Conversions between numeric types
+	ASSERT_NIL(assertVPackGenUnmarshal(`{"age":3.7,"count":12,"ratio":2,"score":5,"big":18446744073709551615}`, t), t)
+	ASSERT_NIL(assertVPackGenUnmarshal(`{"ptr":-5,"ptrOmit":"set","ptrQuoted":"99"}`, t), t)
+	ASSERT_NIL(assertVPackGenUnmarshal(`{"ratio":1e300,"ptrQuoted":"-1"}`, t), t)
+	// Quoted fields
+	ASSERT_NIL(assertVPackGenUnmarshal(`{"quoted":"17","quotedStr":"\"x\"","QuotedF":"1e3","quotedB":"false"}`, t), t)
+	// Other fields
+	ASSERT_NIL(assertVPackGenUnmarshal(`{"color":"blue","tags":["c"],"attrs":{"q":[1]},"any":{"a":1},"address":{"street":"S","NUMBER":4},"addrPtr":{"street":"T"},"embedded":{"Inner":"i"},"-":"d"}`, t), t)
+}
+
+func TestVPackGenUnmarshalOptions(t *testing.T) {
+	strictKeys := velocypack.DecoderOptions{DisallowUnknownFields: true, CaseSensitive: true}
+	err := assertVPackGenUnmarshalWithOptions(`{"NAME":"x","extra":1}`, strictKeys, t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnknownField, t)(err)
+	ASSERT_EQ(err.Error(), `json: unknown field "NAME" in Go struct GenDocument`, t)
+	ASSERT_NIL(assertVPackGenUnmarshalWithOptions(`{"name":"x","tagged":"y"}`, strictKeys, t), t)
+
+	caseSensitive := velocypack.DecoderOptions{CaseSensitive: true}
+	ASSERT_NIL(assertVPackGenUnmarshalWithOptions(`{"NAME":"upper","Age":3,"TAGGED":"upper","notag":"x"}`, caseSensitive, t), t)
+
+	unknown := velocypack.DecoderOptions{DisallowUnknownFields: true}
+	ASSERT_NIL(assertVPackGenUnmarshalWithOptions(`{"NAME":"upper"}`, unknown, t), t)
+	err = assertVPackGenUnmarshalWithOptions(`{"unknown":1,"name":"after"}`, unknown, t)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnknownField, t)(err)
+
+	// Strict mode stops at the first error, leaving later fields untouched.
+	strict := velocypack.DecoderOptions{Strict: true}
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(assertVPackGenUnmarshalWithOptions(`{"small":300,"name":"after"}`, strict, t))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(assertVPackGenUnmarshalWithOptions(`{"age":3.5}`, strict, t))
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(assertVPackGenUnmarshalWithOptions(`[1]`, strict, t))
+
+	// Options are passed on to fields that are decoded by reflection.
+	useNumber := velocypack.DecoderOptions{UseNumber: true}
+	ASSERT_NIL(assertVPackGenUnmarshalWithOptions(`{"any":[1,2.5],"attrs":{"n":3}}`, useNumber, t), t)
+	var v GenDocument
+	must(velocypack.UnmarshalWithOptions(mustSlice(velocypack.ParseJSONFromString(`{"any":1}`)), &v, useNumber))
+	ASSERT_EQ(v.Any, json.Number("1"), t)
+
+	// Options are passed on to nested generated types.
+	err = velocypack.UnmarshalWithOptions(mustSlice(velocypack.ParseJSONFromString(`{"address":{"street":"S","extra":1}}`)), &v, unknown)
+	ASSERT_EQ(err.Error(), `json: unknown field "extra" in Go struct GenAddress`, t)
+	err = velocypack.UnmarshalWithOptions(mustSlice(velocypack.ParseJSONFromString(`{"addrPtr":{"STREET":"S"}}`)), &v, strictKeys)
+	ASSERT_EQ(err.Error(), `json: unknown field "STREET" in Go struct GenAddress`, t)
+	var e GenEmpty
+	err = velocypack.UnmarshalWithOptions(mustSlice(velocypack.ParseJSONFromString(`{"hidden":1}`)), &e, unknown)
+	ASSERT_EQ(err.Error(), `json: unknown field "hidden" in Go struct GenEmpty`, t)
+
+	// Nested values count towards the maximum depth.
+	deep := mustSlice(velocypack.ParseJSONFromString(`{"any":[[]]}`))
+	ASSERT_NIL(velocypack.UnmarshalWithOptions(deep, &v, velocypack.DecoderOptions{MaxDepth: 3}), t)
+	err = velocypack.UnmarshalWithOptions(deep, &v, velocypack.DecoderOptions{MaxDepth: 2})
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsMaxDepthExceeded, t)(err)
+}
+
+func TestVPackGenUnmarshalErrors(t *testing.T) {
+	for _, json := range []string{
+		`{"small":300}`,
+		`{"count":-1}`,
+		`{"byte":256}`,
+		`{"name":12}`,
+		`{"age":"old"}`,
+		`{"active":"yes"}`,
+		`{"quoted":17}`,
+		`{"quoted":"x"}`,
+		`{"tags":"a"}`,
+		`{"addrPtr":[]}`,
+		`[1,2]`,
+		`"text"`,
+	} {
+		ASSERT_TRUE(assertVPackGenUnmarshal(json, t) != nil, t)
+	}
+
+	// Errors of nested generated types keep the context of the nested type.
+	var v GenDocument
+	err := velocypack.Unmarshal(mustSlice(velocypack.ParseJSONFromString(`{"address":{"number":"x"}}`)), &v)
+	ASSERT_EQ(err.Error(), "json: cannot unmarshal string into Go struct field GenAddress.number of type int", t)
+
+	err = velocypack.Unmarshal(mustSlice(velocypack.ParseJSONFromString(`{"name":12}`)), &v)
+	ASSERT_VELOCYPACK_EXCEPTION(velocypack.IsUnmarshalType, t)(err)
+	ASSERT_EQ(err.Error(), "json: cannot unmarshal number into Go struct field GenDocument.name of type string", t)
+	err = velocypack.Unmarshal(mustSlice(velocypack.ParseJSONFromString(`[]`)), &v)
+	ASSERT_EQ(err.Error(), "json: cannot unmarshal array into Go value of type test.GenDocument", t)
+}
+
+func BenchmarkVPackGenMarshal(b *testing.B) {
+	input := GenAddress{Street: "Some street", Number: 123}
+	var builder velocypack.Builder
+	for i := 0; i < b.N; i++ {
+		builder.Clear()
+		if err := input.MarshalVPackTo(&builder); err != nil {
+			b.Errorf("MarshalVPackTo failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkVPackGenMarshalReflection(b *testing.B) {
+	input := plainGenAddress{Street: "Some street", Number: 123}
+	var builder velocypack.Builder
+	for i := 0; i < b.N; i++ {
+		builder.Clear()
+		if err := velocypack.MarshalTo(&builder, input); err != nil {
+			b.Errorf("MarshalTo failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkVPackGenUnmarshal(b *testing.B) {
+	s := mustSlice(velocypack.Marshal(GenAddress{Street: "Some street", Number: 123}))
+	for i := 0; i < b.N; i++ {
+		var v GenAddress
+		if err := v.UnmarshalVPack(s); err != nil {
+			b.Errorf("UnmarshalVPack failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkVPackGenUnmarshalReflection(b *testing.B) {
+	s := mustSlice(velocypack.Marshal(GenAddress{Street: "Some street", Number: 123}))
+	for i := 0; i < b.N; i++ {
+		var v plainGenAddress
+		if err := velocypack.Unmarshal(s, &v); err != nil {
+			b.Errorf("Unmarshal failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package test
+
+import (
+	"time"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+//go:generate go run ../cmd/vpackgen vpackgen_types_test.go
+
+// GenColor is a named type that generated code handles through reflection.
+type GenColor string
+
+// GenAddress is nested in GenDocument.
+//
+//vpackgen:generate
+type GenAddress struct {
+	Street string `json:"street"`
+	Number int    `json:"number,omitempty"`
+}
+
+// GenDocument covers the field types and tag options supported by vpackgen.
+//
+//vpackgen:generate
+type GenDocument struct {
+	Key         string  `json:"_key,omitempty" velocypack:"_key"`
+	Name        string  `json:"name"`
+	Age         int     `json:"age,omitempty"`
+	Small       int8    `json:"small"`
+	Rune        rune    `json:"rune"`
+	Count       uint16  `json:"count"`
+	Byte        byte    `json:"byte"`
+	Big         uint64  `json:"big"`
+	Ratio       float32 `json:"ratio"`
+	Score       float64 `json:"score,omitempty"`
+	Active      bool    `json:"active"`
+	Hidden      bool    `json:"-"`
+	Dash        string  `json:"-,"`
+	Quoted      int     `json:"quoted,string"`
+	QuotedStr   string  `json:"quotedStr,string"`
+	QuotedF     float64 `json:",string"`
+	QuotedB     bool    `json:"quotedB,string,omitempty"`
+	Ptr         *int    `json:"ptr"`
+	PtrOmit     *string `json:"ptrOmit,omitempty"`
+	PtrQuoted   *uint   `json:"ptrQuoted,string"`
+	NoTag       string
+	A, B        int
+	Color       GenColor               `json:"color,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Attrs       map[string]interface{} `json:"attrs"`
+	Any         interface{}            `json:"any,omitempty"`
+	Address     GenAddress             `json:"address"`
+	AddrPtr     *GenAddress            `json:"addrPtr,omitempty"`
+	Created     time.Time              `json:"created"`
+	Raw         velocypack.RawSlice    `json:"raw,omitempty"`
+	Binary      []byte                 `json:"binary"`
+	unexported  string
+	GenEmbedded `json:"embedded"`
+	Dup1        string `velocypack:"dup"`
+	Dup2        string `velocypack:"dup"`
+	Dup3        string `json:"tagged"`
+	Tagged      string
+}
+
+// GenEmbedded is embedded with a name tag in GenDocument.
+type GenEmbedded struct {
+	Inner string
+}
+
+// GenEmpty has no encoded fields.
+//
+//vpackgen:generate
+type GenEmpty struct {
+	hidden int
+}
@@ -0,0 +1,742 @@
+// Code generated by vpackgen. DO NOT EDIT.
+
+package test
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	velocypack "github.com/arangodb/go-velocypack"
+)
+
+// MarshalVPackTo implements velocypack.BuilderMarshaler.
+func (v GenAddress) MarshalVPackTo(b *velocypack.Builder) error {
+	if err := b.OpenObject(); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("street", velocypack.NewStringValue(v.Street)); err != nil {
+		return err
+	}
+	if v.Number != 0 {
+		if err := b.AddKeyValue("number", velocypack.NewIntValue(int64(v.Number))); err != nil {
+			return err
+		}
+	}
+	return b.Close()
+}
+
+// UnmarshalVPack implements velocypack.Unmarshaler.
+func (v *GenAddress) UnmarshalVPack(s velocypack.Slice) error {
+	return v.UnmarshalVPackWithOptions(s, velocypack.DecoderOptions{})
+}
+
+// UnmarshalVPackWithOptions implements velocypack.OptionsUnmarshaler.
+func (v *GenAddress) UnmarshalVPackWithOptions(s velocypack.Slice, options velocypack.DecoderOptions) error {
+	if s.IsNull() {
+		return nil
+	}
+	if !s.IsObject() {
+		// Let Unmarshal report the error, using a type without methods.
+		type noMethods GenAddress
+		err := velocypack.UnmarshalWithOptions(s, (*noMethods)(v), options)
+		if e, ok := velocypack.Cause(err).(*velocypack.UnmarshalTypeError); ok && e.Type == reflect.TypeOf(noMethods{}) {
+			e.Type = reflect.TypeOf(*v)
+		}
+		return err
+	}
+	it, err := velocypack.NewObjectIterator(s)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	saveError := func(err error, field string) {
+		if firstErr != nil {
+			return
+		}
+		if e, ok := velocypack.Cause(err).(*velocypack.UnmarshalTypeError); ok && e.Struct == "" && e.Field == "" {
+			e.Struct, e.Field = "GenAddress", field
+		}
+		firstErr = err
+	}
+	for it.IsValid() {
+		key, err := it.Key(true)
+		if err != nil {
+			return err
+		}
+		name, err := key.GetStringUTF8()
+		if err != nil {
+			return err
+		}
+		value, err := it.Value()
+		if err != nil {
+			return err
+		}
+		field := -1
+		switch string(name) {
+		case "street":
+			field = 0
+		case "number":
+			field = 1
+		default:
+			if !options.CaseSensitive {
+				switch {
+				case strings.EqualFold(string(name), "street"):
+					field = 0
+				case strings.EqualFold(string(name), "number"):
+					field = 1
+				}
+			}
+		}
+		switch field {
+		case 0:
+			if x, err := value.GetString(); err == nil {
+				v.Street = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Street, options); err != nil {
+				saveError(err, "street")
+			}
+		case 1:
+			if x, err := value.GetInt(); err == nil && int64(int(x)) == x {
+				v.Number = int(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Number, options); err != nil {
+				saveError(err, "number")
+			}
+		default:
+			if options.DisallowUnknownFields {
+				saveError(velocypack.WithStack(&velocypack.UnknownFieldError{Field: string(name), Struct: "GenAddress"}), "")
+			}
+		}
+		if firstErr != nil && options.Strict {
+			return firstErr
+		}
+		if err := it.Next(); err != nil {
+			return err
+		}
+	}
+	return firstErr
+}
+
+// MarshalVPackTo implements velocypack.BuilderMarshaler.
+func (v GenDocument) MarshalVPackTo(b *velocypack.Builder) error {
+	if err := b.OpenObject(); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("_key", velocypack.NewStringValue(v.Key)); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("name", velocypack.NewStringValue(v.Name)); err != nil {
+		return err
+	}
+	if v.Age != 0 {
+		if err := b.AddKeyValue("age", velocypack.NewIntValue(int64(v.Age))); err != nil {
+			return err
+		}
+	}
+	if err := b.AddKeyValue("small", velocypack.NewIntValue(int64(v.Small))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("rune", velocypack.NewIntValue(int64(v.Rune))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("count", velocypack.NewUIntValue(uint64(v.Count))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("byte", velocypack.NewUIntValue(uint64(v.Byte))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("big", velocypack.NewUIntValue(uint64(v.Big))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("ratio", velocypack.NewDoubleValue(float64(v.Ratio))); err != nil {
+		return err
+	}
+	if v.Score != 0 {
+		if err := b.AddKeyValue("score", velocypack.NewDoubleValue(float64(v.Score))); err != nil {
+			return err
+		}
+	}
+	if err := b.AddKeyValue("active", velocypack.NewBoolValue(v.Active)); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("-", velocypack.NewStringValue(v.Dash)); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("quoted", velocypack.NewStringValue(strconv.FormatInt(int64(v.Quoted), 10))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("quotedStr", velocypack.NewStringValue(velocypack.QuoteString(b, v.QuotedStr))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("QuotedF", velocypack.NewStringValue(strconv.FormatFloat(float64(v.QuotedF), 'g', -1, 64))); err != nil {
+		return err
+	}
+	if v.QuotedB {
+		if err := b.AddKeyValue("quotedB", velocypack.NewStringValue(strconv.FormatBool(v.QuotedB))); err != nil {
+			return err
+		}
+	}
+	if v.Ptr == nil {
+		if err := b.AddKeyValue("ptr", velocypack.NewNullValue()); err != nil {
+			return err
+		}
+	} else {
+		if err := b.AddKeyValue("ptr", velocypack.NewIntValue(int64(*v.Ptr))); err != nil {
+			return err
+		}
+	}
+	if v.PtrOmit != nil {
+		if err := b.AddKeyValue("ptrOmit", velocypack.NewStringValue(*v.PtrOmit)); err != nil {
+			return err
+		}
+	}
+	if v.PtrQuoted == nil {
+		if err := b.AddKeyValue("ptrQuoted", velocypack.NewNullValue()); err != nil {
+			return err
+		}
+	} else {
+		if err := b.AddKeyValue("ptrQuoted", velocypack.NewStringValue(strconv.FormatUint(uint64(*v.PtrQuoted), 10))); err != nil {
+			return err
+		}
+	}
+	if err := b.AddKeyValue("NoTag", velocypack.NewStringValue(v.NoTag)); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("A", velocypack.NewIntValue(int64(v.A))); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("B", velocypack.NewIntValue(int64(v.B))); err != nil {
+		return err
+	}
+	if !velocypack.IsEmptyValue(v.Color) {
+		if err := b.AddValue(velocypack.NewStringValue("color")); err != nil {
+			return err
+		}
+		if err := velocypack.MarshalTo(b, &v.Color); err != nil {
+			return err
+		}
+	}
+	if len(v.Tags) != 0 {
+		if err := b.AddValue(velocypack.NewStringValue("tags")); err != nil {
+			return err
+		}
+		if err := velocypack.MarshalTo(b, &v.Tags); err != nil {
+			return err
+		}
+	}
+	if err := b.AddValue(velocypack.NewStringValue("attrs")); err != nil {
+		return err
+	}
+	if err := velocypack.MarshalTo(b, &v.Attrs); err != nil {
+		return err
+	}
+	if v.Any != nil {
+		if err := b.AddValue(velocypack.NewStringValue("any")); err != nil {
+			return err
+		}
+		if err := velocypack.MarshalTo(b, &v.Any); err != nil {
+			return err
+		}
+	}
+	if err := b.AddValue(velocypack.NewStringValue("address")); err != nil {
+		return err
+	}
+	if err := velocypack.MarshalTo(b, &v.Address); err != nil {
+		return err
+	}
+	if v.AddrPtr != nil {
+		if err := b.AddValue(velocypack.NewStringValue("addrPtr")); err != nil {
+			return err
+		}
+		if err := velocypack.MarshalTo(b, &v.AddrPtr); err != nil {
+			return err
+		}
+	}
+	if err := b.AddValue(velocypack.NewStringValue("created")); err != nil {
+		return err
+	}
+	if err := velocypack.MarshalTo(b, &v.Created); err != nil {
+		return err
+	}
+	if !velocypack.IsEmptyValue(v.Raw) {
+		if err := b.AddValue(velocypack.NewStringValue("raw")); err != nil {
+			return err
+		}
+		if err := velocypack.MarshalTo(b, &v.Raw); err != nil {
+			return err
+		}
+	}
+	if err := b.AddValue(velocypack.NewStringValue("binary")); err != nil {
+		return err
+	}
+	if err := velocypack.MarshalTo(b, &v.Binary); err != nil {
+		return err
+	}
+	if err := b.AddValue(velocypack.NewStringValue("embedded")); err != nil {
+		return err
+	}
+	if err := velocypack.MarshalTo(b, &v.GenEmbedded); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("tagged", velocypack.NewStringValue(v.Dup3)); err != nil {
+		return err
+	}
+	if err := b.AddKeyValue("Tagged", velocypack.NewStringValue(v.Tagged)); err != nil {
+		return err
+	}
+	return b.Close()
+}
+
+// UnmarshalVPack implements velocypack.Unmarshaler.
+func (v *GenDocument) UnmarshalVPack(s velocypack.Slice) error {
+	return v.UnmarshalVPackWithOptions(s, velocypack.DecoderOptions{})
+}
+
+// UnmarshalVPackWithOptions implements velocypack.OptionsUnmarshaler.
+func (v *GenDocument) UnmarshalVPackWithOptions(s velocypack.Slice, options velocypack.DecoderOptions) error {
+	if s.IsNull() {
+		return nil
+	}
+	if !s.IsObject() {
+		// Let Unmarshal report the error, using a type without methods.
+		type noMethods GenDocument
+		err := velocypack.UnmarshalWithOptions(s, (*noMethods)(v), options)
+		if e, ok := velocypack.Cause(err).(*velocypack.UnmarshalTypeError); ok && e.Type == reflect.TypeOf(noMethods{}) {
+			e.Type = reflect.TypeOf(*v)
+		}
+		return err
+	}
+	it, err := velocypack.NewObjectIterator(s)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	saveError := func(err error, field string) {
+		if firstErr != nil {
+			return
+		}
+		if e, ok := velocypack.Cause(err).(*velocypack.UnmarshalTypeError); ok && e.Struct == "" && e.Field == "" {
+			e.Struct, e.Field = "GenDocument", field
+		}
+		firstErr = err
+	}
+	for it.IsValid() {
+		key, err := it.Key(true)
+		if err != nil {
+			return err
+		}
+		name, err := key.GetStringUTF8()
+		if err != nil {
+			return err
+		}
+		value, err := it.Value()
+		if err != nil {
+			return err
+		}
+		field := -1
+		switch string(name) {
+		case "_key":
+			field = 0
+		case "name":
+			field = 1
+		case "age":
+			field = 2
+		case "small":
+			field = 3
+		case "rune":
+			field = 4
+		case "count":
+			field = 5
+		case "byte":
+			field = 6
+		case "big":
+			field = 7
+		case "ratio":
+			field = 8
+		case "score":
+			field = 9
+		case "active":
+			field = 10
+		case "-":
+			field = 11
+		case "quoted":
+			field = 12
+		case "quotedStr":
+			field = 13
+		case "QuotedF":
+			field = 14
+		case "quotedB":
+			field = 15
+		case "ptr":
+			field = 16
+		case "ptrOmit":
+			field = 17
+		case "ptrQuoted":
+			field = 18
+		case "NoTag":
+			field = 19
+		case "A":
+			field = 20
+		case "B":
+			field = 21
+		case "color":
+			field = 22
+		case "tags":
+			field = 23
+		case "attrs":
+			field = 24
+		case "any":
+			field = 25
+		case "address":
+			field = 26
+		case "addrPtr":
+			field = 27
+		case "created":
+			field = 28
+		case "raw":
+			field = 29
+		case "binary":
+			field = 30
+		case "embedded":
+			field = 31
+		case "tagged":
+			field = 32
+		case "Tagged":
+			field = 33
+		default:
+			if !options.CaseSensitive {
+				switch {
+				case strings.EqualFold(string(name), "_key"):
+					field = 0
+				case strings.EqualFold(string(name), "name"):
+					field = 1
+				case strings.EqualFold(string(name), "age"):
+					field = 2
+				case strings.EqualFold(string(name), "small"):
+					field = 3
+				case strings.EqualFold(string(name), "rune"):
+					field = 4
+				case strings.EqualFold(string(name), "count"):
+					field = 5
+				case strings.EqualFold(string(name), "byte"):
+					field = 6
+				case strings.EqualFold(string(name), "big"):
+					field = 7
+				case strings.EqualFold(string(name), "ratio"):
+					field = 8
+				case strings.EqualFold(string(name), "score"):
+					field = 9
+				case strings.EqualFold(string(name), "active"):
+					field = 10
+				case strings.EqualFold(string(name), "-"):
+					field = 11
+				case strings.EqualFold(string(name), "quoted"):
+					field = 12
+				case strings.EqualFold(string(name), "quotedStr"):
+					field = 13
+				case strings.EqualFold(string(name), "QuotedF"):
+					field = 14
+				case strings.EqualFold(string(name), "quotedB"):
+					field = 15
+				case strings.EqualFold(string(name), "ptr"):
+					field = 16
+				case strings.EqualFold(string(name), "ptrOmit"):
+					field = 17
+				case strings.EqualFold(string(name), "ptrQuoted"):
+					field = 18
+				case strings.EqualFold(string(name), "NoTag"):
+					field = 19
+				case strings.EqualFold(string(name), "A"):
+					field = 20
+				case strings.EqualFold(string(name), "B"):
+					field = 21
+				case strings.EqualFold(string(name), "color"):
+					field = 22
+				case strings.EqualFold(string(name), "tags"):
+					field = 23
+				case strings.EqualFold(string(name), "attrs"):
+					field = 24
+				case strings.EqualFold(string(name), "any"):
+					field = 25
+				case strings.EqualFold(string(name), "address"):
+					field = 26
+				case strings.EqualFold(string(name), "addrPtr"):
+					field = 27
+				case strings.EqualFold(string(name), "created"):
+					field = 28
+				case strings.EqualFold(string(name), "raw"):
+					field = 29
+				case strings.EqualFold(string(name), "binary"):
+					field = 30
+				case strings.EqualFold(string(name), "embedded"):
+					field = 31
+				case strings.EqualFold(string(name), "tagged"):
+					field = 32
+				case strings.EqualFold(string(name), "Tagged"):
+					field = 33
+				}
+			}
+		}
+		switch field {
+		case 0:
+			if x, err := value.GetString(); err == nil {
+				v.Key = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Key, options); err != nil {
+				saveError(err, "_key")
+			}
+		case 1:
+			if x, err := value.GetString(); err == nil {
+				v.Name = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Name, options); err != nil {
+				saveError(err, "name")
+			}
+		case 2:
+			if x, err := value.GetInt(); err == nil && int64(int(x)) == x {
+				v.Age = int(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Age, options); err != nil {
+				saveError(err, "age")
+			}
+		case 3:
+			if x, err := value.GetInt(); err == nil && int64(int8(x)) == x {
+				v.Small = int8(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Small, options); err != nil {
+				saveError(err, "small")
+			}
+		case 4:
+			if x, err := value.GetInt(); err == nil && int64(rune(x)) == x {
+				v.Rune = rune(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Rune, options); err != nil {
+				saveError(err, "rune")
+			}
+		case 5:
+			if x, err := value.GetUInt(); err == nil && uint64(uint16(x)) == x {
+				v.Count = uint16(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Count, options); err != nil {
+				saveError(err, "count")
+			}
+		case 6:
+			if x, err := value.GetUInt(); err == nil && uint64(byte(x)) == x {
+				v.Byte = byte(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Byte, options); err != nil {
+				saveError(err, "byte")
+			}
+		case 7:
+			if x, err := value.GetUInt(); err == nil {
+				v.Big = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Big, options); err != nil {
+				saveError(err, "big")
+			}
+		case 8:
+			if x, err := value.GetDouble(); err == nil && (math.Abs(x) <= math.MaxFloat32 || math.IsInf(x, 0)) {
+				v.Ratio = float32(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Ratio, options); err != nil {
+				saveError(err, "ratio")
+			}
+		case 9:
+			if x, err := value.GetDouble(); err == nil {
+				v.Score = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Score, options); err != nil {
+				saveError(err, "score")
+			}
+		case 10:
+			if x, err := value.GetBool(); err == nil {
+				v.Active = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Active, options); err != nil {
+				saveError(err, "active")
+			}
+		case 11:
+			if x, err := value.GetString(); err == nil {
+				v.Dash = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Dash, options); err != nil {
+				saveError(err, "-")
+			}
+		case 12:
+			if raw, err := value.GetStringUTF8(); err != nil {
+				saveError(fmt.Errorf("json: invalid use of ,string struct tag, expected string, got %s in %s (%v)", value.Type(), "int", err), "quoted")
+			} else if parsed, err := velocypack.ParseJSONFromUTF8(raw); err != nil {
+				saveError(err, "quoted")
+			} else if err := velocypack.UnmarshalWithOptions(parsed, &v.Quoted, options); err != nil {
+				saveError(err, "quoted")
+			}
+		case 13:
+			if raw, err := value.GetStringUTF8(); err != nil {
+				saveError(fmt.Errorf("json: invalid use of ,string struct tag, expected string, got %s in %s (%v)", value.Type(), "string", err), "quotedStr")
+			} else if parsed, err := velocypack.ParseJSONFromUTF8(raw); err != nil {
+				saveError(err, "quotedStr")
+			} else if err := velocypack.UnmarshalWithOptions(parsed, &v.QuotedStr, options); err != nil {
+				saveError(err, "quotedStr")
+			}
+		case 14:
+			if raw, err := value.GetStringUTF8(); err != nil {
+				saveError(fmt.Errorf("json: invalid use of ,string struct tag, expected string, got %s in %s (%v)", value.Type(), "float64", err), "QuotedF")
+			} else if parsed, err := velocypack.ParseJSONFromUTF8(raw); err != nil {
+				saveError(err, "QuotedF")
+			} else if err := velocypack.UnmarshalWithOptions(parsed, &v.QuotedF, options); err != nil {
+				saveError(err, "QuotedF")
+			}
+		case 15:
+			if raw, err := value.GetStringUTF8(); err != nil {
+				saveError(fmt.Errorf("json: invalid use of ,string struct tag, expected string, got %s in %s (%v)", value.Type(), "bool", err), "quotedB")
+			} else if parsed, err := velocypack.ParseJSONFromUTF8(raw); err != nil {
+				saveError(err, "quotedB")
+			} else if err := velocypack.UnmarshalWithOptions(parsed, &v.QuotedB, options); err != nil {
+				saveError(err, "quotedB")
+			}
+		case 16:
+			if x, err := value.GetInt(); err == nil && int64(int(x)) == x {
+				if v.Ptr == nil {
+					v.Ptr = new(int)
+				}
+				*v.Ptr = int(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Ptr, options); err != nil {
+				saveError(err, "ptr")
+			}
+		case 17:
+			if x, err := value.GetString(); err == nil {
+				if v.PtrOmit == nil {
+					v.PtrOmit = new(string)
+				}
+				*v.PtrOmit = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.PtrOmit, options); err != nil {
+				saveError(err, "ptrOmit")
+			}
+		case 18:
+			if raw, err := value.GetStringUTF8(); err != nil {
+				saveError(fmt.Errorf("json: invalid use of ,string struct tag, expected string, got %s in %s (%v)", value.Type(), "*uint", err), "ptrQuoted")
+			} else if parsed, err := velocypack.ParseJSONFromUTF8(raw); err != nil {
+				saveError(err, "ptrQuoted")
+			} else if err := velocypack.UnmarshalWithOptions(parsed, &v.PtrQuoted, options); err != nil {
+				saveError(err, "ptrQuoted")
+			}
+		case 19:
+			if x, err := value.GetString(); err == nil {
+				v.NoTag = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.NoTag, options); err != nil {
+				saveError(err, "NoTag")
+			}
+		case 20:
+			if x, err := value.GetInt(); err == nil && int64(int(x)) == x {
+				v.A = int(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.A, options); err != nil {
+				saveError(err, "A")
+			}
+		case 21:
+			if x, err := value.GetInt(); err == nil && int64(int(x)) == x {
+				v.B = int(x)
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.B, options); err != nil {
+				saveError(err, "B")
+			}
+		case 22:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Color, options); err != nil {
+				saveError(err, "color")
+			}
+		case 23:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Tags, options); err != nil {
+				saveError(err, "tags")
+			}
+		case 24:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Attrs, options); err != nil {
+				saveError(err, "attrs")
+			}
+		case 25:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Any, options); err != nil {
+				saveError(err, "any")
+			}
+		case 26:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Address, options); err != nil {
+				saveError(err, "address")
+			}
+		case 27:
+			if err := velocypack.UnmarshalWithOptions(value, &v.AddrPtr, options); err != nil {
+				saveError(err, "addrPtr")
+			}
+		case 28:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Created, options); err != nil {
+				saveError(err, "created")
+			}
+		case 29:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Raw, options); err != nil {
+				saveError(err, "raw")
+			}
+		case 30:
+			if err := velocypack.UnmarshalWithOptions(value, &v.Binary, options); err != nil {
+				saveError(err, "binary")
+			}
+		case 31:
+			if err := velocypack.UnmarshalWithOptions(value, &v.GenEmbedded, options); err != nil {
+				saveError(err, "embedded")
+			}
+		case 32:
+			if x, err := value.GetString(); err == nil {
+				v.Dup3 = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Dup3, options); err != nil {
+				saveError(err, "tagged")
+			}
+		case 33:
+			if x, err := value.GetString(); err == nil {
+				v.Tagged = x
+			} else if err := velocypack.UnmarshalWithOptions(value, &v.Tagged, options); err != nil {
+				saveError(err, "Tagged")
+			}
+		default:
+			if options.DisallowUnknownFields {
+				saveError(velocypack.WithStack(&velocypack.UnknownFieldError{Field: string(name), Struct: "GenDocument"}), "")
+			}
+		}
+		if firstErr != nil && options.Strict {
+			return firstErr
+		}
+		if err := it.Next(); err != nil {
+			return err
+		}
+	}
+	return firstErr
+}
+
+// MarshalVPackTo implements velocypack.BuilderMarshaler.
+func (v GenEmpty) MarshalVPackTo(b *velocypack.Builder) error {
+	if err := b.OpenObject(); err != nil {
+		return err
+	}
+	return b.Close()
+}
+
+// UnmarshalVPack implements velocypack.Unmarshaler.
+func (v *GenEmpty) UnmarshalVPack(s velocypack.Slice) error {
+	return v.UnmarshalVPackWithOptions(s, velocypack.DecoderOptions{})
+}
+
+// UnmarshalVPackWithOptions implements velocypack.OptionsUnmarshaler.
+func (v *GenEmpty) UnmarshalVPackWithOptions(s velocypack.Slice, options velocypack.DecoderOptions) error {
+	if s.IsNull() {
+		return nil
+	}
+	if !s.IsObject() {
+		// Let Unmarshal report the error, using a type without methods.
+		type noMethods GenEmpty
+		err := velocypack.UnmarshalWithOptions(s, (*noMethods)(v), options)
+		if e, ok := velocypack.Cause(err).(*velocypack.UnmarshalTypeError); ok && e.Type == reflect.TypeOf(noMethods{}) {
+			e.Type = reflect.TypeOf(*v)
+		}
+		return err
+	}
+	if options.DisallowUnknownFields {
+		it, err := velocypack.NewObjectIterator(s)
+		if err != nil {
+			return err
+		}
+		if it.IsValid() {
+			key, err := it.Key(true)
+			if err != nil {
+				return err
+			}
+			name, err := key.GetStringUTF8()
+			if err != nil {
+				return err
+			}
+			return velocypack.WithStack(&velocypack.UnknownFieldError{Field: string(name), Struct: "GenEmpty"})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,563 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// DefaultValidatorMaxDepth is the maximum nesting depth of arrays and objects
+// used by Validate when ValidatorOptions.MaxDepth is 0.
+const DefaultValidatorMaxDepth = 128
+
+// ValidatorOptions controls which checks are performed by Validate.
+type ValidatorOptions struct {
+	// If set, strings are not checked for valid UTF-8 sequences.
+	SkipUTF8Check bool
+	// If set, objects that contain the same key more than once are accepted.
+	AllowDuplicateKeys bool
+	// If set, Custom values (0xf0-0xff) are rejected.
+	DisallowCustom bool
+	// If set, BCD values (0xc8-0xd7) are rejected.
+	DisallowBCD bool
+	// If set, tagged values (0xee-0xef) are rejected.
+	DisallowTags bool
+	// MaxDepth is the maximum nesting depth of arrays and objects.
+	// If 0, DefaultValidatorMaxDepth is used.
+	MaxDepth int
+}
+
+// Validate checks that the given slice contains a single well formed VPack value.
+// The value is walked recursively, checking head bytes, byte lengths, index tables,
+// compact length trailers, string encodings, object keys and nesting depth.
+// All checks are done against the bounds of the given slice, so Validate never panics
+// on malformed input. Bytes following the value are ignored.
+// If the value is invalid, a ValidationError is returned.
+func Validate(s Slice, opts ValidatorOptions) error {
+	if len(s) == 0 {
+		return WithStack(ValidationError{Message: "empty slice", Offset: 0})
+	}
+	v := &validator{
+		s:        s,
+		options:  opts,
+		maxDepth: opts.MaxDepth,
+	}
+	if v.maxDepth <= 0 {
+		v.maxDepth = DefaultValidatorMaxDepth
+	}
+	if _, err := v.validate(0, ValueLength(len(s)), 0); err != nil {
+		return WithStack(err)
+	}
+	return nil
+}
+
+// validator holds the state of a single Validate call.
+type validator struct {
+	s        Slice
+	options  ValidatorOptions
+	maxDepth int
+}
+
+// fail creates a ValidationError for the given offset.
+func (v *validator) fail(offset ValueLength, msg string) error {
+	return WithStack(ValidationError{Message: msg, Offset: offset})
+}
+
+// need checks that at least n bytes are available from offset up to limit.
+func (v *validator) need(offset, n, limit ValueLength) error {
+	if offset > limit || limit-offset < n {
+		return v.fail(offset, "unexpected end of data")
+	}
+	return nil
+}
+
+// validate checks the value starting at offset, which must end at or before limit.
+// It returns the byte size of the value.
+func (v *validator) validate(offset, limit ValueLength, depth int) (ValueLength, error) {
+	if offset >= limit {
+		return 0, v.fail(offset, "unexpected end of data")
+	}
+	h := v.s[offset]
+	switch typeMap[h] {
+	case None:
+		return 0, v.fail(offset, fmt.Sprintf("invalid head byte 0x%02x", h))
+	case Array, Object:
+		size, err := v.validateCompound(offset, limit, depth)
+		return size, WithStack(err)
+	case External:
+		return 0, v.fail(offset, "external values are not allowed")
+	case String:
+		size, err := v.validateString(offset, limit)
+		return size, WithStack(err)
+	case Binary:
+		size, err := v.validateLengthPrefixed(offset, limit, uint(h)-0xbf)
+		return size, WithStack(err)
+	case BCD:
+		if v.options.DisallowBCD {
+			return 0, v.fail(offset, "BCD values are not allowed")
+		}
+		size, err := v.validateBCD(offset, limit)
+		return size, WithStack(err)
+	case Tagged:
+		size, err := v.validateTagged(offset, limit, depth)
+		return size, WithStack(err)
+	case Custom:
+		if v.options.DisallowCustom {
+			return 0, v.fail(offset, "custom values are not allowed")
+		}
+		if l := fixedTypeLengths[h]; l != 0 {
+			if err := v.need(offset, ValueLength(l), limit); err != nil {
+				return 0, WithStack(err)
+			}
+			return ValueLength(l), nil
+		}
+		width := uint(1) << ((h - 0xf4) / 3)
+		size, err := v.validateLengthPrefixed(offset, limit, width)
+		return size, WithStack(err)
+	default:
+		l := ValueLength(fixedTypeLengths[h])
+		if err := v.need(offset, l, limit); err != nil {
+			return 0, WithStack(err)
+		}
+		return l, nil
+	}
+}
+
+// validateString checks a short or long string value.
+func (v *validator) validateString(offset, limit ValueLength) (ValueLength, error) {
+	h := v.s[offset]
+	var start, length ValueLength
+	if h == 0xbf {
+		if err := v.need(offset, 1+8, limit); err != nil {
+			return 0, WithStack(err)
+		}
+		start = offset + 1 + 8
+		length = ValueLength(readIntegerFixed(v.s[offset+1:], 8))
+	} else {
+		start = offset + 1
+		length = ValueLength(h - 0x40)
+	}
+	if err := v.need(start, length, limit); err != nil {
+		return 0, v.fail(offset, "string length exceeds available data")
+	}
+	if !v.options.SkipUTF8Check && !utf8.Valid(v.s[start:start+length]) {
+		return 0, v.fail(offset, "invalid UTF-8 sequence in string")
+	}
+	return start + length - offset, nil
+}
+
+// validateLengthPrefixed checks a value that consists of a head byte, followed by
+// a little endian length of the given width, followed by that many bytes.
+func (v *validator) validateLengthPrefixed(offset, limit ValueLength, width uint) (ValueLength, error) {
+	if err := v.need(offset, 1+ValueLength(width), limit); err != nil {
+		return 0, WithStack(err)
+	}
+	start := offset + 1 + ValueLength(width)
+	length := ValueLength(readIntegerNonEmpty(v.s[offset+1:], width))
+	if err := v.need(start, length, limit); err != nil {
+		return 0, v.fail(offset, "value length exceeds available data")
+	}
+	return start + length - offset, nil
+}
+
+// validateBCD checks a BCD value, which consists of a head byte, the length of the mantissa,
+// a 4 byte exponent and the mantissa as packed BCD digits.
+func (v *validator) validateBCD(offset, limit ValueLength) (ValueLength, error) {
+	lengthSize := bcdLengthSize(v.s[offset])
+	if err := v.need(offset, 1+ValueLength(lengthSize)+4, limit); err != nil {
+		return 0, WithStack(err)
+	}
+	start := offset + 1 + ValueLength(lengthSize) + 4
+	length := ValueLength(readIntegerNonEmpty(v.s[offset+1:], lengthSize))
+	if err := v.need(start, length, limit); err != nil {
+		return 0, v.fail(offset, "value length exceeds available data")
+	}
+	for i := start; i < start+length; i++ {
+		if b := v.s[i]; b>>4 > 9 || b&0x0f > 9 {
+			return 0, v.fail(i, "invalid BCD digit")
+		}
+	}
+	return start + length - offset, nil
+}
+
+// validateTagged checks a tagged value, which consists of a head byte, a 1 or 8 byte tag
+// and the tagged value.
+func (v *validator) validateTagged(offset, limit ValueLength, depth int) (ValueLength, error) {
+	if v.options.DisallowTags {
+		return 0, v.fail(offset, "tagged values are not allowed")
+	}
+	if depth >= v.maxDepth {
+		return 0, v.fail(offset, "nesting too deep")
+	}
+	tagSize := ValueLength(1)
+	if v.s[offset] == 0xef {
+		tagSize = 8
+	}
+	if err := v.need(offset, 1+tagSize, limit); err != nil {
+		return 0, WithStack(err)
+	}
+	size, err := v.validate(offset+1+tagSize, limit, depth+1)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	return 1 + tagSize + size, nil
+}
+
+// validateCompound checks an array or object value.
+func (v *validator) validateCompound(offset, limit ValueLength, depth int) (ValueLength, error) {
+	if depth >= v.maxDepth {
+		return 0, v.fail(offset, "nesting too deep")
+	}
+	h := v.s[offset]
+	switch {
+	case h == 0x01 || h == 0x0a:
+		// empty array or object
+		return 1, nil
+	case h == 0x13 || h == 0x14:
+		size, err := v.validateCompact(offset, limit, depth+1)
+		return size, WithStack(err)
+	case h <= 0x05:
+		size, err := v.validateArrayWithoutIndex(offset, limit, depth+1)
+		return size, WithStack(err)
+	default:
+		size, err := v.validateIndexed(offset, limit, depth+1)
+		return size, WithStack(err)
+	}
+}
+
+// readByteLength reads the byte length of a non-compact array or object and
+// checks it against the available data.
+func (v *validator) readByteLength(offset, limit ValueLength, width uint) (ValueLength, error) {
+	if err := v.need(offset, 1+ValueLength(width), limit); err != nil {
+		return 0, WithStack(err)
+	}
+	byteLength := ValueLength(readIntegerNonEmpty(v.s[offset+1:], width))
+	if byteLength < 1+ValueLength(width) {
+		return 0, v.fail(offset, "byte length too small")
+	}
+	if err := v.need(offset, byteLength, limit); err != nil {
+		return 0, v.fail(offset, "byte length exceeds available data")
+	}
+	return byteLength, nil
+}
+
+// dataOffset finds the offset (relative to the start of the array or object) of
+// the first member, checking that the padding before it consists of zero bytes.
+func (v *validator) dataOffset(offset, byteLength, headerSize ValueLength) (ValueLength, error) {
+	fsm := ValueLength(firstSubMap[v.s[offset]])
+	for _, candidate := range []ValueLength{2, 3, 5, 9} {
+		if candidate < fsm {
+			continue
+		}
+		if candidate >= byteLength {
+			break
+		}
+		if v.s[offset+candidate] != 0 {
+			for i := headerSize; i < candidate; i++ {
+				if v.s[offset+i] != 0 {
+					return 0, v.fail(offset+i, "invalid padding")
+				}
+			}
+			return candidate, nil
+		}
+	}
+	return 0, v.fail(offset, "cannot find first member")
+}
+
+// validateArrayWithoutIndex checks an array of equally sized members without index table (0x02-0x05).
+func (v *validator) validateArrayWithoutIndex(offset, limit ValueLength, depth int) (ValueLength, error) {
+	width := widthMap[v.s[offset]]
+	byteLength, err := v.readByteLength(offset, limit, width)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	pos, err := v.dataOffset(offset, byteLength, 1+ValueLength(width))
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	end := offset + byteLength
+	itemSize, err := v.validate(offset+pos, end, depth)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	for pos += itemSize; pos < byteLength; {
+		size, err := v.validate(offset+pos, end, depth)
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		if size != itemSize {
+			return 0, v.fail(offset+pos, "array members have different sizes")
+		}
+		pos += size
+	}
+	return byteLength, nil
+}
+
+// validateIndexed checks an array or object with index table (0x06-0x09, 0x0b-0x12).
+func (v *validator) validateIndexed(offset, limit ValueLength, depth int) (ValueLength, error) {
+	h := v.s[offset]
+	isObject := h >= 0x0b
+	width := widthMap[h]
+	byteLength, err := v.readByteLength(offset, limit, width)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+
+	// read number of members
+	var n, headerSize, tableEnd ValueLength
+	if width < 8 {
+		headerSize = 1 + 2*ValueLength(width)
+		if byteLength < headerSize {
+			return 0, v.fail(offset, "byte length too small")
+		}
+		n = ValueLength(readIntegerNonEmpty(v.s[offset+1+ValueLength(width):], width))
+		tableEnd = byteLength
+	} else {
+		headerSize = 1 + 8
+		if byteLength < headerSize+8 {
+			return 0, v.fail(offset, "byte length too small")
+		}
+		tableEnd = byteLength - 8
+		n = ValueLength(readIntegerFixed(v.s[offset+tableEnd:], 8))
+	}
+	if n == 0 {
+		return 0, v.fail(offset, "number of members must not be 0")
+	}
+	dataOffset, err := v.dataOffset(offset, byteLength, headerSize)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	if dataOffset > tableEnd || n > (tableEnd-dataOffset)/ValueLength(width) {
+		return 0, v.fail(offset, "index table exceeds available data")
+	}
+	tableStart := tableEnd - n*ValueLength(width)
+
+	// walk all members
+	positions := make([]ValueLength, 0, n)
+	for pos := dataOffset; pos < tableStart; {
+		if ValueLength(len(positions)) == n {
+			return 0, v.fail(offset+pos, "more members than index table entries")
+		}
+		positions = append(positions, pos)
+		if isObject {
+			if err := v.checkKeyType(offset + pos); err != nil {
+				return 0, WithStack(err)
+			}
+		}
+		size, err := v.validate(offset+pos, offset+tableStart, depth)
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		pos += size
+		if isObject {
+			size, err := v.validate(offset+pos, offset+tableStart, depth)
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			pos += size
+		}
+	}
+	if ValueLength(len(positions)) != n {
+		return 0, v.fail(offset, "number of members does not match index table")
+	}
+
+	// check index table
+	used := make([]bool, n)
+	for i := ValueLength(0); i < n; i++ {
+		entryOffset := offset + tableStart + i*ValueLength(width)
+		entry := ValueLength(readIntegerNonEmpty(v.s[entryOffset:], width))
+		idx := sort.Search(len(positions), func(j int) bool { return positions[j] >= entry })
+		if idx == len(positions) || positions[idx] != entry || (!isObject && ValueLength(idx) != i) {
+			return 0, v.fail(entryOffset, "invalid index table entry")
+		}
+		if used[idx] {
+			return 0, v.fail(entryOffset, "duplicate index table entry")
+		}
+		used[idx] = true
+	}
+
+	if isObject {
+		if err := v.checkKeys(offset, tableStart, width, n, h <= 0x0e); err != nil {
+			return 0, WithStack(err)
+		}
+	}
+	return byteLength, nil
+}
+
+// validateCompact checks a compact array or object (0x13, 0x14).
+func (v *validator) validateCompact(offset, limit ValueLength, depth int) (ValueLength, error) {
+	isObject := v.s[offset] == 0x14
+	byteLength, lengthSize, err := v.readVariableValueLength(offset+1, offset+1, limit, false)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	if byteLength < 1+lengthSize+1 {
+		return 0, v.fail(offset, "byte length too small")
+	}
+	if err := v.need(offset, byteLength, limit); err != nil {
+		return 0, v.fail(offset, "byte length exceeds available data")
+	}
+	dataOffset := 1 + lengthSize
+	n, nSize, err := v.readVariableValueLength(offset+byteLength-1, offset+dataOffset, offset+byteLength, true)
+	if err != nil {
+		return 0, WithStack(err)
+	}
+	if n == 0 {
+		return 0, v.fail(offset, "number of members must not be 0")
+	}
+	dataEnd := byteLength - nSize
+
+	// walk all members
+	count := ValueLength(0)
+	keyOffsets := make([]ValueLength, 0, 8)
+	for pos := dataOffset; pos < dataEnd; count++ {
+		if count == n {
+			return 0, v.fail(offset+pos, "more members than specified")
+		}
+		if isObject {
+			if err := v.checkKeyType(offset + pos); err != nil {
+				return 0, WithStack(err)
+			}
+			keyOffsets = append(keyOffsets, offset+pos)
+		}
+		size, err := v.validate(offset+pos, offset+dataEnd, depth)
+		if err != nil {
+			return 0, WithStack(err)
+		}
+		pos += size
+		if isObject {
+			size, err := v.validate(offset+pos, offset+dataEnd, depth)
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			pos += size
+		}
+	}
+	if count != n {
+		return 0, v.fail(offset, "number of members does not match")
+	}
+
+	if isObject && !v.options.AllowDuplicateKeys {
+		keys := make(map[string]struct{}, len(keyOffsets))
+		for _, keyOffset := range keyOffsets {
+			name, err := v.keyName(keyOffset)
+			if err != nil {
+				return 0, WithStack(err)
+			}
+			if _, found := keys[string(name)]; found {
+				return 0, v.fail(keyOffset, "duplicate key")
+			}
+			keys[string(name)] = struct{}{}
+		}
+	}
+	return byteLength, nil
+}
+
+// readVariableValueLength reads a variable length integer in unsigned LEB128 format,
+// staying within [lower, upper). It returns the value and the number of bytes used.
+func (v *validator) readVariableValueLength(offset, lower, upper ValueLength, reverse bool) (ValueLength, ValueLength, error) {
+	value := ValueLength(0)
+	size := ValueLength(0)
+	for shift := uint(0); ; shift += 7 {
+		if offset < lower || offset >= upper {
+			return 0, 0, v.fail(offset, "unexpected end of data")
+		}
+		if shift > 63 {
+			return 0, 0, v.fail(offset, "variable length integer too long")
+		}
+		b := v.s[offset]
+		value |= ValueLength(b&0x7f) << shift
+		size++
+		if b&0x80 == 0 {
+			return value, size, nil
+		}
+		if reverse {
+			offset--
+		} else {
+			offset++
+		}
+	}
+}
+
+// checkKeyType checks that the value at the given offset can be used as an object key.
+func (v *validator) checkKeyType(offset ValueLength) error {
+	h := v.s[offset]
+	if (h >= 0x40 && h <= 0xbf) || (h >= 0x28 && h <= 0x39) {
+		// String, UInt or positive SmallInt
+		return nil
+	}
+	return v.fail(offset, "invalid object key type")
+}
+
+// keyName returns the (translated) name of the object key at the given offset.
+// The key itself must already be validated.
+func (v *validator) keyName(offset ValueLength) ([]byte, error) {
+	key, err := Slice(v.s[offset:]).makeKey()
+	if err != nil || !key.IsString() {
+		return nil, v.fail(offset, "cannot translate object key")
+	}
+	name, err := key.GetStringUTF8()
+	if err != nil {
+		return nil, v.fail(offset, "cannot translate object key")
+	}
+	return name, nil
+}
+
+// checkKeys checks the order and uniqueness of the keys of an indexed object.
+func (v *validator) checkKeys(offset, tableStart ValueLength, width uint, n ValueLength, sorted bool) error {
+	if !sorted && v.options.AllowDuplicateKeys {
+		return nil
+	}
+	var keys map[string]struct{}
+	if !sorted {
+		keys = make(map[string]struct{}, n)
+	}
+	var previous []byte
+	for i := ValueLength(0); i < n; i++ {
+		entryOffset := offset + tableStart + i*ValueLength(width)
+		keyOffset := offset + ValueLength(readIntegerNonEmpty(v.s[entryOffset:], width))
+		name, err := v.keyName(keyOffset)
+		if err != nil {
+			return WithStack(err)
+		}
+		if sorted {
+			if i > 0 {
+				if cmp := bytes.Compare(previous, name); cmp > 0 {
+					return v.fail(entryOffset, "object index table is not sorted")
+				} else if cmp == 0 && !v.options.AllowDuplicateKeys {
+					return v.fail(keyOffset, "duplicate key")
+				}
+			}
+			previous = name
+		} else {
+			if _, found := keys[string(name)]; found {
+				return v.fail(keyOffset, "duplicate key")
+			}
+			keys[string(name)] = struct{}{}
+		}
+	}
+	return nil
+}
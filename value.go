@@ -72,6 +72,9 @@ func NewReflectValue(v reflect.Value) Value {
 		if v, ok := raw.(time.Time); ok {
 			return NewUTCDateValue(v)
 		}
+		if v, ok := raw.(Decimal); ok {
+			return NewBCDValue(v)
+		}
 		if v, ok := raw.(Value); ok {
 			return v
 		}
@@ -117,6 +120,26 @@ func NewUTCDateValue(value time.Time) Value {
 	return Value{UTCDate, value, false}
 }
 
+// NewBCDValue creates a new Value of type BCD with given value.
+func NewBCDValue(value Decimal) Value {
+	return Value{BCD, value, false}
+}
+
+// NewTaggedValue creates a new Value that wraps the given value with the given tag.
+// Tag 0 means "no tag", in which case the given value is returned unmodified.
+func NewTaggedValue(tag uint64, value Value) Value {
+	if tag == 0 {
+		return value
+	}
+	return Value{Tagged, taggedValue{tag, value}, false}
+}
+
+// taggedValue is the data of a Value of type Tagged.
+type taggedValue struct {
+	tag   uint64
+	value Value
+}
+
 // NewSliceValue creates a new Value of from the given slice.
 func NewSliceValue(value Slice) Value {
 	return Value{value.Type(), value, false}
@@ -194,6 +217,14 @@ func (v Value) utcDateValue() int64 {
 	return sec*1000 + nsec/1000000
 }
 
+func (v Value) bcdValue() Decimal {
+	return v.data.(Decimal)
+}
+
+func (v Value) taggedValue() taggedValue {
+	return v.data.(taggedValue)
+}
+
 func (v Value) sliceValue() Slice {
 	return v.data.(Slice)
 }
@@ -43,6 +43,7 @@ const (
 	Binary
 	BCD
 	Custom
+	Tagged
 )
 
 // String returns a string representation of the given type.
@@ -69,6 +70,7 @@ var typeNames = [...]string{
 	"Binary",
 	"BCD",
 	"Custom",
+	"Tagged",
 }
 
 var typeMap = [256]ValueType{
@@ -191,7 +193,7 @@ var typeMap = [256]ValueType{
 	/* 0xe8 */ None /* 0xe9 */, None,
 	/* 0xea */ None /* 0xeb */, None,
 	/* 0xec */ None /* 0xed */, None,
-	/* 0xee */ None /* 0xef */, None,
+	/* 0xee */ Tagged /* 0xef */, Tagged,
 	/* 0xf0 */ Custom /* 0xf1 */, Custom,
 	/* 0xf2 */ Custom /* 0xf3 */, Custom,
 	/* 0xf4 */ Custom /* 0xf5 */, Custom,
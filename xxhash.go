@@ -0,0 +1,96 @@
+//
+// DISCLAIMER
+//
+// Copyright 2017 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+// Author Ewout Prangsma
+//
+
+package velocypack
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	xxPrime64_1 uint64 = 11400714785074694791
+	xxPrime64_2 uint64 = 14029467366897019727
+	xxPrime64_3 uint64 = 1609587929392839161
+	xxPrime64_4 uint64 = 9650029242287828579
+	xxPrime64_5 uint64 = 2870177450012600261
+)
+
+// xxHash64 returns the 64-bit xxHash of the given data, using the given seed.
+func xxHash64(data []byte, seed uint64) uint64 {
+	n := len(data)
+	var h uint64
+	if n >= 32 {
+		v1 := seed + xxPrime64_1 + xxPrime64_2
+		v2 := seed + xxPrime64_2
+		v3 := seed
+		v4 := seed - xxPrime64_1
+		for len(data) >= 32 {
+			v1 = xxRound64(v1, binary.LittleEndian.Uint64(data[0:]))
+			v2 = xxRound64(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxRound64(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxRound64(v4, binary.LittleEndian.Uint64(data[24:]))
+			data = data[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound64(h, v1)
+		h = xxMergeRound64(h, v2)
+		h = xxMergeRound64(h, v3)
+		h = xxMergeRound64(h, v4)
+	} else {
+		h = seed + xxPrime64_5
+	}
+	h += uint64(n)
+
+	for len(data) >= 8 {
+		h ^= xxRound64(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxPrime64_1 + xxPrime64_4
+		data = data[8:]
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxPrime64_1
+		h = bits.RotateLeft64(h, 23)*xxPrime64_2 + xxPrime64_3
+		data = data[4:]
+	}
+	for _, c := range data {
+		h ^= uint64(c) * xxPrime64_5
+		h = bits.RotateLeft64(h, 11) * xxPrime64_1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime64_2
+	h ^= h >> 29
+	h *= xxPrime64_3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound64(acc, input uint64) uint64 {
+	acc += input * xxPrime64_2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime64_1
+}
+
+func xxMergeRound64(acc, val uint64) uint64 {
+	acc ^= xxRound64(0, val)
+	return acc*xxPrime64_1 + xxPrime64_4
+}